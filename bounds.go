@@ -0,0 +1,95 @@
+package lazyconf
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// bounds holds the min= and max= options of a numeric or duration field, parsed into its element type.
+type bounds struct {
+	min, max   reflect.Value // invalid when the option is not set
+	asDuration bool
+}
+
+// parseBounds parses the min= and max= options for fieldType, or the element type of a slice.
+// Bounds of time.Duration fields, and of int64 kinds parsed as durations, are durations such as "1s".
+func parseBounds(fieldType reflect.Type, minStr, maxStr string, asDuration bool) (bounds, error) {
+	elemType := fieldType
+	if elemType.Kind() == reflect.Slice {
+		elemType = elemType.Elem()
+	}
+	if !checkIntegerKind(elemType) && !checkFloatKind(elemType) {
+		return bounds{}, fmt.Errorf("min and max options require a numeric or duration field, got %s", fieldType)
+	}
+
+	b := bounds{asDuration: asDuration && checkDurationKind(elemType) || checkTimeDuration(elemType)}
+	parse := func(s string) (reflect.Value, error) {
+		if s == "" {
+			return reflect.Value{}, nil
+		}
+		bound := reflect.New(elemType).Elem()
+		if b.asDuration {
+			dur, err := parseDuration(s)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			bound.SetInt(int64(dur))
+			return bound, nil
+		}
+		if err := setPrimitive(bound, s); err != nil {
+			return reflect.Value{}, err
+		}
+		return bound, nil
+	}
+
+	var err error
+	if b.min, err = parse(minStr); err != nil {
+		return bounds{}, fmt.Errorf("invalid min=%s: %v", minStr, err)
+	}
+	if b.max, err = parse(maxStr); err != nil {
+		return bounds{}, fmt.Errorf("invalid max=%s: %v", maxStr, err)
+	}
+	return b, nil
+}
+
+// check returns an error if the value, or any element of a slice, is outside the bounds.
+func (b bounds) check(fieldValue reflect.Value) error {
+	if fieldValue.Kind() == reflect.Slice {
+		for i := range fieldValue.Len() {
+			if err := b.check(fieldValue.Index(i)); err != nil {
+				return fmt.Errorf("element %d: %v", i, err)
+			}
+		}
+		return nil
+	}
+
+	if b.min.IsValid() && compareNumbers(fieldValue, b.min) < 0 {
+		return fmt.Errorf("value %s is below the minimum %s", b.format(fieldValue), b.format(b.min))
+	}
+	if b.max.IsValid() && compareNumbers(fieldValue, b.max) > 0 {
+		return fmt.Errorf("value %s is above the maximum %s", b.format(fieldValue), b.format(b.max))
+	}
+	return nil
+}
+
+// compareNumbers compares two values of the same integer or float kind.
+func compareNumbers(a, b reflect.Value) int {
+	switch {
+	case a.CanInt():
+		return cmp.Compare(a.Int(), b.Int())
+	case a.CanUint():
+		return cmp.Compare(a.Uint(), b.Uint())
+	default:
+		return cmp.Compare(a.Float(), b.Float())
+	}
+}
+
+// format formats a value for bound errors, in duration notation for durations.
+func (b bounds) format(v reflect.Value) string {
+	if b.asDuration {
+		return time.Duration(v.Int()).String()
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,72 @@
+package lazyconf
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseEnvBounds tests the min= and max= options on numeric and duration fields.
+func TestParseEnvBounds(t *testing.T) {
+	type Timeout time.Duration
+	type BoundsConfig struct {
+		Poll     time.Duration   `env:"BOUNDS_POLL,min=1s,max=5m"`
+		Retries  []time.Duration `env:"BOUNDS_RETRIES,min=100ms"`
+		Workers  int             `env:"BOUNDS_WORKERS,min=1,max=64"`
+		Ratio    float64         `env:"BOUNDS_RATIO,max=1"`
+		Port     uint16          `env:"BOUNDS_PORT,min=1024"`
+		Deadline Timeout         `env:"BOUNDS_DEADLINE,parser=duration,max=1h"`
+	}
+
+	cfg := &BoundsConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"BOUNDS_POLL":     "30s",
+		"BOUNDS_RETRIES":  "100ms,1s",
+		"BOUNDS_WORKERS":  "64",
+		"BOUNDS_RATIO":    "0.5",
+		"BOUNDS_PORT":     "8080",
+		"BOUNDS_DEADLINE": "1h",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Poll != 30*time.Second {
+		t.Errorf("expected Poll to be 30s, got %v", cfg.Poll)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"duration below min", map[string]string{"BOUNDS_POLL": "500ms"}, "field Poll: value 500ms is below the minimum 1s"},
+		{"duration above max", map[string]string{"BOUNDS_POLL": "1h"}, "field Poll: value 1h0m0s is above the maximum 5m0s"},
+		{"duration element below min", map[string]string{"BOUNDS_RETRIES": "1s,10ms"}, "field Retries: element 1: value 10ms is below the minimum 100ms"},
+		{"int above max", map[string]string{"BOUNDS_WORKERS": "65"}, "value 65 is above the maximum 64"},
+		{"float above max", map[string]string{"BOUNDS_RATIO": "1.5"}, "value 1.5 is above the maximum 1"},
+		{"uint below min", map[string]string{"BOUNDS_PORT": "80"}, "value 80 is below the minimum 1024"},
+		{"named duration above max", map[string]string{"BOUNDS_DEADLINE": "2h"}, "value 2h0m0s is above the maximum 1h0m0s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&BoundsConfig{}, tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+
+	type InvalidBoundConfig struct {
+		Poll time.Duration `env:"BOUNDS_INVALID_POLL,min=fast"`
+	}
+	if err := ParseEnvFromMap(&InvalidBoundConfig{}, nil); err == nil || !strings.Contains(err.Error(), "invalid min=fast") {
+		t.Errorf("expected an error for the invalid bound, got: %v", err)
+	}
+
+	type NonNumericConfig struct {
+		Name string `env:"BOUNDS_NAME,min=1"`
+	}
+	if err := ParseEnvFromMap(&NonNumericConfig{}, nil); err == nil || !strings.Contains(err.Error(), "require a numeric or duration field") {
+		t.Errorf("expected an error for bounds on a string field, got: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package lazyconf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrUnknownByteSizeSuffix is returned when a byte size carries a suffix the parser does not know.
+	ErrUnknownByteSizeSuffix = errors.New("unknown byte size suffix")
+	// ErrAmbiguousByteSizeSuffix is returned for suffixes like "K" or "M" that don't say
+	// whether a decimal (1000) or binary (1024) multiplier is meant.
+	ErrAmbiguousByteSizeSuffix = errors.New("ambiguous byte size suffix")
+)
+
+// byteSizeUnits maps lower-cased suffixes to their multipliers.
+// Decimal suffixes (KB, MB, ...) use powers of 1000, binary suffixes (KiB, MiB, ...) use powers of 1024.
+var byteSizeUnits = map[string]uint64{
+	"":    1,
+	"b":   1,
+	"kb":  1000,
+	"mb":  1000 * 1000,
+	"gb":  1000 * 1000 * 1000,
+	"tb":  1000 * 1000 * 1000 * 1000,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+}
+
+// ambiguousByteSizeUnits holds suffixes that are rejected because they could mean either base.
+var ambiguousByteSizeUnits = map[string]bool{
+	"k": true,
+	"m": true,
+	"g": true,
+	"t": true,
+}
+
+// parseByteSize parses a human-readable size such as "256MB" or "1.5GiB" into a byte count.
+func parseByteSize(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+
+	// Split the numeric part from the suffix
+	i := 0
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+		i++
+	}
+	num, suffix := s[:i], strings.ToLower(strings.TrimSpace(s[i:]))
+	if num == "" {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+
+	if ambiguousByteSizeUnits[suffix] {
+		return 0, fmt.Errorf("%w %q in %q, use %sB or %siB", ErrAmbiguousByteSizeSuffix, s[i:], s, strings.ToUpper(suffix), strings.ToUpper(suffix))
+	}
+	mult, ok := byteSizeUnits[suffix]
+	if !ok {
+		return 0, fmt.Errorf("%w %q in %q", ErrUnknownByteSizeSuffix, s[i:], s)
+	}
+
+	if !strings.Contains(num, ".") {
+		n, err := strconv.ParseUint(num, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid byte size %q: %v", s, err)
+		}
+		if n > math.MaxUint64/mult {
+			return 0, fmt.Errorf("byte size %q overflows uint64", s)
+		}
+		return n * mult, nil
+	}
+
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q: %v", s, err)
+	}
+	size := f * float64(mult)
+	if size >= math.MaxUint64 {
+		return 0, fmt.Errorf("byte size %q overflows uint64", s)
+	}
+	if size != math.Trunc(size) {
+		return 0, fmt.Errorf("byte size %q is not a whole number of bytes", s)
+	}
+	return uint64(size), nil
+}
+
+// setByteSize parses envVal as a byte size and stores the result in fieldValue.
+func setByteSize(fieldValue reflect.Value, envVal string) error {
+	size, err := parseByteSize(envVal)
+	if err != nil {
+		return err
+	}
+
+	switch fieldValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if size > math.MaxInt64 || fieldValue.OverflowInt(int64(size)) {
+			return fmt.Errorf("byte size %q overflows %s", envVal, fieldValue.Type())
+		}
+		fieldValue.SetInt(int64(size))
+	default:
+		if fieldValue.OverflowUint(size) {
+			return fmt.Errorf("byte size %q overflows %s", envVal, fieldValue.Type())
+		}
+		fieldValue.SetUint(size)
+	}
+	return nil
+}
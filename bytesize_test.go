@@ -0,0 +1,118 @@
+package lazyconf
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// TestParseByteSize tests parsing of human-readable byte sizes.
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected uint64
+	}{
+		{"512", 512},
+		{"512B", 512},
+		{"1KB", 1000},
+		{"1kb", 1000},
+		{"256MB", 256000000},
+		{"2GB", 2000000000},
+		{"1KiB", 1024},
+		{"1.5KiB", 1536},
+		{"64MiB", 64 << 20},
+		{"1GiB", 1 << 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseByteSize(tt.in)
+			if err != nil {
+				t.Fatalf("parseByteSize returned an error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestParseByteSizeErrors tests that malformed, ambiguous and unknown sizes are rejected.
+func TestParseByteSizeErrors(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected error
+	}{
+		{"10K", ErrAmbiguousByteSizeSuffix},
+		{"10m", ErrAmbiguousByteSizeSuffix},
+		{"10XB", ErrUnknownByteSizeSuffix},
+		{"10 bytes", ErrUnknownByteSizeSuffix},
+		{"MB", nil},
+		{"0.1B", nil},
+		{"-1KB", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			_, err := parseByteSize(tt.in)
+			if err == nil {
+				t.Fatalf("expected an error for %q, but got none", tt.in)
+			}
+			if tt.expected != nil && !errors.Is(err, tt.expected) {
+				t.Errorf("expected error to wrap %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+// TestParseEnvParserByteSize tests parser="bytesize" on scalar and slice integer fields.
+func TestParseEnvParserByteSize(t *testing.T) {
+	type ByteSizeConfig struct {
+		CacheSize  int64    `env:"BYTESIZE_CACHE,parser=bytesize"`
+		BufferSize uint32   `env:"BYTESIZE_BUFFER,parser=bytesize"`
+		Limits     []uint64 `env:"BYTESIZE_LIMITS,parser=bytesize"`
+	}
+
+	_ = os.Setenv("BYTESIZE_CACHE", "256MB")
+	_ = os.Setenv("BYTESIZE_BUFFER", "64KiB")
+	_ = os.Setenv("BYTESIZE_LIMITS", "1GB,1GiB,100")
+
+	cfg := &ByteSizeConfig{}
+	err := ParseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.CacheSize != 256000000 {
+		t.Errorf("expected CacheSize to be 256000000, got %d", cfg.CacheSize)
+	}
+	if cfg.BufferSize != 65536 {
+		t.Errorf("expected BufferSize to be 65536, got %d", cfg.BufferSize)
+	}
+	expected := []uint64{1000000000, 1 << 30, 100}
+	if !reflect.DeepEqual(cfg.Limits, expected) {
+		t.Errorf("expected Limits to be %v, got %v", expected, cfg.Limits)
+	}
+}
+
+// TestParseEnvParserByteSizeError tests error handling for parser="bytesize".
+func TestParseEnvParserByteSizeError(t *testing.T) {
+	type ByteSizeConfig struct {
+		Small uint8 `env:"BYTESIZE_SMALL,parser=bytesize"`
+		Size  int64 `env:"BYTESIZE_SIZE,parser=bytesize"`
+	}
+
+	_ = os.Setenv("BYTESIZE_SMALL", "1KB")
+	err := ParseEnv(&ByteSizeConfig{})
+	if err == nil {
+		t.Fatal("expected an error when byte size overflows uint8, but got none")
+	}
+
+	_ = os.Setenv("BYTESIZE_SMALL", "1")
+	_ = os.Setenv("BYTESIZE_SIZE", "5G")
+	err = ParseEnv(&ByteSizeConfig{})
+	if !errors.Is(err, ErrAmbiguousByteSizeSuffix) {
+		t.Fatalf("expected ErrAmbiguousByteSizeSuffix, got %v", err)
+	}
+}
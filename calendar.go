@@ -0,0 +1,58 @@
+package lazyconf
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// monthNames and weekdayNames map the lowercase English names and three-letter abbreviations of months and
+// weekdays to their values.
+var (
+	monthNames   = make(map[string]time.Month)
+	weekdayNames = make(map[string]time.Weekday)
+)
+
+func init() {
+	for m := time.January; m <= time.December; m++ {
+		name := strings.ToLower(m.String())
+		monthNames[name], monthNames[name[:3]] = m, m
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		weekdayNames[name], weekdayNames[name[:3]] = d, d
+	}
+}
+
+// checkCalendarEnum reports whether the type is time.Month or time.Weekday.
+func checkCalendarEnum(fieldType reflect.Type) bool {
+	return fieldType == reflect.TypeOf(time.Month(0)) || fieldType == reflect.TypeOf(time.Weekday(0))
+}
+
+// parseCalendarEnum parses a time.Month (1-12) or time.Weekday (0-6, Sunday first) from its number
+// or its case-insensitive English name, full or abbreviated, e.g. "January", "jan" or "1".
+func parseCalendarEnum(fieldType reflect.Type, s string) (int64, error) {
+	lo, hi := int64(time.January), int64(time.December)
+	if fieldType == reflect.TypeOf(time.Weekday(0)) {
+		lo, hi = int64(time.Sunday), int64(time.Saturday)
+	}
+
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if n < lo || n > hi {
+			return 0, fmt.Errorf("%s %d out of range %d to %d", fieldType, n, lo, hi)
+		}
+		return n, nil
+	}
+
+	name := strings.ToLower(strings.TrimSpace(s))
+	if fieldType == reflect.TypeOf(time.Weekday(0)) {
+		if d, ok := weekdayNames[name]; ok {
+			return int64(d), nil
+		}
+	} else if m, ok := monthNames[name]; ok {
+		return int64(m), nil
+	}
+	return 0, fmt.Errorf("unrecognized %s %q", fieldType, s)
+}
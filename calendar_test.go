@@ -0,0 +1,67 @@
+package lazyconf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseEnvCalendarEnums tests parsing time.Month and time.Weekday fields by number and by name.
+func TestParseEnvCalendarEnums(t *testing.T) {
+	type CalendarConfig struct {
+		Month    time.Month     `env:"CALENDAR_MONTH"`
+		Short    time.Month     `env:"CALENDAR_SHORT"`
+		Numeric  time.Month     `env:"CALENDAR_NUMERIC"`
+		Day      time.Weekday   `env:"CALENDAR_DAY"`
+		Sunday   time.Weekday   `env:"CALENDAR_SUNDAY"`
+		Workdays []time.Weekday `env:"CALENDAR_WORKDAYS"`
+		Quarters []time.Month   `env:"CALENDAR_QUARTERS"`
+	}
+
+	cfg := &CalendarConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"CALENDAR_MONTH":    "January",
+		"CALENDAR_SHORT":    "sep",
+		"CALENDAR_NUMERIC":  "12",
+		"CALENDAR_DAY":      "MONDAY",
+		"CALENDAR_SUNDAY":   "0",
+		"CALENDAR_WORKDAYS": "mon,Tuesday,3",
+		"CALENDAR_QUARTERS": "1,apr,July,10",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.Month != time.January || cfg.Short != time.September || cfg.Numeric != time.December {
+		t.Errorf("expected months January, September, December, got %v, %v, %v", cfg.Month, cfg.Short, cfg.Numeric)
+	}
+	if cfg.Day != time.Monday || cfg.Sunday != time.Sunday {
+		t.Errorf("expected days Monday and Sunday, got %v and %v", cfg.Day, cfg.Sunday)
+	}
+	if !reflect.DeepEqual(cfg.Workdays, []time.Weekday{time.Monday, time.Tuesday, time.Wednesday}) {
+		t.Errorf("expected Workdays to be [Monday Tuesday Wednesday], got %v", cfg.Workdays)
+	}
+	if !reflect.DeepEqual(cfg.Quarters, []time.Month{time.January, time.April, time.July, time.October}) {
+		t.Errorf("expected Quarters to be [January April July October], got %v", cfg.Quarters)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"unknown month", map[string]string{"CALENDAR_MONTH": "Smarch"}, `field Month: unrecognized time.Month "Smarch"`},
+		{"month out of range", map[string]string{"CALENDAR_MONTH": "13"}, "out of range 1 to 12"},
+		{"weekday out of range", map[string]string{"CALENDAR_DAY": "7"}, "out of range 0 to 6"},
+		{"unknown element", map[string]string{"CALENDAR_WORKDAYS": "mon,funday"}, "invalid element 1 of field Workdays"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&CalendarConfig{}, tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package lazyconf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// envKeys lists the names of all variables visible through the options' Lookup. A custom Lookup
+// can't be enumerated, so it requires ListKeys.
+func (o ParseEnvOptions) envKeys() ([]string, error) {
+	if o.ListKeys != nil {
+		return o.ListKeys(), nil
+	}
+	if o.Lookup != nil {
+		return nil, errors.New("collectprefix option requires ListKeys when a custom Lookup is set")
+	}
+	return environKeys(), nil
+}
+
+// environKeys lists the names of the variables in the process environment.
+func environKeys() []string {
+	environ := os.Environ()
+	keys := make([]string, 0, len(environ))
+	for _, kv := range environ {
+		key, _, _ := strings.Cut(kv, "=")
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// mapKeys returns a ListKeys function listing the keys of vars.
+func mapKeys(vars map[string]string) func() []string {
+	return func() []string {
+		keys := make([]string, 0, len(vars))
+		for key := range vars {
+			keys = append(keys, key)
+		}
+		return keys
+	}
+}
+
+// collectPrefixed populates a map with string keys from every variable whose name starts with prefix,
+// keyed by the rest of the name as normalized by folder. Variables are read in sorted order. The map is
+// replaced even if no variable matches, leaving it empty.
+func collectPrefixed(fieldValue reflect.Value, prefix string, folder *keyFolder, opts ParseEnvOptions) error {
+	fieldType := fieldValue.Type()
+	if fieldType.Kind() != reflect.Map || fieldType.Key().Kind() != reflect.String || !checkPrimitive(fieldType.Elem()) {
+		return fmt.Errorf("collectprefix option requires a map with string keys, got %s", fieldType)
+	}
+
+	keys, err := opts.envKeys()
+	if err != nil {
+		return err
+	}
+
+	slices.Sort(keys)
+	refMap := reflect.MakeMap(fieldType)
+	for _, key := range keys {
+		name, ok := strings.CutPrefix(key, prefix)
+		if !ok || name == "" {
+			continue
+		}
+		name, err := folder.apply(name)
+		if err != nil {
+			return err
+		}
+		val, _ := opts.lookup(key)
+		elem := reflect.New(fieldType.Elem()).Elem()
+		if err := setPrimitive(elem, val); err != nil {
+			return fmt.Errorf("invalid value %q for key %s: %v", val, key, err)
+		}
+		refMap.SetMapIndex(reflect.ValueOf(name).Convert(fieldType.Key()), elem)
+	}
+	fieldValue.Set(refMap)
+	return nil
+}
@@ -0,0 +1,99 @@
+package lazyconf
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseEnvCollectPrefix tests collecting every variable with a prefix into a map.
+func TestParseEnvCollectPrefix(t *testing.T) {
+	type CollectConfig struct {
+		Features map[string]string `env:"COLLECT_FEATURE_,collectprefix"`
+		Limits   map[string]int    `env:"COLLECT_LIMIT_,collectprefix"`
+		Labels   map[string]string `env:"COLLECT_LABEL_,collectprefix"`
+	}
+
+	cfg := &CollectConfig{Labels: map[string]string{"stale": "x"}}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"COLLECT_FEATURE_DARK_MODE": "on",
+		"COLLECT_FEATURE_BETA":      "",
+		"COLLECT_FEATURE_":          "ignored",
+		"COLLECT_LIMIT_UPLOADS":     "10",
+		"COLLECT_OTHER":             "ignored",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if len(cfg.Features) != 2 || cfg.Features["DARK_MODE"] != "on" {
+		t.Errorf("expected Features to be map[BETA: DARK_MODE:on], got %v", cfg.Features)
+	}
+	if _, ok := cfg.Features["BETA"]; !ok {
+		t.Errorf("expected Features to contain the empty BETA value, got %v", cfg.Features)
+	}
+	if cfg.Limits["UPLOADS"] != 10 {
+		t.Errorf("expected Limits[UPLOADS] to be 10, got %v", cfg.Limits)
+	}
+	if cfg.Labels == nil || len(cfg.Labels) != 0 {
+		t.Errorf("expected Labels to be an empty, non-nil map, got %#v", cfg.Labels)
+	}
+
+	env, err := DumpEnv(cfg)
+	if err != nil {
+		t.Fatalf("DumpEnv returned an error: %v", err)
+	}
+	if env["COLLECT_FEATURE_DARK_MODE"] != "on" || env["COLLECT_LIMIT_UPLOADS"] != "10" {
+		t.Errorf("expected DumpEnv to write one variable per entry, got %v", env)
+	}
+}
+
+// TestParseEnvCollectPrefixNested tests collectprefix inside a prefixed struct and in the process environment.
+func TestParseEnvCollectPrefixNested(t *testing.T) {
+	type Plugin struct {
+		_        struct{}          `env:",prefix=COLLECT_PLUGIN_"`
+		Settings map[string]string `env:"OPT_,collectprefix,required"`
+	}
+	type NestedCollectConfig struct {
+		Plugin Plugin
+	}
+
+	t.Setenv("COLLECT_PLUGIN_OPT_PATH", "/tmp")
+	t.Setenv("COLLECT_PLUGIN_OPT_MODE", "fast")
+
+	cfg := &NestedCollectConfig{}
+	if err := ParseEnv(cfg); err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if len(cfg.Plugin.Settings) != 2 || cfg.Plugin.Settings["PATH"] != "/tmp" || cfg.Plugin.Settings["MODE"] != "fast" {
+		t.Errorf("expected Settings to be map[MODE:fast PATH:/tmp], got %v", cfg.Plugin.Settings)
+	}
+
+	err := ParseEnvFromMap(&NestedCollectConfig{}, map[string]string{"COLLECT_PLUGIN_PATH": "/tmp"})
+	if err == nil || !strings.Contains(err.Error(), "required field Plugin.Settings has no variables with prefix OPT_") {
+		t.Errorf("expected an error for the required map without variables, got: %v", err)
+	}
+}
+
+// TestParseEnvCollectPrefixErrors tests the errors of the collectprefix option.
+func TestParseEnvCollectPrefixErrors(t *testing.T) {
+	type SliceConfig struct {
+		Features []string `env:"COLLECT_ERR_,collectprefix"`
+	}
+	if err := ParseEnvFromMap(&SliceConfig{}, nil); err == nil || !strings.Contains(err.Error(), "requires a map with string keys") {
+		t.Errorf("expected an error for collectprefix on a slice, got: %v", err)
+	}
+
+	type IntConfig struct {
+		Limits map[string]int `env:"COLLECT_ERR_LIMIT_,collectprefix"`
+	}
+	err := ParseEnvFromMap(&IntConfig{}, map[string]string{"COLLECT_ERR_LIMIT_X": "many"})
+	if err == nil || !strings.Contains(err.Error(), `invalid value "many" for key COLLECT_ERR_LIMIT_X`) {
+		t.Errorf("expected an error for the invalid map value, got: %v", err)
+	}
+
+	opts := ParseEnvOptions{Lookup: mapLookup(map[string]string{"COLLECT_ERR_LIMIT_X": "1"})}
+	err = ParseEnvWithOptions(&IntConfig{}, opts)
+	if err == nil || !strings.Contains(err.Error(), "requires ListKeys") {
+		t.Errorf("expected an error for a custom Lookup without ListKeys, got: %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package lazyconf
+
+import (
+	"encoding/hex"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	RegisterType(parseRGBA)
+}
+
+// parseRGBA parses a color written as "#RRGGBB", "#RRGGBBAA" or as decimal "r,g,b" or "r,g,b,a"
+// components. The alpha channel defaults to 255.
+func parseRGBA(s string) (color.RGBA, error) {
+	if hexDigits, ok := strings.CutPrefix(s, "#"); ok {
+		b, err := hex.DecodeString(hexDigits)
+		if err != nil || (len(b) != 3 && len(b) != 4) {
+			return color.RGBA{}, fmt.Errorf("invalid color %q, expected #RRGGBB or #RRGGBBAA", s)
+		}
+		if len(b) == 3 {
+			b = append(b, 255)
+		}
+		return color.RGBA{R: b[0], G: b[1], B: b[2], A: b[3]}, nil
+	}
+
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 && len(parts) != 4 {
+		return color.RGBA{}, fmt.Errorf("invalid color %q, expected #RRGGBB, #RRGGBBAA or r,g,b,a", s)
+	}
+	c := [4]uint8{3: 255}
+	for i, part := range parts {
+		n, err := strconv.ParseUint(strings.TrimSpace(part), 10, 8)
+		if err != nil {
+			return color.RGBA{}, fmt.Errorf("invalid color component %q in %q", part, s)
+		}
+		c[i] = uint8(n)
+	}
+	return color.RGBA{R: c[0], G: c[1], B: c[2], A: c[3]}, nil
+}
+
+// formatRGBA formats a color as "#RRGGBBAA", which parseRGBA reads back.
+func formatRGBA(c color.RGBA) string {
+	return "#" + hex.EncodeToString([]byte{c.R, c.G, c.B, c.A})
+}
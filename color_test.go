@@ -0,0 +1,69 @@
+package lazyconf
+
+import (
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseEnvColor tests parsing color.RGBA fields through the pre-registered type parser.
+func TestParseEnvColor(t *testing.T) {
+	type ColorConfig struct {
+		Background color.RGBA   `env:"COLOR_BACKGROUND"`
+		Overlay    color.RGBA   `env:"COLOR_OVERLAY"`
+		Border     color.RGBA   `env:"COLOR_BORDER"`
+		Palette    []color.RGBA `env:"COLOR_PALETTE,delim=;"`
+	}
+
+	cfg := &ColorConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"COLOR_BACKGROUND": "#ff8000",
+		"COLOR_OVERLAY":    "#00000080",
+		"COLOR_BORDER":     "10, 20, 30, 40",
+		"COLOR_PALETTE":    "#ff0000;0,255,0",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	expected := map[string]color.RGBA{
+		"Background": {255, 128, 0, 255},
+		"Overlay":    {0, 0, 0, 128},
+		"Border":     {10, 20, 30, 40},
+	}
+	for name, want := range expected {
+		if got := reflect.ValueOf(cfg).Elem().FieldByName(name).Interface().(color.RGBA); got != want {
+			t.Errorf("expected %s to be %v, got %v", name, want, got)
+		}
+	}
+	if !reflect.DeepEqual(cfg.Palette, []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}}) {
+		t.Errorf("expected Palette to be [red green], got %v", cfg.Palette)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"short hex", map[string]string{"COLOR_BACKGROUND": "#fff"}, "field Background"},
+		{"component overflow", map[string]string{"COLOR_BORDER": "256,0,0"}, "invalid color component"},
+		{"malformed element", map[string]string{"COLOR_PALETTE": "#ff0000;red"}, "invalid element 1 of field Palette"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&ColorConfig{}, tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+
+	env, err := DumpEnv(cfg)
+	if err != nil {
+		t.Fatalf("DumpEnv returned an error: %v", err)
+	}
+	if env["COLOR_OVERLAY"] != "#00000080" {
+		t.Errorf("expected COLOR_OVERLAY to be dumped as #00000080, got %q", env["COLOR_OVERLAY"])
+	}
+}
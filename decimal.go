@@ -0,0 +1,105 @@
+package lazyconf
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// Decimal is an exact fixed-point number, an integer mantissa scaled by a power of ten, e.g. "12.34"
+// is 1234 with scale 2. Populate it with the parser=decimal tag option.
+type Decimal struct {
+	mantissa int64
+	scale    int
+}
+
+// NewDecimal returns the decimal mantissa * 10^-scale.
+func NewDecimal(mantissa int64, scale int) Decimal {
+	return Decimal{mantissa: mantissa, scale: scale}
+}
+
+// Mantissa returns the unscaled integer value, e.g. 1234 for "12.34".
+func (d Decimal) Mantissa() int64 {
+	return d.mantissa
+}
+
+// Scale returns the number of digits after the decimal point, e.g. 2 for "12.34" and "12.30".
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+// Float64 returns the nearest float64 to the decimal.
+func (d Decimal) Float64() float64 {
+	return float64(d.mantissa) / math.Pow10(d.scale)
+}
+
+// String formats the decimal with all digits of its scale, including trailing zeros, e.g. "12.30".
+func (d Decimal) String() string {
+	digits := fmt.Sprint(d.mantissa)
+	sign := ""
+	if d.mantissa < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if d.scale <= 0 {
+		if d.mantissa == 0 {
+			return digits
+		}
+		// A negative scale multiplies the mantissa, e.g. 12 with scale -1 is 120
+		return sign + digits + strings.Repeat("0", -d.scale)
+	}
+	if len(digits) <= d.scale {
+		digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-d.scale] + "." + digits[len(digits)-d.scale:]
+}
+
+// parseDecimal parses an optionally signed decimal such as "12.34" or "-0.05" exactly.
+func parseDecimal(s string) (Decimal, error) {
+	num := s
+	neg := false
+	if num != "" && (num[0] == '-' || num[0] == '+') {
+		neg = num[0] == '-'
+		num = num[1:]
+	}
+
+	intPart, fracPart, hasFrac := strings.Cut(num, ".")
+	if intPart == "" || (hasFrac && fracPart == "") {
+		return Decimal{}, fmt.Errorf("malformed decimal %q", s)
+	}
+
+	var d Decimal
+	for _, c := range intPart + fracPart {
+		if c < '0' || c > '9' {
+			return Decimal{}, fmt.Errorf("malformed decimal %q", s)
+		}
+		digit := int64(c - '0')
+		if d.mantissa > (math.MaxInt64-digit)/10 {
+			return Decimal{}, fmt.Errorf("decimal %q is out of range", s)
+		}
+		d.mantissa = d.mantissa*10 + digit
+	}
+	d.scale = len(fracPart)
+	if neg {
+		d.mantissa = -d.mantissa
+	}
+	return d, nil
+}
+
+// setDecimal parses envVal as a decimal and stores it in the Decimal fieldValue.
+func setDecimal(fieldValue reflect.Value, envVal string) error {
+	d, err := parseDecimal(strings.TrimSpace(envVal))
+	if err != nil {
+		return err
+	}
+	fieldValue.Set(reflect.ValueOf(d))
+	return nil
+}
+
+// checkDecimal reports whether the type is Decimal or a slice of Decimal.
+func checkDecimal(fieldType reflect.Type) bool {
+	if fieldType.Kind() == reflect.Slice {
+		fieldType = fieldType.Elem()
+	}
+	return fieldType == reflect.TypeOf(Decimal{})
+}
@@ -0,0 +1,83 @@
+package lazyconf
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseEnvDecimal tests parser=decimal on Decimal fields and slices.
+func TestParseEnvDecimal(t *testing.T) {
+	type DecimalConfig struct {
+		Price    Decimal   `env:"DECIMAL_PRICE,parser=decimal"`
+		Discount Decimal   `env:"DECIMAL_DISCOUNT,parser=decimal"`
+		Refund   Decimal   `env:"DECIMAL_REFUND,parser=decimal"`
+		Whole    Decimal   `env:"DECIMAL_WHOLE,parser=decimal"`
+		Tiers    []Decimal `env:"DECIMAL_TIERS,parser=decimal"`
+	}
+
+	cfg := &DecimalConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"DECIMAL_PRICE":    "12.34",
+		"DECIMAL_DISCOUNT": "1.50",
+		"DECIMAL_REFUND":   "-0.05",
+		"DECIMAL_WHOLE":    "+42",
+		"DECIMAL_TIERS":    "0.1,2.00,-3",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		got      Decimal
+		mantissa int64
+		scale    int
+		str      string
+	}{
+		{"Price", cfg.Price, 1234, 2, "12.34"},
+		{"Discount", cfg.Discount, 150, 2, "1.50"},
+		{"Refund", cfg.Refund, -5, 2, "-0.05"},
+		{"Whole", cfg.Whole, 42, 0, "42"},
+		{"Tiers[1]", cfg.Tiers[1], 200, 2, "2.00"},
+		{"Tiers[2]", cfg.Tiers[2], -3, 0, "-3"},
+	}
+	for _, tt := range tests {
+		if tt.got.Mantissa() != tt.mantissa || tt.got.Scale() != tt.scale || tt.got.String() != tt.str {
+			t.Errorf("expected %s to be %s (%d, scale %d), got %s (%d, scale %d)",
+				tt.name, tt.str, tt.mantissa, tt.scale, tt.got, tt.got.Mantissa(), tt.got.Scale())
+		}
+	}
+	if cfg.Discount.Float64() != 1.5 {
+		t.Errorf("expected Discount.Float64() to be 1.5, got %v", cfg.Discount.Float64())
+	}
+	if len(cfg.Tiers) != 3 {
+		t.Errorf("expected 3 Tiers, got %v", cfg.Tiers)
+	}
+
+	env, err := DumpEnv(cfg)
+	if err != nil {
+		t.Fatalf("DumpEnv returned an error: %v", err)
+	}
+	if env["DECIMAL_DISCOUNT"] != "1.50" || env["DECIMAL_TIERS"] != "0.1,2.00,-3" {
+		t.Errorf("expected decimals to be dumped exactly, got %q and %q", env["DECIMAL_DISCOUNT"], env["DECIMAL_TIERS"])
+	}
+
+	for _, value := range []string{"abc", "1.2.3", "12.", ".5", "-", "1e3", "1,5", "99999999999999999999"} {
+		t.Run(value, func(t *testing.T) {
+			err := ParseEnvFromMap(&DecimalConfig{}, map[string]string{"DECIMAL_PRICE": value})
+			if err == nil || !strings.Contains(err.Error(), "field Price: invalid decimal value") {
+				t.Errorf("expected an error for %q, got: %v", value, err)
+			}
+		})
+	}
+
+	if got := NewDecimal(-7, 3).String(); got != "-0.007" {
+		t.Errorf("expected NewDecimal(-7, 3) to be -0.007, got %s", got)
+	}
+	if d := NewDecimal(-12, -1); d.String() != "-120" || d.Float64() != -120 {
+		t.Errorf("expected NewDecimal(-12, -1) to be -120, got %s and %v", d.String(), d.Float64())
+	}
+	if got := NewDecimal(0, -2).String(); got != "0" {
+		t.Errorf("expected NewDecimal(0, -2) to be 0, got %s", got)
+	}
+}
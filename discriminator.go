@@ -0,0 +1,66 @@
+package lazyconf
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// discriminator holds the index of the field of a struct tagged with the discriminator option,
+// whose parsed value selects the fields tagged with a matching when= option.
+type discriminator struct {
+	field string
+	index int
+}
+
+// readDiscriminator finds the struct's discriminator field. It returns nil if the struct has none.
+func readDiscriminator(structType reflect.Type, opts ParseEnvOptions) (*discriminator, error) {
+	var d *discriminator
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		tag := field.Tag.Get("env")
+		if isIgnoredTag(tag) || !hasTagOption(tag, "discriminator") {
+			continue
+		}
+		if d != nil {
+			return nil, fmt.Errorf("fields %s and %s are both tagged discriminator", opts.path+d.field, opts.path+field.Name)
+		}
+		if !field.IsExported() {
+			return nil, fmt.Errorf("discriminator field %s is not exported", opts.path+field.Name)
+		}
+		d = &discriminator{field: field.Name, index: i}
+	}
+	return d, nil
+}
+
+// order returns the indices of the n fields of the struct in the order they are parsed: the
+// discriminator first, so its value is set before the fields it selects, then the others.
+func (d *discriminator) order(n int) []int {
+	indices := make([]int, 0, n)
+	if d != nil {
+		indices = append(indices, d.index)
+	}
+	for i := range n {
+		if d == nil || i != d.index {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// selects reports whether the field with the given tag is parsed, and whether it is a variant gated
+// by a when= option. Fields without one always are parsed, the others only if their space-separated
+// list contains the value of the discriminator field in v, which is parsed first.
+func (d *discriminator) selects(tag string, v reflect.Value) (selected, variant bool, err error) {
+	for _, opt := range splitTag(tag)[1:] {
+		if !strings.HasPrefix(opt, "when=") {
+			continue
+		}
+		if d == nil {
+			return false, true, fmt.Errorf("when option requires a field tagged discriminator in the same struct")
+		}
+		return slices.Contains(strings.Fields(strings.TrimPrefix(opt, "when=")), fmt.Sprint(v.Field(d.index).Interface())), true, nil
+	}
+	return true, false, nil
+}
@@ -0,0 +1,151 @@
+package lazyconf
+
+import (
+	"strings"
+	"testing"
+)
+
+type discS3Config struct {
+	Bucket string `env:"DISC_S3_BUCKET,required"`
+	Region string `env:"DISC_S3_REGION,default=us-east-1"`
+}
+
+type discDiskConfig struct {
+	Path string `env:"DISC_DISK_PATH,required"`
+}
+
+type discStorageConfig struct {
+	Type  string          `env:"DISC_TYPE,discriminator,oneof=s3 disk memory,default=memory"`
+	S3    discS3Config    `env:",when=s3"`
+	Disk  *discDiskConfig `env:",when=disk"`
+	Cache int             `env:"DISC_CACHE,when=s3 disk"`
+}
+
+// TestParseEnvDiscriminator tests that the discriminator selects which nested blocks are parsed and required.
+func TestParseEnvDiscriminator(t *testing.T) {
+	cfg := &discStorageConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"DISC_TYPE":      "s3",
+		"DISC_S3_BUCKET": "backups",
+		"DISC_CACHE":     "64",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.S3.Bucket != "backups" || cfg.S3.Region != "us-east-1" {
+		t.Errorf("expected S3 to be {backups us-east-1}, got %+v", cfg.S3)
+	}
+	if cfg.Disk != nil {
+		t.Errorf("expected Disk to stay nil, got %+v", cfg.Disk)
+	}
+	if cfg.Cache != 64 {
+		t.Errorf("expected Cache to be 64, got %d", cfg.Cache)
+	}
+
+	cfg = &discStorageConfig{}
+	err = ParseEnvFromMap(cfg, map[string]string{
+		"DISC_TYPE":      "disk",
+		"DISC_DISK_PATH": "/var/data",
+		"DISC_S3_BUCKET": "ignored",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Disk == nil || cfg.Disk.Path != "/var/data" {
+		t.Errorf("expected Disk.Path to be /var/data, got %+v", cfg.Disk)
+	}
+	if cfg.S3.Bucket != "" || cfg.S3.Region != "" {
+		t.Errorf("expected the unselected S3 block to stay zero, got %+v", cfg.S3)
+	}
+
+	cfg = &discStorageConfig{}
+	if err := ParseEnvFromMap(cfg, map[string]string{"DISC_CACHE": "64"}); err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Type != "memory" || cfg.Cache != 0 {
+		t.Errorf("expected the default memory type to select no block, got %+v", cfg)
+	}
+}
+
+// TestParseEnvDiscriminatorErrors tests the errors of the discriminator and when options.
+func TestParseEnvDiscriminatorErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"selected block missing required", map[string]string{"DISC_TYPE": "s3"}, "required environment variable DISC_S3_BUCKET for field S3.Bucket not set"},
+		{"selected pointer block missing required", map[string]string{"DISC_TYPE": "disk"}, "required environment variable DISC_DISK_PATH"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&discStorageConfig{}, tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+
+	type NoDiscriminatorConfig struct {
+		S3 discS3Config `env:",when=s3"`
+	}
+	err := ParseEnvFromMap(&NoDiscriminatorConfig{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "field S3: when option requires a field tagged discriminator") {
+		t.Errorf("expected an error for when without a discriminator, got: %v", err)
+	}
+
+	type TwoDiscriminatorsConfig struct {
+		Type string `env:"DISC_A,discriminator"`
+		Kind string `env:"DISC_B,discriminator"`
+	}
+	err = ParseEnvFromMap(&TwoDiscriminatorsConfig{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "fields Type and Kind are both tagged discriminator") {
+		t.Errorf("expected an error for two discriminators, got: %v", err)
+	}
+}
+
+type discResolvedConfig struct {
+	Cache int    `env:"DISC_RESOLVED_CACHE,when=redis"`
+	Type  string `env:"DISC_RESOLVED_TYPE,discriminator,transform=lower"`
+}
+
+// DefaultType computes the discriminator when neither its variable nor a default= is set.
+func (c *discResolvedConfig) DefaultType() string {
+	return "redis"
+}
+
+// TestParseEnvDiscriminatorResolved tests that the discriminator is resolved like any other field,
+// through transforms, default indirection and Default<Field> methods, before the fields it selects.
+func TestParseEnvDiscriminatorResolved(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{"transformed value", map[string]string{"DISC_RESOLVED_TYPE": "REDIS", "DISC_RESOLVED_CACHE": "64"}},
+		{"default method", map[string]string{"DISC_RESOLVED_CACHE": "64"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &discResolvedConfig{}
+			if err := ParseEnvFromMap(cfg, tt.env); err != nil {
+				t.Fatalf("ParseEnv returned an error: %v", err)
+			}
+			if cfg.Type != "redis" || cfg.Cache != 64 {
+				t.Errorf("expected the redis variant to be selected, got %+v", cfg)
+			}
+		})
+	}
+
+	type IndirectConfig struct {
+		Type  string `env:"DISC_INDIRECT_TYPE,discriminator,default=$DISC_INDIRECT_KIND"`
+		Cache int    `env:"DISC_INDIRECT_CACHE,when=redis"`
+	}
+	cfg := &IndirectConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{"DISC_INDIRECT_KIND": "redis", "DISC_INDIRECT_CACHE": "64"})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Cache != 64 {
+		t.Errorf("expected the default indirection to select the redis variant, got %+v", cfg)
+	}
+}
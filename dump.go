@@ -0,0 +1,251 @@
+package lazyconf
+
+import (
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"net"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DumpEnv serializes the tagged fields of the struct pointed to by cfg back into
+// environment variable values keyed by their env keys. Values are formatted so that
+// ParseEnv reads them back into the same field values.
+func DumpEnv(cfg any) (map[string]string, error) {
+	if err := checkStructPointer(cfg); err != nil {
+		return nil, fmt.Errorf("xconf.DumpEnv: DumpEnv %v", err)
+	}
+
+	env := make(map[string]string)
+	if err := dumpEnv(reflect.ValueOf(cfg), "", "", env); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// dumpEnv writes the tagged fields of the struct pointed to by val into env. The path names the
+// struct within the root config so that errors in nested structs can be traced.
+func dumpEnv(val reflect.Value, prefix, path string, env map[string]string) error {
+	op := "xconf.DumpEnv"
+
+	v := val.Elem()
+	t := v.Type()
+	prefix += structPrefix(t)
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("env")
+
+		// Skip ignored and unexported fields, the latter values can't be read
+		if isIgnoredTag(tag) || !field.IsExported() {
+			continue
+		}
+
+		// If the field is a struct, recursively dump it
+		if field.Type.Kind() == reflect.Struct {
+			if err := dumpEnv(v.Field(i).Addr(), prefix, path+field.Name+".", env); err != nil {
+				return err
+			}
+		}
+
+		// If the field is a non-nil pointer to a struct, recursively dump it
+		if field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct && !checkRegexp(field.Type) {
+			if !v.Field(i).IsNil() {
+				if err := dumpEnv(v.Field(i), prefix, path+field.Name+".", env); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		// If the field is not tagged, skip it
+		if tag == "" {
+			continue
+		}
+
+		parts := splitTag(tag)
+		envKey := parts[0]
+		if envKey == "_" {
+			continue
+		}
+
+		parserType, layout := "", ""
+		delim, innerDelim, mapSep, kvSep := ",", ":", ",", ":"
+		collect := false
+		for _, opt := range parts[1:] {
+			if strings.HasPrefix(opt, "parser=") {
+				parserType = strings.TrimPrefix(opt, "parser=")
+			} else if opt == "collectprefix" {
+				collect = true
+			} else if strings.HasPrefix(opt, "layout=") {
+				layout = strings.TrimPrefix(opt, "layout=")
+			} else if strings.HasPrefix(opt, "layouts=") {
+				layout = strings.TrimPrefix(opt, "layouts=")
+			} else if strings.HasPrefix(opt, "delim=") {
+				delim = unescapeSeparator(strings.TrimPrefix(opt, "delim="))
+			} else if strings.HasPrefix(opt, "innerdelim=") {
+				innerDelim = unescapeSeparator(strings.TrimPrefix(opt, "innerdelim="))
+			} else if strings.HasPrefix(opt, "mapsep=") {
+				mapSep = unescapeSeparator(strings.TrimPrefix(opt, "mapsep="))
+			} else if strings.HasPrefix(opt, "kvsep=") {
+				kvSep = unescapeSeparator(strings.TrimPrefix(opt, "kvsep="))
+			}
+		}
+
+		// Times with an explicit layout must be written back in that layout to parse again
+		if layout != "" && checkTime(field.Type) {
+			env[prefix+envKey] = formatTime(v.Field(i).Interface().(time.Time), layout)
+			continue
+		}
+
+		// Collected maps are written back as one variable per entry
+		if collect && field.Type.Kind() == reflect.Map {
+			iter := v.Field(i).MapRange()
+			for iter.Next() {
+				env[prefix+envKey+iter.Key().String()] = fmt.Sprint(iter.Value().Interface())
+			}
+			continue
+		}
+
+		// Maps are written as sorted key/value pairs using the field's separators, sets as sorted keys
+		// and url.Values as an encoded query string
+		if checkURLValues(field.Type) {
+			env[prefix+envKey] = v.Field(i).Interface().(url.Values).Encode()
+			continue
+		}
+		if checkSet(field.Type) {
+			env[prefix+envKey] = formatSet(v.Field(i), delim)
+			continue
+		}
+		if field.Type.Kind() == reflect.Map && !checkTextMarshaler(field.Type) {
+			env[prefix+envKey] = formatMap(v.Field(i), mapSep, kvSep)
+			continue
+		}
+
+		str, err := formatValue(v.Field(i), parserType, delim, innerDelim)
+		if err != nil {
+			return fmt.Errorf("%s: failed to format field %s: %v", op, path+field.Name, err)
+		}
+		env[prefix+envKey] = str
+	}
+	return nil
+}
+
+// formatValue converts an addressable value into its environment variable representation, joining
+// slices with delim and the elements of nested slices with innerDelim. MarshalJSON is preferred for
+// parser=json fields, MarshalText for everything else that implements it, then driver.Valuer.
+func formatValue(fieldValue reflect.Value, parserType, delim, innerDelim string) (string, error) {
+	fieldType := fieldValue.Type()
+
+	if fieldType.Kind() == reflect.Ptr && fieldValue.IsNil() {
+		return "", nil
+	}
+
+	if parserType == "json" && checkJSONMarshaler(fieldType) {
+		b, err := fieldValue.Addr().Interface().(json.Marshaler).MarshalJSON()
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+
+	// Slices decoded from a JSON array are written back as one
+	if parserType == "json" && fieldType.Kind() == reflect.Slice {
+		b, err := json.Marshal(fieldValue.Interface())
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+
+	if parserType == "reltime" && checkTime(fieldType) {
+		return formatRelTime(fieldValue.Interface().(time.Time)), nil
+	}
+
+	if parserType == "ints" && checkBytes(fieldType) {
+		return formatByteInts(fieldValue, delim), nil
+	}
+
+	if (parserType == "hex" || parserType == "base64") && checkBytes(fieldType) {
+		return formatEncodedBytes(fieldValue, parserType), nil
+	}
+
+	if checkTextMarshaler(fieldType) {
+		b, err := fieldValue.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+
+	// SQL-style types implementing Scan are written back through their driver.Valuer
+	if checkValuer(fieldType) {
+		v, err := fieldValue.Addr().Interface().(driver.Valuer).Value()
+		if err != nil {
+			return "", err
+		}
+		switch v := v.(type) {
+		case nil:
+			return "", nil
+		case []byte:
+			return string(v), nil
+		case time.Time:
+			return v.Format(time.RFC3339Nano), nil
+		}
+		return fmt.Sprint(v), nil
+	}
+
+	if c, ok := fieldValue.Interface().(color.RGBA); ok {
+		return formatRGBA(c), nil
+	}
+
+	if checkHardwareAddr(fieldType) {
+		return fieldValue.Interface().(net.HardwareAddr).String(), nil
+	}
+
+	if fieldType.Kind() == reflect.Slice {
+		vals := make([]string, fieldValue.Len())
+		for i := range fieldValue.Len() {
+			str, err := formatValue(fieldValue.Index(i), parserType, innerDelim, innerDelim)
+			if err != nil {
+				return "", err
+			}
+			vals[i] = str
+		}
+		return strings.Join(vals, delim), nil
+	}
+
+	return fmt.Sprint(fieldValue.Interface()), nil
+}
+
+// formatMap joins the entries of a map of primitives as key/value pairs sorted by key.
+func formatMap(fieldValue reflect.Value, mapSep, kvSep string) string {
+	entries := make([]string, 0, fieldValue.Len())
+	iter := fieldValue.MapRange()
+	for iter.Next() {
+		entries = append(entries, fmt.Sprint(iter.Key().Interface())+kvSep+fmt.Sprint(iter.Value().Interface()))
+	}
+	slices.Sort(entries)
+	return strings.Join(entries, mapSep)
+}
+
+// formatSet joins the keys of a set sorted.
+func formatSet(fieldValue reflect.Value, delim string) string {
+	keys := make([]string, 0, fieldValue.Len())
+	for _, key := range fieldValue.MapKeys() {
+		keys = append(keys, fmt.Sprint(key.Interface()))
+	}
+	slices.Sort(keys)
+	return strings.Join(keys, delim)
+}
+
+// checkValuer reports whether a pointer to the type implements driver.Valuer.
+func checkValuer(fieldType reflect.Type) bool {
+	return reflect.PointerTo(fieldType).Implements(reflect.TypeOf((*driver.Valuer)(nil)).Elem())
+}
@@ -0,0 +1,228 @@
+package lazyconf
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// LevelType implements both encoding.TextMarshaler and encoding.TextUnmarshaler
+type LevelType int
+
+var levelNames = []string{"debug", "info", "warn"}
+
+func (l LevelType) MarshalText() ([]byte, error) {
+	if int(l) < 0 || int(l) >= len(levelNames) {
+		return nil, fmt.Errorf("invalid level %d", int(l))
+	}
+	return []byte(levelNames[l]), nil
+}
+
+func (l *LevelType) UnmarshalText(text []byte) error {
+	for i, name := range levelNames {
+		if name == string(text) {
+			*l = LevelType(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown level %q", string(text))
+}
+
+// JSONRoundTripType implements both json.Marshaler and json.Unmarshaler
+type JSONRoundTripType struct {
+	Name string
+	Size int
+}
+
+func (j JSONRoundTripType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{"name": j.Name, "size": j.Size})
+}
+
+func (j *JSONRoundTripType) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Name string `json:"name"`
+		Size int    `json:"size"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	j.Name, j.Size = raw.Name, raw.Size
+	return nil
+}
+
+type DumpNestedConfig struct {
+	Host string `env:"DUMP_NESTED_HOST"`
+}
+
+type DumpConfig struct {
+	Name    string            `env:"DUMP_NAME"`
+	Port    int               `env:"DUMP_PORT"`
+	Ratio   float64           `env:"DUMP_RATIO"`
+	Enabled bool              `env:"DUMP_ENABLED"`
+	Timeout time.Duration     `env:"DUMP_TIMEOUT"`
+	Started time.Time         `env:"DUMP_STARTED"`
+	Ports   []int             `env:"DUMP_PORTS"`
+	Level   LevelType         `env:"DUMP_LEVEL"`
+	Levels  []LevelType       `env:"DUMP_LEVELS"`
+	Payload JSONRoundTripType `env:"DUMP_PAYLOAD,parser=json"`
+	Nested  DumpNestedConfig
+	Ignored string `env:"_"`
+}
+
+// TestDumpEnv tests that DumpEnv output parses back into an identical struct.
+func TestDumpEnv(t *testing.T) {
+	started, _ := time.Parse(time.RFC3339, "2023-07-19T15:30:45Z")
+	cfg := &DumpConfig{
+		Name:    "service",
+		Port:    8080,
+		Ratio:   0.25,
+		Enabled: true,
+		Timeout: 90 * time.Second,
+		Started: started,
+		Ports:   []int{1, 2, 3},
+		Level:   LevelType(2),
+		Levels:  []LevelType{0, 1},
+		Payload: JSONRoundTripType{Name: "blob", Size: 3},
+		Nested:  DumpNestedConfig{Host: "db.local"},
+	}
+
+	env, err := DumpEnv(cfg)
+	if err != nil {
+		t.Fatalf("DumpEnv returned an error: %v", err)
+	}
+
+	if env["DUMP_LEVEL"] != "warn" {
+		t.Errorf("expected DUMP_LEVEL to be marshaled via MarshalText as 'warn', got '%s'", env["DUMP_LEVEL"])
+	}
+	if env["DUMP_LEVELS"] != "debug,info" {
+		t.Errorf("expected DUMP_LEVELS to be 'debug,info', got '%s'", env["DUMP_LEVELS"])
+	}
+	if env["DUMP_PAYLOAD"] != `{"name":"blob","size":3}` {
+		t.Errorf("expected DUMP_PAYLOAD to be marshaled via MarshalJSON, got '%s'", env["DUMP_PAYLOAD"])
+	}
+	if _, ok := env["_"]; ok {
+		t.Error("expected '_' keyed field to be skipped")
+	}
+
+	for k, v := range env {
+		_ = os.Setenv(k, v)
+	}
+
+	parsed := &DumpConfig{}
+	if err := ParseEnv(parsed); err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, cfg) {
+		t.Errorf("expected round-tripped config to be %+v, got %+v", cfg, parsed)
+	}
+}
+
+// TestDumpEnvDelimiters tests that slices are dumped with the field's delimiter and parse back unchanged.
+func TestDumpEnvDelimiters(t *testing.T) {
+	type DelimDumpConfig struct {
+		Items []string   `env:"DUMP_DELIM_ITEMS,delim=|"`
+		Mask  []byte     `env:"DUMP_DELIM_MASK,parser=ints,delim=;"`
+		Words [][]string `env:"DUMP_DELIM_WORDS"`
+		Grid  [][]int    `env:"DUMP_DELIM_GRID,delim=;,innerdelim=|"`
+	}
+
+	cfg := &DelimDumpConfig{
+		Items: []string{"a,b", "c"},
+		Mask:  []byte{1, 2},
+		Words: [][]string{{"a", "b"}, {"c"}},
+		Grid:  [][]int{{1, 2}, {3, 4}},
+	}
+	env, err := DumpEnv(cfg)
+	if err != nil {
+		t.Fatalf("DumpEnv returned an error: %v", err)
+	}
+	if env["DUMP_DELIM_ITEMS"] != "a,b|c" || env["DUMP_DELIM_MASK"] != "1;2" {
+		t.Errorf("expected the slices to be joined with their delimiters, got %q and %q", env["DUMP_DELIM_ITEMS"], env["DUMP_DELIM_MASK"])
+	}
+	if env["DUMP_DELIM_WORDS"] != "a:b,c" || env["DUMP_DELIM_GRID"] != "1|2;3|4" {
+		t.Errorf("expected the nested slices to use the inner delimiter, got %q and %q", env["DUMP_DELIM_WORDS"], env["DUMP_DELIM_GRID"])
+	}
+
+	parsed := &DelimDumpConfig{}
+	if err := ParseEnvFromMap(parsed, env); err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, cfg) {
+		t.Errorf("expected round-tripped config to be %+v, got %+v", cfg, parsed)
+	}
+}
+
+// TestDumpEnvMarshalError tests that MarshalText errors are returned.
+func TestDumpEnvMarshalError(t *testing.T) {
+	cfg := &DumpConfig{Level: LevelType(10)}
+
+	_, err := DumpEnv(cfg)
+	if err == nil {
+		t.Fatal("expected an error when MarshalText fails, but got none")
+	}
+
+	type NestedLevelConfig struct {
+		Inner struct {
+			Level LevelType `env:"DUMP_NESTED_LEVEL"`
+		}
+	}
+	nested := &NestedLevelConfig{}
+	nested.Inner.Level = LevelType(10)
+	_, err = DumpEnv(nested)
+	if err == nil || !strings.Contains(err.Error(), "field Inner.Level:") {
+		t.Errorf("expected the error to name the nested field path, got %v", err)
+	}
+}
+
+// NullString is an SQL-style type implementing Scan and driver.Valuer
+type NullString struct {
+	String string
+	Valid  bool
+}
+
+func (n *NullString) Scan(value any) error {
+	n.String, n.Valid = value.(string)
+	n.Valid = n.Valid && n.String != ""
+	return nil
+}
+
+func (n NullString) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.String, nil
+}
+
+// TestDumpEnvValuer tests that Scan/Valuer types are dumped via Value and parse back.
+func TestDumpEnvValuer(t *testing.T) {
+	type ValuerConfig struct {
+		Name  NullString   `env:"DUMP_VALUER_NAME"`
+		Empty NullString   `env:"DUMP_VALUER_EMPTY"`
+		Names []NullString `env:"DUMP_VALUER_NAMES"`
+	}
+
+	cfg := &ValuerConfig{
+		Name:  NullString{"alice", true},
+		Names: []NullString{{"a", true}, {"b", true}},
+	}
+	env, err := DumpEnv(cfg)
+	if err != nil {
+		t.Fatalf("DumpEnv returned an error: %v", err)
+	}
+	if env["DUMP_VALUER_NAME"] != "alice" || env["DUMP_VALUER_EMPTY"] != "" || env["DUMP_VALUER_NAMES"] != "a,b" {
+		t.Errorf("expected values to be dumped via Value, got %v", env)
+	}
+
+	parsed := &ValuerConfig{}
+	if err := ParseEnvFromMap(parsed, env); err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, cfg) {
+		t.Errorf("expected round-tripped config to be %+v, got %+v", cfg, parsed)
+	}
+}
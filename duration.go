@@ -0,0 +1,120 @@
+package lazyconf
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseDuration extends time.ParseDuration with "d" (days) and "w" (weeks) units, e.g. "7d" or "1w2d3h".
+// Days and weeks are converted to hours before the rest of the string is handed off to time.ParseDuration.
+// A day is always 24 hours. Underscores between digits are ignored, e.g. "1_500ms".
+func parseDuration(s string) (time.Duration, error) {
+	s = trimDigitSeparators(s)
+	if !strings.ContainsAny(s, "dw") {
+		return time.ParseDuration(s)
+	}
+
+	orig := s
+	neg := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	if s == "" {
+		return 0, fmt.Errorf("invalid duration %q", orig)
+	}
+
+	var hours float64
+	var rest strings.Builder
+	for s != "" {
+		// Consume the number
+		i := 0
+		for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+			i++
+		}
+		num := s[:i]
+		if num == "" {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		s = s[i:]
+
+		// Consume the unit
+		i = 0
+		for i < len(s) && !(s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+			i++
+		}
+		unit := s[:i]
+		s = s[i:]
+
+		switch unit {
+		case "d", "w":
+			n, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", orig)
+			}
+			if unit == "w" {
+				n *= 7
+			}
+			hours += n * 24
+		default:
+			rest.WriteString(num)
+			rest.WriteString(unit)
+		}
+	}
+
+	if hours*float64(time.Hour) >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid duration %q: overflows time.Duration", orig)
+	}
+	dur := time.Duration(hours * float64(time.Hour))
+	if rest.Len() > 0 {
+		d, err := time.ParseDuration(rest.String())
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %v", orig, err)
+		}
+		dur += d
+	}
+	if neg {
+		dur = -dur
+	}
+	return dur, nil
+}
+
+// setDuration parses envVal as a duration and stores it in the int64 kind fieldValue, such as a
+// named duration type.
+func setDuration(fieldValue reflect.Value, envVal string) error {
+	dur, err := parseDuration(envVal)
+	if err != nil {
+		return err
+	}
+	fieldValue.SetInt(int64(dur))
+	return nil
+}
+
+// setRelTime parses envVal as a duration relative to the current time and stores the resulting UTC time in
+// the time.Time fieldValue. The sign is mandatory: "+24h" is a day from now and "-7d" a week ago.
+func setRelTime(fieldValue reflect.Value, envVal string) error {
+	if envVal == "" || (envVal[0] != '+' && envVal[0] != '-') {
+		return fmt.Errorf("%q must start with + (from now) or - (ago)", envVal)
+	}
+
+	dur, err := parseDuration(envVal)
+	if err != nil {
+		return err
+	}
+	fieldValue.Set(reflect.ValueOf(time.Now().UTC().Add(dur)))
+	return nil
+}
+
+// formatRelTime formats t as its offset from the current time, rounded to the second, so that it parses
+// again with parser=reltime.
+func formatRelTime(t time.Time) string {
+	dur := time.Until(t).Round(time.Second)
+	if dur < 0 {
+		return dur.String()
+	}
+	return "+" + dur.String()
+}
@@ -0,0 +1,231 @@
+package lazyconf
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseDuration tests the extended duration parser with days and weeks.
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected time.Duration
+	}{
+		{"5m", 5 * time.Minute},
+		{"1h30m", 90 * time.Minute},
+		{"250ms", 250 * time.Millisecond},
+		{"7d", 7 * 24 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+		{"1w2d3h", (9*24 + 3) * time.Hour},
+		{"1.5d", 36 * time.Hour},
+		{"1d30s", 24*time.Hour + 30*time.Second},
+		{"-2d", -48 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseDuration(tt.in)
+			if err != nil {
+				t.Fatalf("parseDuration returned an error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestParseDurationErrors tests that malformed extended durations are rejected.
+func TestParseDurationErrors(t *testing.T) {
+	for _, in := range []string{"d", "7dd", "1w2x", "w7", "-", "1.2.3d", "999999999w"} {
+		t.Run(in, func(t *testing.T) {
+			_, err := parseDuration(in)
+			if err == nil {
+				t.Fatalf("expected an error for %q, but got none", in)
+			}
+		})
+	}
+}
+
+// TestParseEnvExtendedDuration tests days and weeks in duration scalar and slice fields.
+func TestParseEnvExtendedDuration(t *testing.T) {
+	type DurationConfig struct {
+		Retention time.Duration   `env:"EXTENDED_RETENTION"`
+		Intervals []time.Duration `env:"EXTENDED_INTERVALS"`
+	}
+
+	_ = os.Setenv("EXTENDED_RETENTION", "1w2d3h")
+	_ = os.Setenv("EXTENDED_INTERVALS", "1d,12h,2w")
+
+	cfg := &DurationConfig{}
+	err := ParseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.Retention != 219*time.Hour {
+		t.Errorf("expected Retention to be 219h, got %v", cfg.Retention)
+	}
+	expected := []time.Duration{24 * time.Hour, 12 * time.Hour, 336 * time.Hour}
+	if !reflect.DeepEqual(cfg.Intervals, expected) {
+		t.Errorf("expected Intervals to be %v, got %v", expected, cfg.Intervals)
+	}
+
+	_ = os.Setenv("EXTENDED_RETENTION", "7days")
+	err = ParseEnv(&DurationConfig{})
+	if err == nil {
+		t.Fatal("expected an error for a malformed extended duration, but got none")
+	}
+}
+
+// Timeout is a named duration type, which reflection sees as a plain int64.
+type Timeout time.Duration
+
+// TestParseEnvNamedDuration tests parsing named duration types with parser=duration.
+func TestParseEnvNamedDuration(t *testing.T) {
+	type NamedDurationConfig struct {
+		Timeout  Timeout       `env:"NAMED_DURATION_TIMEOUT,parser=duration"`
+		Retries  []Timeout     `env:"NAMED_DURATION_RETRIES,parser=duration"`
+		Standard time.Duration `env:"NAMED_DURATION_STANDARD"`
+		Count    int64         `env:"NAMED_DURATION_COUNT"`
+	}
+
+	cfg := &NamedDurationConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"NAMED_DURATION_TIMEOUT":  "1m30s",
+		"NAMED_DURATION_RETRIES":  "1s,2d",
+		"NAMED_DURATION_STANDARD": "5s",
+		"NAMED_DURATION_COUNT":    "90",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if time.Duration(cfg.Timeout) != 90*time.Second {
+		t.Errorf("expected Timeout to be 1m30s, got %v", time.Duration(cfg.Timeout))
+	}
+	if len(cfg.Retries) != 2 || time.Duration(cfg.Retries[1]) != 48*time.Hour {
+		t.Errorf("expected Retries to be [1s 48h], got %v", cfg.Retries)
+	}
+	if cfg.Standard != 5*time.Second {
+		t.Errorf("expected Standard to be 5s, got %v", cfg.Standard)
+	}
+	if cfg.Count != 90 {
+		t.Errorf("expected plain int64 Count to be 90, got %d", cfg.Count)
+	}
+
+	err = ParseEnvFromMap(&NamedDurationConfig{}, map[string]string{"NAMED_DURATION_TIMEOUT": "90"})
+	if err == nil {
+		t.Fatal("expected an error for a duration without a unit, but got none")
+	}
+
+	// Without parser=duration, named int64 types are integers, whatever type they are defined as
+	type Count int64
+	type NamedIntConfig struct {
+		Count   Count   `env:"NAMED_DURATION_INT_COUNT"`
+		Timeout Timeout `env:"NAMED_DURATION_INT_TIMEOUT"`
+	}
+	for _, key := range []string{"NAMED_DURATION_INT_COUNT", "NAMED_DURATION_INT_TIMEOUT"} {
+		err = ParseEnvFromMap(&NamedIntConfig{}, map[string]string{key: "5s"})
+		if err == nil {
+			t.Errorf("expected %s to reject a duration without parser=duration, but got no error", key)
+		}
+	}
+}
+
+// TestParseEnvParserSliceSplitting tests that parser= slices are split like other slices.
+func TestParseEnvParserSliceSplitting(t *testing.T) {
+	type SplitConfig struct {
+		Delim   []time.Duration `env:"PSPLIT_DELIM,parser=duration,delim=;"`
+		Lines   []Timeout       `env:"PSPLIT_LINES,parser=duration,delim=\n"`
+		Indexed []time.Duration `env:"PSPLIT_INDEXED,parser=duration,indexed"`
+		Merged  []time.Duration `env:"PSPLIT_MERGED,parser=duration,merged"`
+		Regex   []uint64        `env:"PSPLIT_REGEX,parser=bytesize,regexsplit=\\s+"`
+	}
+
+	cfg := &SplitConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"PSPLIT_DELIM":     "1s;2s",
+		"PSPLIT_LINES":     "1m\n2m\n",
+		"PSPLIT_INDEXED_0": "3s",
+		"PSPLIT_INDEXED_1": "4s",
+		"PSPLIT_MERGED":    "1s,2s",
+		"PSPLIT_MERGED_1":  "5s",
+		"PSPLIT_REGEX":     "1KB  2KB",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if expected := []time.Duration{time.Second, 2 * time.Second}; !reflect.DeepEqual(cfg.Delim, expected) {
+		t.Errorf("expected Delim to be %v, got %v", expected, cfg.Delim)
+	}
+	if expected := []Timeout{Timeout(time.Minute), Timeout(2 * time.Minute)}; !reflect.DeepEqual(cfg.Lines, expected) {
+		t.Errorf("expected Lines to be %v, got %v", expected, cfg.Lines)
+	}
+	if expected := []time.Duration{3 * time.Second, 4 * time.Second}; !reflect.DeepEqual(cfg.Indexed, expected) {
+		t.Errorf("expected Indexed to be %v, got %v", expected, cfg.Indexed)
+	}
+	if expected := []time.Duration{time.Second, 5 * time.Second}; !reflect.DeepEqual(cfg.Merged, expected) {
+		t.Errorf("expected Merged to be %v, got %v", expected, cfg.Merged)
+	}
+	if len(cfg.Regex) != 2 || cfg.Regex[1] != 2*cfg.Regex[0] {
+		t.Errorf("expected Regex to hold two byte sizes, got %v", cfg.Regex)
+	}
+}
+
+// TestParseEnvRelTime tests parser=reltime for times relative to now.
+func TestParseEnvRelTime(t *testing.T) {
+	type RelTimeConfig struct {
+		Expires time.Time `env:"RELTIME_EXPIRES,parser=reltime"`
+		Since   time.Time `env:"RELTIME_SINCE,parser=reltime"`
+	}
+
+	before := time.Now()
+	cfg := &RelTimeConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"RELTIME_EXPIRES": "+24h",
+		"RELTIME_SINCE":   "-7d",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	after := time.Now()
+
+	if cfg.Expires.Before(before.Add(24*time.Hour)) || cfg.Expires.After(after.Add(24*time.Hour)) {
+		t.Errorf("expected Expires to be 24h from now, got %v", cfg.Expires)
+	}
+	if cfg.Since.Before(before.Add(-7*24*time.Hour)) || cfg.Since.After(after.Add(-7*24*time.Hour)) {
+		t.Errorf("expected Since to be 7 days ago, got %v", cfg.Since)
+	}
+
+	env, err := DumpEnv(cfg)
+	if err != nil {
+		t.Fatalf("DumpEnv returned an error: %v", err)
+	}
+	if env["RELTIME_EXPIRES"] != "+24h0m0s" || env["RELTIME_SINCE"] != "-168h0m0s" {
+		t.Errorf("expected the times to be dumped as offsets, got %q and %q", env["RELTIME_EXPIRES"], env["RELTIME_SINCE"])
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"missing sign", map[string]string{"RELTIME_EXPIRES": "24h"}, `field Expires: invalid relative time value: "24h" must start with +`},
+		{"absolute time", map[string]string{"RELTIME_SINCE": "2024-01-02T00:00:00Z"}, "field Since: invalid relative time value"},
+		{"bad duration", map[string]string{"RELTIME_SINCE": "-7x"}, "field Since: invalid relative time value"},
+		{"sign only", map[string]string{"RELTIME_EXPIRES": "+"}, "field Expires: invalid relative time value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&RelTimeConfig{}, tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package lazyconf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// DurationRange is a span between two durations, e.g. "100ms..5s".
+// Populate it with the parser=durationrange tag option.
+type DurationRange struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+// String formats the range as "Min..Max", which parser=durationrange reads back.
+func (r DurationRange) String() string {
+	return r.Min.String() + ".." + r.Max.String()
+}
+
+// setDurationRange parses envVal as "min..max" durations and stores it in the DurationRange fieldValue.
+// The minimum may not exceed the maximum.
+func setDurationRange(fieldValue reflect.Value, envVal string) error {
+	minStr, maxStr, ok := strings.Cut(envVal, "..")
+	if !ok {
+		return fmt.Errorf("range %q is missing '..' between min and max", envVal)
+	}
+	var r DurationRange
+	var err error
+	if r.Min, err = parseDuration(strings.TrimSpace(minStr)); err != nil {
+		return fmt.Errorf("invalid min of range %q: %v", envVal, err)
+	}
+	if r.Max, err = parseDuration(strings.TrimSpace(maxStr)); err != nil {
+		return fmt.Errorf("invalid max of range %q: %v", envVal, err)
+	}
+	if r.Min > r.Max {
+		return fmt.Errorf("min of range %q is greater than its max", envVal)
+	}
+	fieldValue.Set(reflect.ValueOf(r))
+	return nil
+}
+
+// checkDurationRange reports whether the type is DurationRange or a slice of DurationRange.
+func checkDurationRange(fieldType reflect.Type) bool {
+	if fieldType.Kind() == reflect.Slice {
+		fieldType = fieldType.Elem()
+	}
+	return fieldType == reflect.TypeOf(DurationRange{})
+}
@@ -0,0 +1,65 @@
+package lazyconf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseEnvDurationRange tests parsing DurationRange fields with parser=durationrange.
+func TestParseEnvDurationRange(t *testing.T) {
+	type DurationRangeConfig struct {
+		Backoff  DurationRange   `env:"DURRANGE_BACKOFF,parser=durationrange"`
+		Fixed    DurationRange   `env:"DURRANGE_FIXED,parser=durationrange"`
+		Backoffs []DurationRange `env:"DURRANGE_BACKOFFS,parser=durationrange"`
+	}
+
+	cfg := &DurationRangeConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"DURRANGE_BACKOFF":  "100ms..5s",
+		"DURRANGE_FIXED":    "1m..1m",
+		"DURRANGE_BACKOFFS": "1s..2s,1d..1w",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.Backoff != (DurationRange{100 * time.Millisecond, 5 * time.Second}) {
+		t.Errorf("expected Backoff to be 100ms..5s, got %v", cfg.Backoff)
+	}
+	if cfg.Fixed != (DurationRange{time.Minute, time.Minute}) {
+		t.Errorf("expected Fixed to be 1m..1m, got %v", cfg.Fixed)
+	}
+	expected := []DurationRange{{time.Second, 2 * time.Second}, {24 * time.Hour, 7 * 24 * time.Hour}}
+	if !reflect.DeepEqual(cfg.Backoffs, expected) {
+		t.Errorf("expected Backoffs to be %v, got %v", expected, cfg.Backoffs)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"missing separator", map[string]string{"DURRANGE_BACKOFF": "100ms-5s"}, "missing '..'"},
+		{"invalid min", map[string]string{"DURRANGE_BACKOFF": "fast..5s"}, "invalid min"},
+		{"min greater than max", map[string]string{"DURRANGE_BACKOFF": "5s..100ms"}, "greater than its max"},
+		{"invalid element", map[string]string{"DURRANGE_BACKOFFS": "1s..2s,3s"}, "missing '..'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&DurationRangeConfig{}, tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+
+	env, err := DumpEnv(cfg)
+	if err != nil {
+		t.Fatalf("DumpEnv returned an error: %v", err)
+	}
+	if env["DURRANGE_BACKOFF"] != "100ms..5s" {
+		t.Errorf("expected DURRANGE_BACKOFF to be dumped as 100ms..5s, got %q", env["DURRANGE_BACKOFF"])
+	}
+}
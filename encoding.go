@@ -0,0 +1,126 @@
+package lazyconf
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// setEncodedBytes decodes envVal with the hex or base64 parser and stores the bytes in the []byte
+// or [N]byte fieldValue. Arrays require the decoded length to match their size exactly.
+func setEncodedBytes(fieldValue reflect.Value, envVal, parserType string) error {
+	var b []byte
+	var err error
+	switch parserType {
+	case "hex":
+		b, err = hex.DecodeString(envVal)
+	case "base64":
+		b, err = base64.StdEncoding.DecodeString(envVal)
+	}
+	if err != nil {
+		return err
+	}
+
+	if fieldValue.Kind() == reflect.Array {
+		if len(b) != fieldValue.Len() {
+			return fmt.Errorf("decoded %d bytes, expected exactly %d for %s", len(b), fieldValue.Len(), fieldValue.Type())
+		}
+		reflect.Copy(fieldValue, reflect.ValueOf(b))
+		return nil
+	}
+	fieldValue.SetBytes(b)
+	return nil
+}
+
+// formatEncodedBytes encodes the []byte or [N]byte fieldValue for the hex or base64 parser.
+func formatEncodedBytes(fieldValue reflect.Value, parserType string) string {
+	b := make([]byte, fieldValue.Len())
+	reflect.Copy(reflect.ValueOf(b), fieldValue)
+	if parserType == "hex" {
+		return hex.EncodeToString(b)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// setEndianNum decodes envVal as hex bytes, e.g. "0A000001", and stores them in the integer fieldValue
+// in big ("be") or little ("le") endian order. Fewer bytes than the field width are zero-extended,
+// or sign-extended for signed fields.
+func setEndianNum(fieldValue reflect.Value, envVal, parserType string) error {
+	b, err := hex.DecodeString(envVal)
+	if err != nil {
+		return err
+	}
+	if len(b) == 0 {
+		return errors.New("no bytes to decode")
+	}
+	width := fieldValue.Type().Bits() / 8
+	if len(b) > width {
+		return fmt.Errorf("decoded %d bytes, %s holds at most %d", len(b), fieldValue.Type(), width)
+	}
+
+	var n uint64
+	for i := range b {
+		if parserType == "le" {
+			n |= uint64(b[i]) << (8 * i)
+		} else {
+			n = n<<8 | uint64(b[i])
+		}
+	}
+
+	switch fieldValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		shift := 64 - 8*len(b)
+		fieldValue.SetInt(int64(n<<shift) >> shift)
+	default:
+		fieldValue.SetUint(n)
+	}
+	return nil
+}
+
+// setByteInts parses vals, the elements of a list split like any other slice, e.g. "1,2,255", as integers
+// from 0 to 255 and stores them in the []byte or [N]byte fieldValue. Arrays require exactly N values.
+func setByteInts(fieldValue reflect.Value, vals []string) error {
+	if fieldValue.Kind() == reflect.Array && len(vals) != fieldValue.Len() {
+		return fmt.Errorf("got %d bytes, expected exactly %d for %s", len(vals), fieldValue.Len(), fieldValue.Type())
+	}
+
+	b := make([]byte, len(vals))
+	for i, vl := range vals {
+		n, err := strconv.ParseUint(strings.TrimSpace(vl), 10, 8)
+		if err != nil {
+			return fmt.Errorf("invalid byte %q at index %d: %v", vl, i, err)
+		}
+		b[i] = byte(n)
+	}
+
+	if fieldValue.Kind() == reflect.Array {
+		reflect.Copy(fieldValue, reflect.ValueOf(b))
+		return nil
+	}
+	fieldValue.SetBytes(b)
+	return nil
+}
+
+// formatByteInts formats the []byte or [N]byte fieldValue as a list of integers joined with delim.
+func formatByteInts(fieldValue reflect.Value, delim string) string {
+	vals := make([]string, fieldValue.Len())
+	for i := range fieldValue.Len() {
+		vals[i] = strconv.FormatUint(fieldValue.Index(i).Uint(), 10)
+	}
+	return strings.Join(vals, delim)
+}
+
+// isPlainBytes reports whether the type is an unnamed byte slice or array, as opposed to types such as
+// net.HardwareAddr or json.RawMessage with a format of their own.
+func isPlainBytes(fieldType reflect.Type) bool {
+	return checkBytes(fieldType) && fieldType.Name() == ""
+}
+
+// checkBytes reports whether the type is a byte slice or a byte array.
+func checkBytes(fieldType reflect.Type) bool {
+	return (fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Array) && fieldType.Elem().Kind() == reflect.Uint8
+}
@@ -0,0 +1,219 @@
+package lazyconf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestParseEnvEncodedBytes tests decoding []byte and [N]byte fields with parser=hex and parser=base64.
+func TestParseEnvEncodedBytes(t *testing.T) {
+	type EncodedConfig struct {
+		Key   [4]byte `env:"ENCODED_KEY,parser=hex"`
+		Nonce [3]byte `env:"ENCODED_NONCE,parser=base64"`
+		Salt  []byte  `env:"ENCODED_SALT,parser=hex"`
+		Token []byte  `env:"ENCODED_TOKEN,parser=base64"`
+	}
+
+	cfg := &EncodedConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"ENCODED_KEY":   "deadbeef",
+		"ENCODED_NONCE": "AQID",
+		"ENCODED_SALT":  "0102",
+		"ENCODED_TOKEN": "aGk=",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.Key != [4]byte{0xde, 0xad, 0xbe, 0xef} {
+		t.Errorf("expected Key to be deadbeef, got %x", cfg.Key)
+	}
+	if cfg.Nonce != [3]byte{1, 2, 3} {
+		t.Errorf("expected Nonce to be [1 2 3], got %v", cfg.Nonce)
+	}
+	if !bytes.Equal(cfg.Salt, []byte{1, 2}) {
+		t.Errorf("expected Salt to be [1 2], got %v", cfg.Salt)
+	}
+	if string(cfg.Token) != "hi" {
+		t.Errorf("expected Token to be hi, got %q", cfg.Token)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"hex too short", map[string]string{"ENCODED_KEY": "dead"}, "field Key: invalid hex value: decoded 2 bytes, expected exactly 4"},
+		{"base64 too long", map[string]string{"ENCODED_NONCE": "AQIDBA=="}, "field Nonce: invalid base64 value: decoded 4 bytes, expected exactly 3"},
+		{"invalid hex", map[string]string{"ENCODED_SALT": "xyz"}, "invalid hex value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&EncodedConfig{}, tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+
+	env, err := DumpEnv(cfg)
+	if err != nil {
+		t.Fatalf("DumpEnv returned an error: %v", err)
+	}
+	if env["ENCODED_KEY"] != "deadbeef" || env["ENCODED_NONCE"] != "AQID" {
+		t.Errorf("expected the keys to be dumped encoded, got %q and %q", env["ENCODED_KEY"], env["ENCODED_NONCE"])
+	}
+}
+
+// TestParseEnvByteInts tests parser=ints and the BytesEncoding default for byte slices and arrays.
+func TestParseEnvByteInts(t *testing.T) {
+	type ByteIntsConfig struct {
+		Mask  []byte  `env:"BYTEINTS_MASK"`
+		Magic [3]byte `env:"BYTEINTS_MAGIC,parser=ints"`
+		Salt  []byte  `env:"BYTEINTS_SALT,parser=ints"`
+	}
+
+	cfg := &ByteIntsConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"BYTEINTS_MASK":  "1,2,255",
+		"BYTEINTS_MAGIC": "7, 8, 9",
+		"BYTEINTS_SALT":  "0,16",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if !bytes.Equal(cfg.Mask, []byte{1, 2, 255}) {
+		t.Errorf("expected Mask to be [1 2 255], got %v", cfg.Mask)
+	}
+	if cfg.Magic != [3]byte{7, 8, 9} {
+		t.Errorf("expected Magic to be [7 8 9], got %v", cfg.Magic)
+	}
+
+	env, err := DumpEnv(cfg)
+	if err != nil {
+		t.Fatalf("DumpEnv returned an error: %v", err)
+	}
+	if env["BYTEINTS_MAGIC"] != "7,8,9" || env["BYTEINTS_SALT"] != "0,16" {
+		t.Errorf("expected the bytes to be dumped as integers, got %q and %q", env["BYTEINTS_MAGIC"], env["BYTEINTS_SALT"])
+	}
+
+	// BytesEncoding changes the default, while an explicit parser=ints still wins
+	encoded := &ByteIntsConfig{}
+	err = ParseEnvWithOptions(encoded, ParseEnvOptions{
+		BytesEncoding: "hex",
+		Lookup: mapLookup(map[string]string{
+			"BYTEINTS_MASK":  "01ff",
+			"BYTEINTS_MAGIC": "1,2,3",
+		}),
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if !bytes.Equal(encoded.Mask, []byte{1, 255}) {
+		t.Errorf("expected Mask to be [1 255], got %v", encoded.Mask)
+	}
+	if encoded.Magic != [3]byte{1, 2, 3} {
+		t.Errorf("expected Magic to be [1 2 3], got %v", encoded.Magic)
+	}
+
+	// Integers are split with the field's list options, whether BytesEncoding is "ints" or unset
+	type DelimConfig struct {
+		Mask  []byte  `env:"BYTEINTS_DELIM_MASK,delim=;"`
+		Magic [2]byte `env:"BYTEINTS_DELIM_MAGIC,parser=ints,delim=;"`
+	}
+	for _, encoding := range []string{"", "ints"} {
+		delimited := &DelimConfig{}
+		err = ParseEnvWithOptions(delimited, ParseEnvOptions{
+			BytesEncoding: encoding,
+			Lookup: mapLookup(map[string]string{
+				"BYTEINTS_DELIM_MASK":  "1;2;3",
+				"BYTEINTS_DELIM_MAGIC": "4;5",
+			}),
+		})
+		if err != nil {
+			t.Fatalf("ParseEnv with BytesEncoding %q returned an error: %v", encoding, err)
+		}
+		if !bytes.Equal(delimited.Mask, []byte{1, 2, 3}) || delimited.Magic != [2]byte{4, 5} {
+			t.Errorf("expected Mask [1 2 3] and Magic [4 5] with BytesEncoding %q, got %v and %v", encoding, delimited.Mask, delimited.Magic)
+		}
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"out of range", map[string]string{"BYTEINTS_SALT": "1,256"}, `field Salt: invalid bytes value: invalid byte "256" at index 1`},
+		{"wrong array size", map[string]string{"BYTEINTS_MAGIC": "1,2"}, "field Magic: invalid bytes value: got 2 bytes, expected exactly 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&ByteIntsConfig{}, tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+
+	err = ParseEnvWithOptions(&ByteIntsConfig{}, ParseEnvOptions{BytesEncoding: "base32", Lookup: mapLookup(nil)})
+	if err == nil || !strings.Contains(err.Error(), `unknown BytesEncoding "base32"`) {
+		t.Errorf("expected an unknown BytesEncoding error, got: %v", err)
+	}
+}
+
+// TestParseEnvEndianNum tests parser=be and parser=le on integer fields.
+func TestParseEnvEndianNum(t *testing.T) {
+	type EndianConfig struct {
+		Big    uint32   `env:"ENDIAN_BIG,parser=be"`
+		Little uint32   `env:"ENDIAN_LITTLE,parser=le"`
+		Short  uint32   `env:"ENDIAN_SHORT,parser=le"`
+		Signed int16    `env:"ENDIAN_SIGNED,parser=be"`
+		Words  []uint16 `env:"ENDIAN_WORDS,parser=le"`
+	}
+
+	cfg := &EndianConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"ENDIAN_BIG":    "0A000001",
+		"ENDIAN_LITTLE": "0A000001",
+		"ENDIAN_SHORT":  "3412",
+		"ENDIAN_SIGNED": "FFFE",
+		"ENDIAN_WORDS":  "0100,FF00",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Big != 0x0A000001 {
+		t.Errorf("expected Big to be 0x0A000001, got %#x", cfg.Big)
+	}
+	if cfg.Little != 0x0100000A {
+		t.Errorf("expected Little to be 0x0100000A, got %#x", cfg.Little)
+	}
+	if cfg.Short != 0x1234 {
+		t.Errorf("expected Short to be 0x1234, got %#x", cfg.Short)
+	}
+	if cfg.Signed != -2 {
+		t.Errorf("expected Signed to be -2, got %d", cfg.Signed)
+	}
+	if len(cfg.Words) != 2 || cfg.Words[0] != 1 || cfg.Words[1] != 255 {
+		t.Errorf("expected Words to be [1 255], got %v", cfg.Words)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"too many bytes", map[string]string{"ENDIAN_BIG": "0102030405"}, "field Big: invalid be hex bytes value: decoded 5 bytes, uint32 holds at most 4"},
+		{"odd digits", map[string]string{"ENDIAN_LITTLE": "ABC"}, "field Little: invalid le hex bytes value"},
+		{"not hex", map[string]string{"ENDIAN_BIG": "0xFF"}, "field Big: invalid be hex bytes value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&EndianConfig{}, tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package lazyconf
+
+import "strings"
+
+// expandWindows replaces Windows-style %VAR% references in s by the values of the variables read
+// through lookup. References to unset variables are kept as-is, like cmd.exe does, and "%%" is a
+// literal "%". Text between percent signs that isn't a variable name, e.g. in "10% to 20%", is kept.
+func expandWindows(s string, lookup func(string) (string, bool)) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(s, '%')
+		if start < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		b.WriteString(s[:start])
+		s = s[start+1:]
+
+		if strings.HasPrefix(s, "%") {
+			b.WriteByte('%')
+			s = s[1:]
+			continue
+		}
+
+		end := strings.IndexByte(s, '%')
+		if end < 0 || !isVarName(s[:end]) {
+			b.WriteByte('%')
+			continue
+		}
+		if val, ok := lookup(s[:end]); ok {
+			b.WriteString(val)
+		} else {
+			b.WriteString("%" + s[:end+1])
+		}
+		s = s[end+1:]
+	}
+}
+
+// isVarName reports whether s is a variable name made of letters, digits and underscores
+// that doesn't start with a digit.
+func isVarName(s string) bool {
+	if s == "" || ('0' <= s[0] && s[0] <= '9') {
+		return false
+	}
+	for i := range len(s) {
+		c := s[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package lazyconf
+
+import "testing"
+
+// TestParseEnvWindowsExpand tests expanding %VAR% references with WindowsExpand.
+func TestParseEnvWindowsExpand(t *testing.T) {
+	type ExpandConfig struct {
+		Dir     string   `env:"EXPAND_DIR"`
+		Cache   string   `env:"EXPAND_CACHE,default=%EXPAND_HOME%\\cache"`
+		Missing string   `env:"EXPAND_MISSING"`
+		Ratio   string   `env:"EXPAND_RATIO"`
+		Paths   []string `env:"EXPAND_PATHS,delim=;"`
+	}
+
+	env := map[string]string{
+		"EXPAND_HOME":    `C:\Users\dev`,
+		"EXPAND_DIR":     `%EXPAND_HOME%\AppData`,
+		"EXPAND_MISSING": `%EXPAND_UNSET%\x`,
+		"EXPAND_RATIO":   `10% to 20%, 100%%`,
+		"EXPAND_PATHS":   `%EXPAND_HOME%\bin;C:\tools`,
+	}
+
+	cfg := &ExpandConfig{}
+	if err := ParseEnvWithOptions(cfg, ParseEnvOptions{Lookup: mapLookup(env), WindowsExpand: true}); err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Dir != `C:\Users\dev\AppData` {
+		t.Errorf("expected Dir to be expanded, got %q", cfg.Dir)
+	}
+	if cfg.Cache != `C:\Users\dev\cache` {
+		t.Errorf("expected the default of Cache to be expanded, got %q", cfg.Cache)
+	}
+	if cfg.Missing != `%EXPAND_UNSET%\x` {
+		t.Errorf("expected the reference to an unset variable to be kept, got %q", cfg.Missing)
+	}
+	if cfg.Ratio != `10% to 20%, 100%` {
+		t.Errorf("expected percent signs outside references to be kept, got %q", cfg.Ratio)
+	}
+	if len(cfg.Paths) != 2 || cfg.Paths[0] != `C:\Users\dev\bin` {
+		t.Errorf("expected Paths to be expanded before splitting, got %v", cfg.Paths)
+	}
+
+	// Without the option the values are read verbatim
+	cfg = &ExpandConfig{}
+	if err := ParseEnvFromMap(cfg, env); err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Dir != `%EXPAND_HOME%\AppData` || cfg.Cache != `%EXPAND_HOME%\cache` {
+		t.Errorf("expected the references to be kept without WindowsExpand, got %q and %q", cfg.Dir, cfg.Cache)
+	}
+}
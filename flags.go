@@ -0,0 +1,85 @@
+package lazyconf
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// envFlag is a flag.Value that records the raw value of a command-line flag, so it's converted by
+// ParseEnv like the value of the corresponding environment variable.
+type envFlag struct {
+	value  string
+	set    bool
+	isBool bool
+}
+
+func (f *envFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.value
+}
+
+func (f *envFlag) Set(s string) error {
+	f.value, f.set = s, true
+	return nil
+}
+
+// IsBoolFlag lets bool fields be set with a bare -flag, as with flag.Bool.
+func (f *envFlag) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// ParseFlagsAndEnv registers a flag on fs for every key declared by the struct pointed to by cfg, named
+// after the key in lower case (e.g. DB_HOST becomes -db_host), parses args and then populates cfg.
+// Flags that are given take precedence over environment variables, which are used for the others.
+func ParseFlagsAndEnv(cfg any, fs *flag.FlagSet, args []string) error {
+	return ParseFlagsAndEnvWithOptions(cfg, fs, args, ParseEnvOptions{})
+}
+
+// ParseFlagsAndEnvWithOptions is like ParseFlagsAndEnv, but populates cfg with opts. Flags that are
+// given take precedence over opts.Lookup, and keys rejected by opts.FieldFilter get no flag.
+func ParseFlagsAndEnvWithOptions(cfg any, fs *flag.FlagSet, args []string, opts ParseEnvOptions) error {
+	op := "xconf.ParseFlagsAndEnv"
+
+	if err := checkStructPointer(cfg); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	flags := make(map[string]*envFlag)
+	for _, info := range KeysWithOptions(cfg, opts) {
+		// Collected maps and indexed slices span several variables, which a single flag can't set
+		if slices.Contains(info.Options, "collectprefix") || slices.Contains(info.Options, "indexed") {
+			continue
+		}
+		if opts.FieldFilter != nil && !opts.FieldFilter(info.Key) {
+			continue
+		}
+		name := strings.ToLower(info.Key)
+		if fs.Lookup(name) != nil {
+			continue
+		}
+		f := &envFlag{value: info.Default, isBool: info.Type.Kind() == reflect.Bool}
+		fs.Var(f, name, fmt.Sprintf("sets %s (env %s)", info.Field, info.Key))
+		flags[info.Key] = f
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if opts.Lookup == nil && opts.ListKeys == nil {
+		opts.ListKeys = environKeys
+	}
+	lookup := opts.rawLookup
+	opts.Lookup = func(key string) (string, bool) {
+		if f, ok := flags[key]; ok && f.set {
+			return f.value, true
+		}
+		return lookup(key)
+	}
+	return ParseEnvWithOptions(cfg, opts)
+}
@@ -0,0 +1,130 @@
+package lazyconf
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+type flagsConfig struct {
+	Host    string        `env:"FLAGS_HOST,default=localhost"`
+	Port    int           `env:"FLAGS_PORT,required"`
+	Debug   bool          `env:"FLAGS_DEBUG"`
+	Timeout time.Duration `env:"FLAGS_TIMEOUT"`
+	Tags    []string      `env:"FLAGS_TAGS"`
+	DB      struct {
+		_    struct{} `env:",prefix=FLAGS_DB_"`
+		Name string   `env:"NAME"`
+	}
+}
+
+// newTestFlagSet returns a flag set that reports errors instead of exiting.
+func newTestFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// TestParseFlagsAndEnvOverride tests that command-line flags take precedence over environment variables.
+func TestParseFlagsAndEnvOverride(t *testing.T) {
+	t.Setenv("FLAGS_HOST", "env.local")
+	t.Setenv("FLAGS_PORT", "8080")
+	t.Setenv("FLAGS_DB_NAME", "envdb")
+
+	cfg := &flagsConfig{}
+	fs := newTestFlagSet()
+	err := ParseFlagsAndEnv(cfg, fs, []string{"-flags_port=9090", "-flags_debug", "-flags_tags", "a,b", "-flags_db_name", "flagdb", "serve"})
+	if err != nil {
+		t.Fatalf("ParseFlagsAndEnv returned an error: %v", err)
+	}
+
+	if cfg.Port != 9090 {
+		t.Errorf("expected the flag to override Port, got %d", cfg.Port)
+	}
+	if !cfg.Debug {
+		t.Errorf("expected the bare bool flag to set Debug")
+	}
+	if len(cfg.Tags) != 2 || cfg.Tags[1] != "b" {
+		t.Errorf("expected Tags to be [a b], got %v", cfg.Tags)
+	}
+	if cfg.DB.Name != "flagdb" {
+		t.Errorf("expected the flag to override the prefixed DB.Name, got %q", cfg.DB.Name)
+	}
+	if cfg.Host != "env.local" {
+		t.Errorf("expected Host to fall back to the environment, got %q", cfg.Host)
+	}
+	if fs.NArg() != 1 || fs.Arg(0) != "serve" {
+		t.Errorf("expected the remaining arguments to be [serve], got %v", fs.Args())
+	}
+}
+
+// TestParseFlagsAndEnvFallback tests that unset flags fall back to environment variables and defaults.
+func TestParseFlagsAndEnvFallback(t *testing.T) {
+	t.Setenv("FLAGS_PORT", "8080")
+	t.Setenv("FLAGS_TIMEOUT", "5s")
+
+	cfg := &flagsConfig{}
+	fs := newTestFlagSet()
+	if err := ParseFlagsAndEnv(cfg, fs, nil); err != nil {
+		t.Fatalf("ParseFlagsAndEnv returned an error: %v", err)
+	}
+	if cfg.Port != 8080 || cfg.Timeout != 5*time.Second {
+		t.Errorf("expected Port and Timeout from the environment, got %d and %v", cfg.Port, cfg.Timeout)
+	}
+	if cfg.Host != "localhost" {
+		t.Errorf("expected Host to default to localhost, got %q", cfg.Host)
+	}
+	if f := fs.Lookup("flags_host"); f == nil || f.DefValue != "localhost" {
+		t.Errorf("expected the flags_host flag to show the default, got %+v", f)
+	}
+}
+
+// TestParseFlagsAndEnvErrors tests the errors of flag parsing and value conversion.
+func TestParseFlagsAndEnvErrors(t *testing.T) {
+	t.Setenv("FLAGS_PORT", "8080")
+
+	err := ParseFlagsAndEnv(&flagsConfig{}, newTestFlagSet(), []string{"-flags_port=http"})
+	if err == nil || !strings.Contains(err.Error(), "invalid int value for FLAGS_PORT (field Port)") {
+		t.Errorf("expected a conversion error for the flag value, got: %v", err)
+	}
+
+	err = ParseFlagsAndEnv(&flagsConfig{}, newTestFlagSet(), []string{"-unknown"})
+	if err == nil || !strings.Contains(err.Error(), "flag provided but not defined: -unknown") {
+		t.Errorf("expected an error for an unknown flag, got: %v", err)
+	}
+}
+
+// TestParseFlagsAndEnvWithOptions tests that flags take precedence over a custom Lookup and that
+// keys rejected by FieldFilter get no flag.
+func TestParseFlagsAndEnvWithOptions(t *testing.T) {
+	vars := map[string]string{"FLAGS_HOST": "map.local", "FLAGS_PORT": "8080", "FLAGS_DEBUG": "true"}
+	opts := ParseEnvOptions{
+		Lookup:      mapLookup(vars),
+		FieldFilter: func(key string) bool { return key != "FLAGS_DEBUG" },
+	}
+
+	cfg := &flagsConfig{}
+	fs := newTestFlagSet()
+	if err := ParseFlagsAndEnvWithOptions(cfg, fs, []string{"-flags_port=9090"}, opts); err != nil {
+		t.Fatalf("ParseFlagsAndEnvWithOptions returned an error: %v", err)
+	}
+	if cfg.Port != 9090 {
+		t.Errorf("expected the flag to override Port, got %d", cfg.Port)
+	}
+	if cfg.Host != "map.local" {
+		t.Errorf("expected Host to fall back to the custom Lookup, got %q", cfg.Host)
+	}
+	if cfg.Debug {
+		t.Errorf("expected the filtered Debug field to be left alone")
+	}
+	if fs.Lookup("flags_debug") != nil {
+		t.Errorf("expected no flag for the filtered FLAGS_DEBUG key")
+	}
+
+	err := ParseFlagsAndEnvWithOptions(flagsConfig{}, newTestFlagSet(), nil, opts)
+	if err == nil || !strings.HasPrefix(err.Error(), "xconf.ParseFlagsAndEnv: requires a non-nil pointer") {
+		t.Errorf("expected an error naming ParseFlagsAndEnv once, got: %v", err)
+	}
+}
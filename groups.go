@@ -0,0 +1,60 @@
+package lazyconf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// fieldGroup is a named set of fields of a struct that are constrained together, like the fields
+// tagged with the same group= or exclusive= option.
+type fieldGroup struct {
+	name    string
+	indexes []int
+}
+
+// fieldGroups holds the groups of a struct in the order they are first declared.
+type fieldGroups []fieldGroup
+
+// add adds the field at index to the named group.
+func (g *fieldGroups) add(name string, index int) {
+	for i := range *g {
+		if (*g)[i].name == name {
+			(*g)[i].indexes = append((*g)[i].indexes, index)
+			return
+		}
+	}
+	*g = append(*g, fieldGroup{name: name, indexes: []int{index}})
+}
+
+// setFields returns the names of the fields of the group holding a non-zero value.
+func (fg fieldGroup) setFields(v reflect.Value, path string) (set, all []string) {
+	for _, index := range fg.indexes {
+		name := path + v.Type().Field(index).Name
+		all = append(all, name)
+		if !v.Field(index).IsZero() {
+			set = append(set, name)
+		}
+	}
+	return set, all
+}
+
+// checkRequiredGroups returns an error for the first group none of whose fields holds a non-zero value.
+func checkRequiredGroups(v reflect.Value, groups fieldGroups, path string) error {
+	for _, fg := range groups {
+		if set, all := fg.setFields(v, path); len(set) == 0 {
+			return fmt.Errorf("group %s requires at least one of the fields %s to be set", fg.name, strings.Join(all, ", "))
+		}
+	}
+	return nil
+}
+
+// checkExclusiveGroups returns an error for the first group more than one of whose fields holds a non-zero value.
+func checkExclusiveGroups(v reflect.Value, groups fieldGroups, path string) error {
+	for _, fg := range groups {
+		if set, _ := fg.setFields(v, path); len(set) > 1 {
+			return fmt.Errorf("fields %s are mutually exclusive in group %s, only one may be set", strings.Join(set, ", "), fg.name)
+		}
+	}
+	return nil
+}
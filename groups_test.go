@@ -0,0 +1,87 @@
+package lazyconf
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseEnvRequiredGroup tests that a group= requires at least one of its fields to be set.
+func TestParseEnvRequiredGroup(t *testing.T) {
+	type GroupConfig struct {
+		Token    string `env:"GROUP_TOKEN,group=auth"`
+		User     string `env:"GROUP_USER,group=auth"`
+		CertFile string `env:"GROUP_CERT,group=auth"`
+		Host     string `env:"GROUP_HOST,group=target,default=localhost"`
+		Socket   string `env:"GROUP_SOCKET,group=target"`
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{"one member set", map[string]string{"GROUP_USER": "admin"}, ""},
+		{"all members set", map[string]string{"GROUP_TOKEN": "t", "GROUP_USER": "u", "GROUP_CERT": "c"}, ""},
+		{"no member set", map[string]string{}, "group auth requires at least one of the fields Token, User, CertFile to be set"},
+		{"empty member", map[string]string{"GROUP_TOKEN": ""}, "group auth"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&GroupConfig{}, tt.env)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ParseEnv returned an error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected an error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestParseEnvExclusiveGroup tests that exclusive= allows at most one field of a group to be set.
+func TestParseEnvExclusiveGroup(t *testing.T) {
+	type ExclusiveConfig struct {
+		Password string `env:"EXCLUSIVE_PASSWORD,exclusive=secret"`
+		KeyFile  string `env:"EXCLUSIVE_KEY_FILE,exclusive=secret"`
+		Vault    string `env:"EXCLUSIVE_VAULT,exclusive=secret"`
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{"none set", map[string]string{}, ""},
+		{"one set", map[string]string{"EXCLUSIVE_KEY_FILE": "/key"}, ""},
+		{"two set", map[string]string{"EXCLUSIVE_PASSWORD": "p", "EXCLUSIVE_VAULT": "v"}, "fields Password, Vault are mutually exclusive in group secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&ExclusiveConfig{}, tt.env)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ParseEnv returned an error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected an error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	// Both constraints combine into "exactly one of"
+	type ExactlyOneConfig struct {
+		Token string `env:"EXACTLY_TOKEN,group=auth,exclusive=auth"`
+		Cert  string `env:"EXACTLY_CERT,group=auth,exclusive=auth"`
+	}
+	if err := ParseEnvFromMap(&ExactlyOneConfig{}, map[string]string{}); err == nil {
+		t.Error("expected an error with no member set, but got none")
+	}
+	if err := ParseEnvFromMap(&ExactlyOneConfig{}, map[string]string{"EXACTLY_TOKEN": "t", "EXACTLY_CERT": "c"}); err == nil {
+		t.Error("expected an error with both members set, but got none")
+	}
+}
@@ -0,0 +1,53 @@
+package lazyconf
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// keyFolder normalizes the keys of a map field for the keycase option. Keys that collide once
+// normalized are an error unless the last one wins, as with the keylast option.
+type keyFolder struct {
+	fold func(string) string
+	last bool
+	seen map[string]string // normalized key -> original key
+}
+
+// newKeyFolder returns the folder for a keycase option value, or nil if keyCase is empty.
+func newKeyFolder(fieldType reflect.Type, keyCase string, last bool) (*keyFolder, error) {
+	if keyCase == "" {
+		if last {
+			return nil, errors.New("keylast option requires keycase")
+		}
+		return nil, nil
+	}
+	if fieldType.Kind() != reflect.Map || fieldType.Key().Kind() != reflect.String || checkSet(fieldType) || checkURLValues(fieldType) {
+		return nil, fmt.Errorf("keycase option requires a map with string keys, got %s", fieldType)
+	}
+
+	f := &keyFolder{last: last, seen: make(map[string]string)}
+	switch keyCase {
+	case "lower":
+		f.fold = strings.ToLower
+	case "upper":
+		f.fold = strings.ToUpper
+	default:
+		return nil, fmt.Errorf("keycase must be lower or upper, got %q", keyCase)
+	}
+	return f, nil
+}
+
+// apply returns the normalized key. A nil folder leaves keys unchanged.
+func (f *keyFolder) apply(key string) (string, error) {
+	if f == nil {
+		return key, nil
+	}
+	folded := f.fold(key)
+	if prev, ok := f.seen[folded]; ok && !f.last {
+		return "", fmt.Errorf("keys %q and %q collide as %q", prev, key, folded)
+	}
+	f.seen[folded] = key
+	return folded, nil
+}
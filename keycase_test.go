@@ -0,0 +1,76 @@
+package lazyconf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseEnvKeyCase tests normalizing map keys with keycase= and resolving collisions with keylast.
+func TestParseEnvKeyCase(t *testing.T) {
+	type KeyCaseConfig struct {
+		Labels  map[string]string `env:"KEYCASE_LABELS,keycase=lower"`
+		Weights map[string]int    `env:"KEYCASE_WEIGHTS,keycase=upper,keylast"`
+		Hosts   map[string]string `env:"KEYCASE_HOST_,collectprefix,keycase=lower"`
+	}
+
+	cfg := &KeyCaseConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"KEYCASE_LABELS":      "Env:prod,TEAM:core",
+		"KEYCASE_WEIGHTS":     "a:1,B:2,A:3",
+		"KEYCASE_HOST_API":    "api.local",
+		"KEYCASE_HOST_Search": "search.local",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if expected := map[string]string{"env": "prod", "team": "core"}; !reflect.DeepEqual(cfg.Labels, expected) {
+		t.Errorf("expected Labels to be %v, got %v", expected, cfg.Labels)
+	}
+	if expected := map[string]int{"A": 3, "B": 2}; !reflect.DeepEqual(cfg.Weights, expected) {
+		t.Errorf("expected Weights to be %v, got %v", expected, cfg.Weights)
+	}
+	if expected := map[string]string{"api": "api.local", "search": "search.local"}; !reflect.DeepEqual(cfg.Hosts, expected) {
+		t.Errorf("expected Hosts to be %v, got %v", expected, cfg.Hosts)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"colliding keys", map[string]string{"KEYCASE_LABELS": "env:prod,ENV:dev"}, `field Labels: keys "env" and "ENV" collide as "env"`},
+		{"colliding variables", map[string]string{"KEYCASE_HOST_API": "a", "KEYCASE_HOST_api": "b"}, `field Hosts: keys "API" and "api" collide as "api"`},
+		{"invalid value after normalizing", map[string]string{"KEYCASE_WEIGHTS": "a:x"}, `invalid map value "x" for key "A"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&KeyCaseConfig{}, tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+
+	type InvalidCaseConfig struct {
+		Labels map[string]string `env:"KEYCASE_INVALID,keycase=title"`
+	}
+	if err := ParseEnvFromMap(&InvalidCaseConfig{}, nil); err == nil || !strings.Contains(err.Error(), `keycase must be lower or upper, got "title"`) {
+		t.Errorf("expected an error for keycase=title, got: %v", err)
+	}
+
+	type SliceConfig struct {
+		Tags []string `env:"KEYCASE_TAGS,keycase=lower"`
+	}
+	if err := ParseEnvFromMap(&SliceConfig{}, nil); err == nil || !strings.Contains(err.Error(), "keycase option requires a map with string keys") {
+		t.Errorf("expected an error for keycase on a slice, got: %v", err)
+	}
+
+	type KeyLastConfig struct {
+		Labels map[string]string `env:"KEYCASE_LAST,keylast"`
+	}
+	if err := ParseEnvFromMap(&KeyLastConfig{}, nil); err == nil || !strings.Contains(err.Error(), "keylast option requires keycase") {
+		t.Errorf("expected an error for keylast without keycase, got: %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package lazyconf
+
+import (
+	"reflect"
+	"strings"
+)
+
+// KeyInfo describes an environment variable declared by a config struct.
+type KeyInfo struct {
+	Key      string       // Env key, including struct prefixes
+	Field    string       // Path of the Go field, e.g. "Database.Host"
+	Type     reflect.Type // Type of the field
+	Required bool         // Whether the field has the required option
+	Default  string       // Value of the default= option, if any
+	Options  []string     // Remaining tag options, e.g. "oneof=debug info" or "delim=;"
+}
+
+// Keys returns the environment variables declared by the struct pointed to by cfg, including
+// those of nested structs, in field order. Only the struct type is inspected, the environment
+// isn't read. It returns nil if cfg is not a pointer to a struct.
+func Keys(cfg any) []KeyInfo {
+	return KeysWithOptions(cfg, ParseEnvOptions{})
+}
+
+// KeysWithOptions is like Keys, but derives the keys of untagged fields with opts.KeyFromField
+// the way ParseEnvWithOptions does.
+func KeysWithOptions(cfg any, opts ParseEnvOptions) []KeyInfo {
+	if err := checkStructPointer(cfg); err != nil {
+		return nil
+	}
+	return collectKeys(reflect.TypeOf(cfg).Elem(), "", "", opts, nil, make(map[reflect.Type]bool))
+}
+
+// collectKeys appends the keys declared by the fields of structType to keys.
+func collectKeys(structType reflect.Type, prefix, path string, opts ParseEnvOptions, keys []KeyInfo, visited map[reflect.Type]bool) []KeyInfo {
+	if visited[structType] {
+		return keys
+	}
+	visited[structType] = true
+	defer delete(visited, structType)
+
+	prefix += structPrefix(structType)
+
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		tag := field.Tag.Get("env")
+		if isIgnoredTag(tag) || !field.IsExported() {
+			continue
+		}
+
+		// Nested structs contribute their own keys, like they are parsed
+		if isNestedStruct(field, tag) {
+			keys = collectKeys(field.Type, prefix, path+field.Name+".", opts, keys, visited)
+			if key, _, _ := strings.Cut(tag, ","); key == "" {
+				continue
+			}
+		}
+		if field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct && !checkRegexp(field.Type) {
+			keys = collectKeys(field.Type.Elem(), prefix, path+field.Name+".", opts, keys, visited)
+			continue
+		}
+
+		parts := splitTag(tag)
+		key := parts[0]
+		if key == "" && opts.KeyFromField != nil && (field.Type.Kind() != reflect.Struct || isValueStruct(field.Type)) {
+			key = opts.KeyFromField(field.Name)
+		}
+		if key == "" || key == "_" {
+			continue
+		}
+
+		info := KeyInfo{Key: prefix + key, Field: path + field.Name, Type: field.Type}
+		for _, opt := range parts[1:] {
+			if opt == "required" {
+				info.Required = true
+			} else if strings.HasPrefix(opt, "default=") {
+				info.Default = strings.TrimPrefix(opt, "default=")
+			} else {
+				info.Options = append(info.Options, opt)
+			}
+		}
+		keys = append(keys, info)
+	}
+	return keys
+}
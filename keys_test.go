@@ -0,0 +1,73 @@
+package lazyconf
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestKeys tests listing the keys declared by the sample Config.
+func TestKeys(t *testing.T) {
+	keys := Keys(&Config{})
+
+	if n := reflect.TypeOf(Config{}).NumField(); len(keys) != n {
+		t.Fatalf("expected %d keys, got %d: %+v", n, len(keys), keys)
+	}
+
+	byField := make(map[string]KeyInfo)
+	for _, k := range keys {
+		byField[k.Field] = k
+	}
+
+	expected := []KeyInfo{
+		{Key: "STRING_FIELD", Field: "StringField", Type: reflect.TypeOf("")},
+		{Key: "DEFAULT_FIELD", Field: "DefaultField", Type: reflect.TypeOf(""), Default: "defaultValue"},
+		{Key: "REQUIRED_FIELD", Field: "RequiredField", Type: reflect.TypeOf(""), Required: true},
+		{Key: "TIMES_FIELD", Field: "Times", Type: reflect.TypeOf([]time.Time{})},
+		{Key: "INT_FIELD", Field: "CustomField", Type: reflect.TypeOf(CustomType{})},
+	}
+	for _, want := range expected {
+		if got := byField[want.Field]; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %+v, got %+v", want, got)
+		}
+	}
+
+	if Keys(Config{}) != nil {
+		t.Error("expected nil for a non-pointer config")
+	}
+}
+
+// TestKeysNested tests that nested structs contribute their keys with prefixes applied.
+func TestKeysNested(t *testing.T) {
+	type KeysDatabase struct {
+		_    struct{} `env:",prefix=DB_"`
+		Host string   `env:"HOST,required"`
+		Port int
+	}
+	type KeysConfig struct {
+		Level    string `env:"LEVEL,default=info,oneof=debug info"`
+		Database KeysDatabase
+		Replica  *KeysDatabase
+		Skipped  string `env:"-"`
+		internal string `env:"INTERNAL"`
+	}
+	_ = KeysConfig{}.internal
+
+	keys := KeysWithOptions(&KeysConfig{}, ParseEnvOptions{KeyFromField: SnakeUpper})
+
+	expected := []KeyInfo{
+		{Key: "LEVEL", Field: "Level", Type: reflect.TypeOf(""), Default: "info", Options: []string{"oneof=debug info"}},
+		{Key: "DB_HOST", Field: "Database.Host", Type: reflect.TypeOf(""), Required: true},
+		{Key: "DB_PORT", Field: "Database.Port", Type: reflect.TypeOf(0)},
+		{Key: "DB_HOST", Field: "Replica.Host", Type: reflect.TypeOf(""), Required: true},
+		{Key: "DB_PORT", Field: "Replica.Port", Type: reflect.TypeOf(0)},
+	}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected %+v, got %+v", expected, keys)
+	}
+
+	// Without KeyFromField untagged fields declare no key
+	if keys := Keys(&KeysConfig{}); len(keys) != 3 {
+		t.Errorf("expected 3 keys without KeyFromField, got %+v", keys)
+	}
+}
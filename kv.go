@@ -0,0 +1,55 @@
+package lazyconf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// setKV parses comma separated key=value pairs, e.g. "host=localhost,port=5432", into the fields of
+// the struct fieldValue. A key matches a field by its env key or json tag name, case-insensitively.
+// Unknown keys are ignored unless strict is set.
+func setKV(fieldValue reflect.Value, envVal string, strict bool, opts ParseEnvOptions) error {
+	structType := fieldValue.Type()
+
+	for _, pair := range strings.Split(envVal, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return fmt.Errorf("malformed pair %q, expected key=value", pair)
+		}
+
+		index := kvFieldIndex(structType, key)
+		if index < 0 {
+			if strict {
+				return fmt.Errorf("unknown key %q for %s", key, structType)
+			}
+			continue
+		}
+
+		field := structType.Field(index)
+		parsed, err := parseSingleField(field, key, value, opts)
+		if err != nil {
+			return err
+		}
+		fieldValue.Field(index).Set(parsed)
+	}
+	return nil
+}
+
+// kvFieldIndex returns the index of the exported field of structType whose env key or json tag
+// name matches key, or -1 if there is none.
+func kvFieldIndex(structType reflect.Type, key string) int {
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		if !field.IsExported() || isIgnoredTag(field.Tag.Get("env")) {
+			continue
+		}
+		envKey, _, _ := strings.Cut(field.Tag.Get("env"), ",")
+		jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if (envKey != "" && strings.EqualFold(envKey, key)) || (jsonName != "" && jsonName != "-" && strings.EqualFold(jsonName, key)) {
+			return i
+		}
+	}
+	return -1
+}
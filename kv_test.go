@@ -0,0 +1,76 @@
+package lazyconf
+
+import (
+	"testing"
+)
+
+type KVDatabase struct {
+	Host string `env:"host"`
+	Port int    `json:"port"`
+}
+
+// TestParseEnvKV tests parsing key=value pairs into a struct field.
+func TestParseEnvKV(t *testing.T) {
+	type KVConfig struct {
+		DB     KVDatabase `env:"KV_DB,parser=kv"`
+		Strict KVDatabase `env:"KV_STRICT,parser=kv,strict"`
+	}
+
+	cfg := &KVConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"KV_DB":     "host=localhost,PORT=5432,user=admin",
+		"KV_STRICT": "port=6543",
+		"host":      "ignored",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.DB.Host != "localhost" || cfg.DB.Port != 5432 {
+		t.Errorf("expected DB to be {localhost 5432}, got %+v", cfg.DB)
+	}
+	if cfg.Strict.Host != "" || cfg.Strict.Port != 6543 {
+		t.Errorf("expected Strict to be { 6543}, got %+v", cfg.Strict)
+	}
+
+	// The filter selects the kv field by its own key, the pairs inside it are not filtered again
+	filtered := &KVConfig{}
+	err = ParseEnvWithOptions(filtered, ParseEnvOptions{
+		Lookup:      mapLookup(map[string]string{"KV_DB": "host=localhost,port=5432", "KV_STRICT": "port=6543"}),
+		FieldFilter: func(key string) bool { return key == "KV_DB" },
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if filtered.DB.Host != "localhost" || filtered.DB.Port != 5432 {
+		t.Errorf("expected DB to be {localhost 5432}, got %+v", filtered.DB)
+	}
+	if filtered.Strict.Port != 0 {
+		t.Errorf("expected the filtered Strict field to be left alone, got %+v", filtered.Strict)
+	}
+}
+
+// TestParseEnvKVErrors tests unknown keys in strict mode, malformed pairs and invalid values.
+func TestParseEnvKVErrors(t *testing.T) {
+	type KVConfig struct {
+		DB KVDatabase `env:"KV_ERR_DB,parser=kv,strict"`
+	}
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"unknown key", "host=localhost,user=admin"},
+		{"malformed pair", "host"},
+		{"invalid value", "port=http"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&KVConfig{}, map[string]string{"KV_ERR_DB": tt.value})
+			if err == nil {
+				t.Fatalf("expected an error for %s, but got none", tt.name)
+			}
+		})
+	}
+}
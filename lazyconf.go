@@ -1,201 +1,1220 @@
 package lazyconf
 
 import (
+	"context"
 	"encoding"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"net"
+	"net/url"
 	"os"
 	"reflect"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+// setterMethodName is the default name of the setter method, see ParseEnvOptions.SetterMethod.
 const setterMethodName = "Scan"
 
+// defaultMethodPrefix is the prefix of config methods computing dynamic defaults, e.g. DefaultHost() string.
+const defaultMethodPrefix = "Default"
+
 type Setter interface {
 	Scan(value interface{}) error
 }
 
+// StringParser is implemented by types parsing themselves from the raw variable value. It is an
+// alternative to Setter for types that don't want to deal with an any-typed argument.
+type StringParser interface {
+	ParseString(s string) error
+}
+
+// Validatable is implemented by config structs that check their own values. Validate is called
+// once all fields of the struct, including nested structs, have been parsed.
+type Validatable interface {
+	Validate() error
+}
+
+// AfterParser is implemented by config structs that derive or adjust fields once parsing is done.
+// AfterParse is called after Validate succeeds and may mutate the struct.
+type AfterParser interface {
+	AfterParse() error
+}
+
+// transforms holds the string normalizations available to the transform= tag option.
+var transforms = map[string]func(string) string{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+}
+
+// ParseEnvOptions controls how ParseEnvWithOptions populates a config struct.
+type ParseEnvOptions struct {
+	// AllocateNilStructs allocates nil pointers to nested structs even when none of
+	// their environment variables are set. By default such pointers are left nil.
+	AllocateNilStructs bool
+
+	// Lookup retrieves the value of an environment variable and reports whether it is present.
+	// Defaults to os.LookupEnv.
+	Lookup func(key string) (string, bool)
+
+	// ListKeys lists the names of all variables available through Lookup, which fields tagged with
+	// collectprefix need to find their variables. Defaults to the names in os.Environ when Lookup is unset.
+	ListKeys func() []string
+
+	// Funcs holds named function registries for func-typed fields tagged with registry=<name>.
+	// The env value selects a function by its key in the registry.
+	Funcs map[string]map[string]any
+
+	// Factories holds named implementation registries for interface-typed fields tagged with
+	// registry=<name>. The env value selects a constructor by its key in the registry, and the
+	// constructed value is parsed recursively when it is a pointer to a struct.
+	Factories map[string]map[string]func() any
+
+	// DisableUnmarshalFallback turns off the implicit UnmarshalText/UnmarshalJSON fallback, so
+	// unmarshalers are only used when requested explicitly with parser=text or parser=json.
+	DisableUnmarshalFallback bool
+
+	// KeyFromField derives the env key of fields without one from the Go field name, e.g. SnakeUpper
+	// turns MaxConns into MAX_CONNS. By default fields without a key are skipped.
+	KeyFromField func(fieldName string) string
+
+	// ValidateTypesUpfront checks that every field ParseEnv would populate has a supported type
+	// before reading any value, so unsupported fields fail even when their variables are unset.
+	ValidateTypesUpfront bool
+
+	// SecretResolver resolves the values of fields tagged with the secret option, which are references
+	// such as "vault://path#key", into the actual values before they are converted.
+	SecretResolver func(ref string) (string, error)
+
+	// SecretResolverContext is like SecretResolver, but receives the context passed to ParseEnvContext
+	// so slow lookups can be cancelled. It takes precedence over SecretResolver.
+	SecretResolverContext func(ctx context.Context, ref string) (string, error)
+
+	// TrimQuotes strips a single pair of matching single or double quotes surrounding every value,
+	// e.g. FOO='bar' reads as bar. Slices are unquoted as a whole before they are split.
+	TrimQuotes bool
+
+	// FieldFilter, when set, restricts parsing to the fields whose env key, including any struct
+	// prefix, it returns true for. The other fields keep their current values, so a reload can
+	// refresh a subset of the configuration.
+	FieldFilter func(key string) bool
+
+	// RejectNonFinite makes parsed floats that are infinite or NaN, such as "inf" or "nan", an error.
+	// It applies to float fields, slice elements and map values.
+	RejectNonFinite bool
+
+	// SetterMethod is the name of the method used to set fields from their raw value, "Scan" by
+	// default. The method must take a string or an any and return an error.
+	SetterMethod string
+
+	// YAMLUnmarshal decodes the values of fields tagged with parser=yaml, e.g. yaml.Unmarshal from a
+	// YAML library of the caller's choice, which keeps this package free of a YAML dependency.
+	YAMLUnmarshal func(data []byte, v any) error
+
+	// PreserveExisting leaves fields that already hold a non-zero value untouched when their variable
+	// is unset, instead of re-applying defaults or enforcing required. Values set at runtime then
+	// survive parsing the same struct again.
+	PreserveExisting bool
+
+	// WindowsExpand replaces Windows-style %VAR% references in values and defaults by the values of
+	// the referenced variables, read through Lookup. References to unset variables are kept as-is.
+	WindowsExpand bool
+
+	// BytesEncoding is the encoding of []byte and [N]byte fields without a parser= option: "ints" (the
+	// default) for comma separated integers like other slices, "hex" or "base64".
+	BytesEncoding string
+
+	// OnField is called for every field set from a variable or a default once the struct holding it
+	// has been parsed, with the field's path, its env key including struct prefixes, its value and
+	// whether the value came from a default. It is purely observational, e.g. for audit logs or metrics.
+	// Collected maps are reported under their prefix, fields forced to false under their negate key,
+	// and indexed struct slices element field by element field, like nested structs.
+	OnField func(path, key string, value reflect.Value, fromDefault bool)
+
+	// AutoJSON decodes values starting with '{' or '[' with json.Unmarshal into fields whose type
+	// has no built-in parsing, such as plain structs, instead of reporting an unsupported type.
+	AutoJSON bool
+
+	// path is the dotted path of the struct being parsed, e.g. "Parent.Nested.", which
+	// prefixes field names in errors.
+	path string
+
+	// keyPrefix is the accumulated key prefix of the struct being parsed, which lookups prepend to
+	// the keys of its fields.
+	keyPrefix string
+
+	// ctx is the context passed to ParseEnvContext, if any.
+	ctx context.Context
+}
+
+// lookup retrieves a value through the configured Lookup function, falling back to os.LookupEnv,
+// and strips surrounding quotes from it if TrimQuotes is set.
+func (o ParseEnvOptions) lookup(key string) (string, bool) {
+	val, ok := o.rawLookup(key)
+	if o.TrimQuotes {
+		val = trimQuotes(val)
+	}
+	return val, ok
+}
+
+// rawLookup retrieves a value through the configured Lookup function, falling back to os.LookupEnv.
+func (o ParseEnvOptions) rawLookup(key string) (string, bool) {
+	if o.Lookup != nil {
+		return o.Lookup(key)
+	}
+	return os.LookupEnv(key)
+}
+
+// trimQuotes strips a single pair of matching single or double quotes surrounding s.
+func trimQuotes(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// ParseEnv parses environment variables into the struct pointed to by cfg using default options.
 func ParseEnv(cfg any) error {
+	return ParseEnvWithOptions(cfg, ParseEnvOptions{})
+}
+
+// context returns the context passed to ParseEnvContext, or context.Background().
+func (o ParseEnvOptions) context() context.Context {
+	if o.ctx != nil {
+		return o.ctx
+	}
+	return context.Background()
+}
+
+// resolveSecret resolves the secret reference with the configured resolver. A context-aware resolver
+// is preferred; otherwise a context that is already done skips the call to SecretResolver.
+func (o ParseEnvOptions) resolveSecret(ref string) (string, error) {
+	ctx := o.context()
+	if o.SecretResolverContext != nil {
+		return o.SecretResolverContext(ctx, ref)
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return o.SecretResolver(ref)
+}
+
+// setterMethod returns the configured setter method name.
+func (o ParseEnvOptions) setterMethod() string {
+	if o.SetterMethod != "" {
+		return o.SetterMethod
+	}
+	return setterMethodName
+}
+
+// withPath returns a copy of the options for parsing the nested struct of the named field.
+func (o ParseEnvOptions) withPath(name string) ParseEnvOptions {
+	o.path += name + "."
+	return o
+}
+
+// withPrefix returns a copy of the options whose lookups and field filter prepend prefix to every key.
+func (o ParseEnvOptions) withPrefix(prefix string) ParseEnvOptions {
+	// Only keys that can be enumerated are listed; a custom Lookup without ListKeys stays unlisted
+	if o.ListKeys != nil || o.Lookup == nil {
+		list := o.ListKeys
+		if list == nil {
+			list = environKeys
+		}
+		o.ListKeys = func() []string {
+			var keys []string
+			for _, key := range list() {
+				if name, ok := strings.CutPrefix(key, prefix); ok {
+					keys = append(keys, name)
+				}
+			}
+			return keys
+		}
+	}
+	lookup := o.rawLookup
+	o.Lookup = func(key string) (string, bool) {
+		return lookup(prefix + key)
+	}
+	if filter := o.FieldFilter; filter != nil {
+		o.FieldFilter = func(key string) bool {
+			return filter(prefix + key)
+		}
+	}
+	o.keyPrefix += prefix
+	return o
+}
+
+// Parse allocates a T, parses environment variables into it using default options and returns it.
+func Parse[T any]() (T, error) {
+	return ParseWith[T](ParseEnvOptions{})
+}
+
+// ParseWith allocates a T, parses environment variables into it using opts and returns it.
+func ParseWith[T any](opts ParseEnvOptions) (T, error) {
+	var cfg T
+	err := ParseEnvWithOptions(&cfg, opts)
+	return cfg, err
+}
+
+// ParseEnvJSON populates the struct pointed to by cfg from the JSON document in the environment
+// variable key, then parses the individual environment variables into it. Variables that are set
+// override the JSON values, while defaults only apply to fields the JSON left at their zero value.
+func ParseEnvJSON(cfg any, key string) error {
+	op := "xconf.ParseEnvJSON"
+
+	if err := checkStructPointer(cfg); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	if doc, _ := os.LookupEnv(key); doc != "" {
+		if err := json.Unmarshal([]byte(doc), cfg); err != nil {
+			return fmt.Errorf("%s: invalid JSON in environment variable %s: %v", op, key, err)
+		}
+	}
+	return ParseEnvWithOptions(cfg, ParseEnvOptions{PreserveExisting: true})
+}
+
+// ParseEnvContext is like ParseEnvWithOptions, but aborts with the context's error once ctx is done.
+// The context is passed to SecretResolverContext, so lookups performing I/O can be cancelled.
+func ParseEnvContext(ctx context.Context, cfg any, opts ParseEnvOptions) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("xconf.ParseEnv: %w", err)
+	}
+	opts.ctx = ctx
+	return ParseEnvWithOptions(cfg, opts)
+}
+
+// ParseEnvFromMap parses the values of vars instead of the process environment into the struct
+// pointed to by cfg. It doesn't touch global state, so it is safe to use from concurrent goroutines.
+func ParseEnvFromMap(cfg any, vars map[string]string) error {
+	return ParseEnvWithOptions(cfg, ParseEnvOptions{Lookup: mapLookup(vars), ListKeys: mapKeys(vars)})
+}
+
+// Snapshot captures the current process environment into a map. Passing the same snapshot to
+// several ParseEnvFromMap calls guarantees they all see the same values, even if the environment
+// changes in between.
+func Snapshot() map[string]string {
+	environ := os.Environ()
+	vars := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		key, value, _ := strings.Cut(kv, "=")
+		vars[key] = value
+	}
+	return vars
+}
+
+// mapLookup returns a Lookup function reading from vars.
+func mapLookup(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		val, ok := vars[key]
+		return val, ok
+	}
+}
+
+// ParseEnvWithOptions parses environment variables into the struct pointed to by cfg.
+func ParseEnvWithOptions(cfg any, opts ParseEnvOptions) error {
 	op := "xconf.ParseEnv"
 
+	if err := checkStructPointer(cfg); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	switch opts.BytesEncoding {
+	case "", "ints", "hex", "base64":
+	default:
+		return fmt.Errorf("%s: unknown BytesEncoding %q, expected ints, hex or base64", op, opts.BytesEncoding)
+	}
+
 	val := reflect.ValueOf(cfg)
 	v := val.Elem()
 	t := v.Type()
 
-	for i := range t.NumField() {
+	// Check all field types before reading any value. Nested structs are covered by this check,
+	// so it isn't repeated for them.
+	if opts.ValidateTypesUpfront {
+		if err := validateTypes(t, opts, make(map[reflect.Type]bool)); err != nil {
+			return err
+		}
+		opts.ValidateTypesUpfront = false
+	}
+
+	// A struct may declare a prefix for the keys of all its fields, including nested structs
+	if prefix := structPrefix(t); prefix != "" {
+		opts = opts.withPrefix(prefix)
+	}
+
+	// The discriminator is parsed first, since it decides which of the other fields are parsed
+	disc, err := readDiscriminator(t, opts)
+	if err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	var templates []templateField
+	var groups, exclusives fieldGroups
+	var setFields []setField
+	for _, i := range disc.order(t.NumField()) {
 		field := t.Field(i)
+		fieldPath := opts.path + field.Name
 		tag := field.Tag.Get("env")
 
-		// If the field is a struct, recursively parse it
-		if field.Type.Kind() == reflect.Struct {
-			if err := ParseEnv(v.Field(i).Addr().Interface()); err != nil {
+		// If the field is explicitly ignored, skip it entirely
+		if isIgnoredTag(tag) {
+			continue
+		}
+
+		// Fields tagged when= are left untouched unless the discriminator selects them,
+		// so their required options only apply to the selected variant
+		selected, variant, err := disc.selects(tag, v)
+		if err != nil {
+			return fmt.Errorf("%s: field %s: %v", op, fieldPath, err)
+		}
+		if !selected {
+			continue
+		}
+
+		// If the field is a struct, recursively parse it. Unexported structs, such as the
+		// internals of a typed atomic, can't be populated and are skipped.
+		if isNestedStruct(field, tag) {
+			if err := ParseEnvWithOptions(v.Field(i).Addr().Interface(), opts.withPath(field.Name)); err != nil {
 				return err
 			}
+
+			// A nested struct tagged without an env key only carries options, e.g. env:",required"
+			if tag != "" && strings.HasPrefix(tag, ",") {
+				if hasTagOption(tag, "required") && !hasEnvValues(field.Type, opts, nil) {
+					return fmt.Errorf("%s: required nested struct %s has none of its environment variables set", op, fieldPath)
+				}
+				continue
+			}
 		}
 
-		// If the field is not tagged, skip it
-		if tag == "" {
+		// If the field is a pointer to a struct, allocate it when needed and recursively parse it
+		if field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct && !checkRegexp(field.Type) {
+			if !v.Field(i).CanSet() {
+				continue
+			}
+			hasValues := hasEnvValues(field.Type.Elem(), opts, nil)
+			if hasTagOption(tag, "required") && !hasValues {
+				return fmt.Errorf("%s: required nested struct %s has none of its environment variables set", op, fieldPath)
+			}
+			if v.Field(i).IsNil() {
+				// Leave the pointer nil unless something is going to be set in it or it is the selected variant
+				if !opts.AllocateNilStructs && !hasValues && !variant {
+					continue
+				}
+				v.Field(i).Set(reflect.New(field.Type.Elem()))
+			}
+			if err := ParseEnvWithOptions(v.Field(i).Interface(), opts.withPath(field.Name)); err != nil {
+				return err
+			}
 			continue
 		}
 
+		// If the field has no key, derive it from the field name or skip the field
+		if key, _, _ := strings.Cut(tag, ","); key == "" {
+			if opts.KeyFromField == nil || !field.IsExported() || (field.Type.Kind() == reflect.Struct && !isValueStruct(field.Type)) {
+				continue
+			}
+			tag = opts.KeyFromField(field.Name) + tag
+		}
+
 		// Parse the tag
-		parts := strings.Split(tag, ",")
+		parts := splitTag(tag)
 		envKey := parts[0]
 		required := false
+		escaped := false
+		unique, uniqueStrict := false, false
+		isTemplate := false
+		isSecret := false
+		indexed := false
+		merged := false
+		appendSlice := false
+		presence := false
+		collect := false
+		strictParse := false
+		nonEmpty := false
+		validJSON := false
+		zeroAsUnset := false
+		layout := ""
+		delim, innerDelim := ",", ":"
+		mapSep, kvSep := ",", ":"
+		minStr, maxStr := "", ""
+		lenStr, minLenStr, maxLenStr := "", "", ""
+		decimal := "."
+		strict := false
 		defaultVal := ""
 		setterName := ""
+		decoderName := ""
+		regexSplit := ""
+		keyCase := ""
+		keyLast := false
 
 		// Parse the tag options
 		parserType := ""
+		registryName := ""
+		negateKey := ""
+		trueToken, falseToken := "", ""
+		var transformNames, oneOf []string
 		for _, opt := range parts[1:] {
 			if opt == "required" {
 				required = true
+			} else if opt == "escaped" {
+				escaped = true
+			} else if strings.HasPrefix(opt, "delim=") {
+				delim = unescapeSeparator(strings.TrimPrefix(opt, "delim="))
+			} else if opt == "strict" {
+				strict = true
+			} else if strings.HasPrefix(opt, "decimal=") {
+				decimal = strings.TrimPrefix(opt, "decimal=")
+			} else if strings.HasPrefix(opt, "mapsep=") {
+				mapSep = unescapeSeparator(strings.TrimPrefix(opt, "mapsep="))
+			} else if strings.HasPrefix(opt, "kvsep=") {
+				kvSep = unescapeSeparator(strings.TrimPrefix(opt, "kvsep="))
+			} else if strings.HasPrefix(opt, "innerdelim=") {
+				innerDelim = unescapeSeparator(strings.TrimPrefix(opt, "innerdelim="))
+			} else if strings.HasPrefix(opt, "layout=") {
+				layout = strings.TrimPrefix(opt, "layout=")
+			} else if strings.HasPrefix(opt, "layouts=") {
+				layout = strings.TrimPrefix(opt, "layouts=")
+			} else if opt == "indexed" {
+				indexed = true
+			} else if opt == "merged" {
+				merged = true
+			} else if opt == "keylast" {
+				keyLast = true
+			} else if strings.HasPrefix(opt, "keycase=") {
+				keyCase = strings.TrimPrefix(opt, "keycase=")
+			} else if opt == "nonempty" {
+				nonEmpty = true
+			} else if opt == "json" {
+				validJSON = true
+			} else if opt == "zeroasunset" {
+				zeroAsUnset = true
+			} else if opt == "strictparse" {
+				strictParse = true
+			} else if opt == "collectprefix" {
+				collect = true
+			} else if opt == "presence" {
+				presence = true
+			} else if opt == "append" {
+				appendSlice = true
+			} else if opt == "secret" {
+				isSecret = true
+			} else if opt == "template" {
+				isTemplate = true
+			} else if opt == "unique" {
+				unique = true
+			} else if opt == "uniquestrict" {
+				unique, uniqueStrict = true, true
 			} else if strings.HasPrefix(opt, "default=") {
 				defaultVal = strings.TrimPrefix(opt, "default=")
 			} else if strings.HasPrefix(opt, "setter=") {
 				setterName = strings.TrimPrefix(opt, "setter=")
+			} else if strings.HasPrefix(opt, "regexsplit=") {
+				regexSplit = strings.TrimPrefix(opt, "regexsplit=")
+			} else if strings.HasPrefix(opt, "decoder=") {
+				decoderName = strings.TrimPrefix(opt, "decoder=")
 			} else if strings.HasPrefix(opt, "parser=") {
 				parserType = strings.TrimPrefix(opt, "parser=")
+			} else if strings.HasPrefix(opt, "transform=") {
+				transformNames = strings.Split(strings.TrimPrefix(opt, "transform="), "+")
+				for _, name := range transformNames {
+					if _, ok := transforms[name]; !ok {
+						return fmt.Errorf("%s: unknown transform '%s' for field %s", op, name, fieldPath)
+					}
+				}
+			} else if strings.HasPrefix(opt, "negate=") {
+				negateKey = strings.TrimPrefix(opt, "negate=")
+			} else if strings.HasPrefix(opt, "registry=") {
+				registryName = strings.TrimPrefix(opt, "registry=")
+			} else if strings.HasPrefix(opt, "true=") {
+				trueToken = strings.TrimPrefix(opt, "true=")
+			} else if strings.HasPrefix(opt, "false=") {
+				falseToken = strings.TrimPrefix(opt, "false=")
+			} else if strings.HasPrefix(opt, "group=") {
+				groups.add(strings.TrimPrefix(opt, "group="), i)
+			} else if strings.HasPrefix(opt, "exclusive=") {
+				exclusives.add(strings.TrimPrefix(opt, "exclusive="), i)
+			} else if strings.HasPrefix(opt, "min=") {
+				minStr = strings.TrimPrefix(opt, "min=")
+			} else if strings.HasPrefix(opt, "max=") {
+				maxStr = strings.TrimPrefix(opt, "max=")
+			} else if strings.HasPrefix(opt, "len=") {
+				lenStr = strings.TrimPrefix(opt, "len=")
+			} else if strings.HasPrefix(opt, "minlen=") {
+				minLenStr = strings.TrimPrefix(opt, "minlen=")
+			} else if strings.HasPrefix(opt, "maxlen=") {
+				maxLenStr = strings.TrimPrefix(opt, "maxlen=")
+			} else if strings.HasPrefix(opt, "oneof=") {
+				oneOf = strings.Fields(strings.TrimPrefix(opt, "oneof="))
+			}
+		}
+
+		if delim == "" || innerDelim == "" || mapSep == "" || kvSep == "" {
+			return fmt.Errorf("%s: empty delimiter for field %s", op, fieldPath)
+		}
+		if decimal != "." && decimal != "," {
+			return fmt.Errorf("%s: decimal separator for field %s must be '.' or ',', got %q", op, fieldPath, decimal)
+		}
+		if escaped && len(delim) != 1 {
+			return fmt.Errorf("%s: escaped option for field %s requires a single-byte delimiter, got %q", op, fieldPath, delim)
+		}
+		splitter := listSplitter{delim: delim, escaped: escaped}
+		if regexSplit != "" {
+			if field.Type.Kind() != reflect.Slice || escaped {
+				return fmt.Errorf("%s: regexsplit option for field %s requires a slice without the escaped option, got %s", op, fieldPath, field.Type)
+			}
+			re, err := compileSplitRegexp(regexSplit)
+			if err != nil {
+				return fmt.Errorf("%s: invalid regexsplit pattern for field %s: %v", op, fieldPath, err)
+			}
+			splitter.re = re
+		}
+
+		// Plain byte slices and arrays without a parser use the configured encoding
+		if parserType == "" && opts.BytesEncoding != "" && isPlainBytes(field.Type) {
+			parserType = opts.BytesEncoding
+		}
+
+		if negateKey != "" && field.Type.Kind() != reflect.Bool {
+			return fmt.Errorf("%s: negate option for field %s requires a bool field, got %s", op, fieldPath, field.Type)
+		}
+		if presence && field.Type.Kind() != reflect.Bool {
+			return fmt.Errorf("%s: presence option for field %s requires a bool field, got %s", op, fieldPath, field.Type)
+		}
+
+		if strictParse && (field.Type.Kind() == reflect.Slice || !checkIntegerKind(field.Type) && !checkFloatKind(field.Type)) {
+			return fmt.Errorf("%s: strictparse option for field %s requires a numeric field, got %s", op, fieldPath, field.Type)
+		}
+
+		if nonEmpty && !checkStringKind(field.Type) {
+			return fmt.Errorf("%s: nonempty option for field %s requires a string or string slice field, got %s", op, fieldPath, field.Type)
+		}
+
+		if zeroAsUnset && (field.Type.Kind() == reflect.Slice || field.Type.Kind() == reflect.Map || presence) {
+			return fmt.Errorf("%s: zeroasunset option for field %s requires a scalar field, got %s", op, fieldPath, field.Type)
+		}
+
+		if validJSON && field.Type.Kind() != reflect.String {
+			return fmt.Errorf("%s: json option for field %s requires a string field, got %s", op, fieldPath, field.Type)
+		}
+
+		if indexed && field.Type.Kind() != reflect.Slice {
+			return fmt.Errorf("%s: indexed option for field %s requires a slice, got %s", op, fieldPath, field.Type)
+		}
+		if merged && (field.Type.Kind() != reflect.Slice || indexed) {
+			return fmt.Errorf("%s: merged option for field %s requires a slice without the indexed option, got %s", op, fieldPath, field.Type)
+		}
+		if appendSlice && field.Type.Kind() != reflect.Slice {
+			return fmt.Errorf("%s: append option for field %s requires a slice, got %s", op, fieldPath, field.Type)
+		}
+
+		// The unique option can only deduplicate slices of comparable elements
+		if unique && (field.Type.Kind() != reflect.Slice || !field.Type.Elem().Comparable()) {
+			return fmt.Errorf("%s: unique option for field %s requires a slice of comparable elements, got %s", op, fieldPath, field.Type)
+		}
+
+		var fieldBounds bounds
+		hasBounds := minStr != "" || maxStr != ""
+		if hasBounds {
+			asDuration := slices.Contains(strings.Split(parserType, "|"), "duration")
+			b, err := parseBounds(field.Type, minStr, maxStr, asDuration)
+			if err != nil {
+				return fmt.Errorf("%s: field %s: %v", op, fieldPath, err)
+			}
+			fieldBounds = b
+		}
+
+		var fieldLength lengthLimits
+		hasLength := lenStr != "" || minLenStr != "" || maxLenStr != ""
+		if hasLength {
+			l, err := parseLengthLimits(field.Type, lenStr, minLenStr, maxLenStr)
+			if err != nil {
+				return fmt.Errorf("%s: field %s: %v", op, fieldPath, err)
+			}
+			fieldLength = l
+		}
+
+		folder, err := newKeyFolder(field.Type, keyCase, keyLast)
+		if err != nil {
+			return fmt.Errorf("%s: field %s: %v", op, fieldPath, err)
+		}
+
+		// Leave fields rejected by the filter untouched
+		if opts.FieldFilter != nil && envKey != "_" && !opts.FieldFilter(envKey) {
+			continue
+		}
+
+		// Maps tagged collectprefix gather every variable whose name starts with the key
+		if collect {
+			if err := collectPrefixed(v.Field(i), envKey, folder, opts); err != nil {
+				return fmt.Errorf("%s: field %s: %v", op, fieldPath, err)
+			}
+			if required && v.Field(i).Len() == 0 {
+				return fmt.Errorf("%s: required field %s has no variables with prefix %s", op, fieldPath, envKey)
+			}
+			if v.Field(i).Len() > 0 {
+				setFields = append(setFields, setField{index: i, path: fieldPath, key: opts.keyPrefix + envKey})
+			}
+			continue
+		}
+
+		// Indexed struct slices parse every element from KEY_0_*, KEY_1_*, ... variables
+		if indexed && isIndexedStructSlice(field.Type) {
+			n, err := parseIndexedStructs(v.Field(i), envKey, opts.withPath(field.Name))
+			if err != nil {
+				return err
 			}
+			if n == 0 && required {
+				return fmt.Errorf("%s: required slice field %s is empty", op, fieldPath)
+			}
+			continue
 		}
 
 		// Get the value from the environment
 		var envVal string
+		var indexedVals []string
+		present := false
 		if envKey == "_" {
 			envVal = ""
+		} else if indexed {
+			// Indexed slices read KEY_0, KEY_1, ... up to the first missing index
+			indexedVals = lookupIndexed(envKey, opts)
+			present = len(indexedVals) > 0
+			envVal = strings.Join(indexedVals, delim)
+		} else if merged {
+			// Merged slices read the KEY list and apply KEY_0, KEY_1, ... on top of it
+			indexedVals, present, err = lookupMerged(envKey, splitter, opts)
+			if err != nil {
+				return fmt.Errorf("%s: field %s: %v", op, fieldPath, err)
+			}
+			if present {
+				envVal = strings.Join(indexedVals, delim)
+			}
 		} else {
-			envVal = os.Getenv(envKey)
+			envVal, present = opts.lookup(envKey)
+		}
+
+		// Keep values populated before parsing, e.g. by ParseEnvJSON or an earlier parse, unless the variable overrides them
+		if opts.PreserveExisting && !present && !v.Field(i).IsZero() {
+			continue
+		}
+
+		// Presence flags are true when the variable is set at all, whatever its value
+		if presence {
+			v.Field(i).SetBool(present)
+			if present {
+				setFields = append(setFields, setField{index: i, path: fieldPath, key: opts.keyPrefix + envKey})
+			}
+			continue
+		}
+
+		// A numeric variable that is set must hold a number, an empty value doesn't fall back to the default
+		if strictParse && present && strings.TrimSpace(envVal) == "" {
+			return fmt.Errorf("%s: environment variable %s for field %s is set but empty", op, envKey, fieldPath)
+		}
+
+		// A value that parses to the zero value counts as unset, so the default and required apply to it.
+		// A value that doesn't parse is left for the checks below to report.
+		if zeroAsUnset && envVal != "" {
+			parsed, err := parseSingleField(field, envKey, envVal, opts, zeroAsUnsetSkipped...)
+			if err == nil && parsed.IsZero() {
+				envVal, present = "", false
+			}
+		}
+
+		// Resolve default indirection: "$OTHER_VAR" reads another variable, a leading "$$" escapes a literal "$"
+		if envVal == "" {
+			if strings.HasPrefix(defaultVal, "$$") {
+				defaultVal = defaultVal[1:]
+			} else if strings.HasPrefix(defaultVal, "$") {
+				defaultVal, _ = opts.lookup(defaultVal[1:])
+			}
+		}
+
+		// Compute the default by the Default<FieldName> method if there is no static default
+		if defaultVal == "" {
+			if method := val.MethodByName(defaultMethodPrefix + field.Name); method.IsValid() {
+				if method.Type().NumIn() != 0 || method.Type().NumOut() != 1 || method.Type().Out(0).Kind() != reflect.String {
+					return fmt.Errorf("%s: default method '%s' for field '%s' must have signature func() string", op, defaultMethodPrefix+field.Name, fieldPath)
+				}
+				if envVal == "" {
+					defaultVal = method.Call(nil)[0].String()
+				}
+			}
 		}
 
+		fromDefault := false
 		if envVal == "" {
-			if required && defaultVal == "" {
-				return fmt.Errorf("%s: required environment variable %s not set", op, envKey)
+			// A variable that is set, even to an empty value, satisfies required, except for slices
+			// which must have at least one element
+			if required && !present && defaultVal == "" {
+				return fmt.Errorf("%s: required environment variable %s for field %s not set", op, envKey, fieldPath)
+			}
+			if required && defaultVal == "" && field.Type.Kind() == reflect.Slice {
+				return fmt.Errorf("%s: required slice field %s is empty", op, fieldPath)
 			}
 			if defaultVal != "" {
 				envVal = defaultVal
+				fromDefault = true
+			}
+
+			// default=now and default=zero are sentinels for time.Time fields rather than values to parse
+			if checkTime(field.Type) && (defaultVal == timeDefaultNow || defaultVal == timeDefaultZero) {
+				if !v.Field(i).CanSet() {
+					return fmt.Errorf("%s: field %s is not exported", op, fieldPath)
+				}
+				timeVal := time.Time{}
+				if defaultVal == timeDefaultNow {
+					timeVal = time.Now().UTC()
+				}
+				v.Field(i).Set(reflect.ValueOf(timeVal))
+				setFields = append(setFields, setField{index: i, path: fieldPath, key: opts.keyPrefix + envKey, fromDefault: true})
+				continue
+			}
+		}
+
+		// Indexed and merged values are already split, so the stages below apply to each of their
+		// elements and join them into envVal again. A default is split like any other value.
+		if !present || fromDefault {
+			indexedVals = nil
+		}
+
+		// Expand %VAR% references against the active lookup
+		if opts.WindowsExpand {
+			envVal, _ = mapElements(envVal, indexedVals, delim, func(s string) (string, error) {
+				return expandWindows(s, opts.lookup), nil
+			})
+		}
+
+		// Every path below either sets a non-empty value or fails, so the field is reported as set
+		if envVal != "" {
+			setFields = append(setFields, setField{index: i, path: fieldPath, key: opts.keyPrefix + envKey, fromDefault: fromDefault})
+		}
+
+		// Secret values are references resolved through the configured resolver
+		if isSecret && envVal != "" {
+			if opts.SecretResolver == nil && opts.SecretResolverContext == nil {
+				return fmt.Errorf("%s: secret option for field %s requires a SecretResolver", op, fieldPath)
+			}
+			envVal, err = mapElements(envVal, indexedVals, delim, func(ref string) (string, error) {
+				resolved, err := opts.resolveSecret(ref)
+				if ctxErr := opts.context().Err(); ctxErr != nil {
+					return "", fmt.Errorf("%s: resolving secret for field %s aborted: %w", op, fieldPath, ctxErr)
+				}
+				if err != nil {
+					// Don't leak the reference, which may point at sensitive paths
+					return "", fmt.Errorf("%s: failed to resolve secret for field %s: %s", op, fieldPath, strings.ReplaceAll(err.Error(), ref, "<redacted>"))
+				}
+				return resolved, nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// Templates are rendered once the rest of the struct has been parsed
+		if isTemplate {
+			if envVal != "" {
+				templates = append(templates, templateField{index: i, key: envKey, value: envVal})
+			}
+			continue
+		}
+
+		// Apply the transforms in the order they are listed
+		for _, name := range transformNames {
+			envVal, _ = mapElements(envVal, indexedVals, delim, func(s string) (string, error) {
+				return transforms[name](s), nil
+			})
+		}
+
+		// Validate the value against the allowed set, every element of it for slices
+		if len(oneOf) > 0 && envVal != "" && field.Type.Kind() == reflect.Slice {
+			elems := indexedVals
+			if indexedVals == nil {
+				elems = splitter.split(envVal)
+			}
+			for idx, elem := range elems {
+				if !slices.Contains(oneOf, elem) {
+					return fmt.Errorf("%s: value '%s' at index %d of field %s must be one of [%s]", op, elem, idx, fieldPath, strings.Join(oneOf, " "))
+				}
 			}
+		} else if len(oneOf) > 0 && envVal != "" && !checkSet(field.Type) && !slices.Contains(oneOf, envVal) {
+			return fmt.Errorf("%s: value '%s' for field %s must be one of [%s]", op, envVal, fieldPath, strings.Join(oneOf, " "))
 		}
 
 		// Set the value by provided setter method if it's name is mentioned in the tag option "setter"
 		if setterName != "" {
 			setter := val.MethodByName(setterName)
 			if !setter.IsValid() {
-				return fmt.Errorf("%s: setter method '%s' for field '%s' not found", op, setterName, field.Name)
+				return fmt.Errorf("%s: setter method '%s' for field '%s' not found", op, setterName, fieldPath)
 			}
 
 			errs := setter.Call([]reflect.Value{reflect.ValueOf(envVal)})
 			if len(errs) > 0 && !errs[0].IsNil() {
-				return fmt.Errorf("%s: setter method '%s' for field '%s' failed: %v", op, setterName, field.Name, errs[0].Interface())
+				return fmt.Errorf("%s: setter method '%s' for field '%s' failed: %v", op, setterName, fieldPath, errs[0].Interface())
 			}
 			continue
 		}
 
 		// Check if the field is exported
 		if !v.Field(i).CanSet() {
-			return fmt.Errorf("%s: field %s is not exported", op, field.Name)
+			return fmt.Errorf("%s: field %s is not exported", op, fieldPath)
 		}
 
-		// Check if the field implements the Setter interface
-		if v.Field(i).CanAddr() {
-			set := v.Field(i).Addr().MethodByName(setterMethodName)
-			if set.IsValid() {
-				errs := set.Call([]reflect.Value{reflect.ValueOf(envVal)})
-				if len(errs) > 0 && !errs[0].IsNil() {
-					return fmt.Errorf("%s: failed to set value for field %s: %v", op, field.Name, errs[0].Interface())
+		// Decode the value by the method mentioned in the tag option "decoder" and assign its result
+		if decoderName != "" {
+			decoder := val.MethodByName(decoderName)
+			if !decoder.IsValid() {
+				return fmt.Errorf("%s: decoder method '%s' for field '%s' not found", op, decoderName, fieldPath)
+			}
+			if err := checkDecoderType(decoder.Type(), field.Type); err != nil {
+				return fmt.Errorf("%s: decoder method '%s' for field '%s' %v", op, decoderName, fieldPath, err)
+			}
+			if envVal != "" {
+				out := decoder.Call([]reflect.Value{reflect.ValueOf(envVal)})
+				if !out[1].IsNil() {
+					return fmt.Errorf("%s: decoder method '%s' for field '%s' failed: %v", op, decoderName, fieldPath, out[1].Interface())
 				}
-				continue
+				decoded := out[0]
+				if decoded.Kind() == reflect.Interface {
+					decoded = decoded.Elem()
+				}
+				if !decoded.IsValid() || !decoded.Type().AssignableTo(field.Type) {
+					return fmt.Errorf("%s: decoder method '%s' for field '%s' returned %s, which is not assignable to %s", op, decoderName, fieldPath, typeName(decoded), field.Type)
+				}
+				v.Field(i).Set(decoded)
 			}
+			continue
 		}
 
-		// Handle parser tag if present
-		if parserType != "" {
-			if envVal != "" {
-				if parserType == "text" && checkTextUnmarshaler(field.Type) {
-					if v.Field(i).CanAddr() {
-						unmarshaler := v.Field(i).Addr().Interface().(encoding.TextUnmarshaler)
-						if err := unmarshaler.UnmarshalText([]byte(envVal)); err != nil {
-							return fmt.Errorf("%s: failed to unmarshal text for field %s: %v", op, field.Name, err)
-						}
-						continue
-					}
-				} else if parserType == "json" && checkJSONUnmarshaler(field.Type) {
-					if v.Field(i).CanAddr() {
-						unmarshaler := v.Field(i).Addr().Interface().(json.Unmarshaler)
-						if err := unmarshaler.UnmarshalJSON([]byte(envVal)); err != nil {
-							return fmt.Errorf("%s: failed to unmarshal JSON for field %s: %v", op, field.Name, err)
-						}
-						continue
+		// A truthy negate key forces the field to false, whatever its own value
+		if negateKey != "" {
+			if negateVal, _ := opts.lookup(negateKey); negateVal != "" {
+				negated, err := parseBool(negateVal, trueToken, falseToken)
+				if err != nil {
+					return fmt.Errorf("%s: invalid boolean value for %s (field %s): %v", op, negateKey, fieldPath, err)
+				}
+				if negated {
+					v.Field(i).SetBool(false)
+					// A field set from its own variable is already reported under that key
+					if envVal == "" {
+						setFields = append(setFields, setField{index: i, path: fieldPath, key: opts.keyPrefix + negateKey})
 					}
+					continue
 				}
-				// If parser tag is specified but type doesn't implement the interface, return error
-				return fmt.Errorf("%s: field %s does not implement required unmarshaler interface for parser=%s", op, field.Name, parserType)
 			}
 		}
 
-		// Set the value based on the field type
-		if envVal != "" {
-			// Try UnmarshalText/JSON first for all types
-			if tryUnmarshalMethods(v.Field(i), field.Type, envVal) {
-				continue
+		// Resolve function fields by name from the registry mentioned in the tag option "registry"
+		if registryName != "" && field.Type.Kind() == reflect.Func {
+			if envVal != "" {
+				registry, ok := opts.Funcs[registryName]
+				if !ok {
+					return fmt.Errorf("%s: function registry '%s' for field %s not provided", op, registryName, fieldPath)
+				}
+				fn, ok := registry[envVal]
+				if !ok {
+					return fmt.Errorf("%s: function '%s' for field %s is not registered in registry '%s'", op, envVal, fieldPath, registryName)
+				}
+				fnVal := reflect.ValueOf(fn)
+				if !fnVal.IsValid() || !fnVal.Type().AssignableTo(field.Type) {
+					return fmt.Errorf("%s: function '%s' in registry '%s' of type %T is not assignable to field %s of type %s", op, envVal, registryName, fn, fieldPath, field.Type)
+				}
+				v.Field(i).Set(fnVal)
+			}
+			continue
+		}
+
+		// Construct interface fields by name from the factory registry mentioned in the tag option "registry"
+		if registryName != "" && field.Type.Kind() == reflect.Interface {
+			if envVal != "" {
+				registry, ok := opts.Factories[registryName]
+				if !ok {
+					return fmt.Errorf("%s: factory registry '%s' for field %s not provided", op, registryName, fieldPath)
+				}
+				factory, ok := registry[envVal]
+				if !ok {
+					return fmt.Errorf("%s: implementation '%s' for field %s is not registered in registry '%s'", op, envVal, fieldPath, registryName)
+				}
+				impl := factory()
+				implVal := reflect.ValueOf(impl)
+				if !implVal.IsValid() || !implVal.Type().AssignableTo(field.Type) {
+					return fmt.Errorf("%s: implementation '%s' in registry '%s' of type %T does not implement %s for field %s", op, envVal, registryName, impl, field.Type, fieldPath)
+				}
+				if implVal.Kind() == reflect.Ptr && implVal.Elem().Kind() == reflect.Struct {
+					if err := ParseEnvWithOptions(impl, opts.withPath(field.Name)); err != nil {
+						return err
+					}
+				}
+				v.Field(i).Set(implVal)
+			}
+			continue
+		}
+
+		// Check if the field implements the Setter interface
+		if v.Field(i).CanAddr() {
+			if set, ok := setterMethod(v.Field(i).Addr(), opts.setterMethod()); ok {
+				errs := set.Call([]reflect.Value{reflect.ValueOf(envVal)})
+				if len(errs) > 0 && !errs[0].IsNil() {
+					return fmt.Errorf("%s: failed to set value for field %s: %v", op, fieldPath, errs[0].Interface())
+				}
+				continue
+			}
+		}
+
+		// Populate typed atomics such as atomic.Int64 and atomic.Bool by their Store method
+		if storeType, ok := checkAtomicStore(field.Type); ok {
+			if envVal != "" {
+				storeField := field
+				storeField.Type = storeType
+				// The secret, if any, is already resolved
+				parsed, err := parseSingleField(storeField, envKey, envVal, opts, "secret")
+				if err != nil {
+					return err
+				}
+				v.Field(i).Addr().MethodByName("Store").Call([]reflect.Value{parsed})
+			}
+			continue
+		}
+
+		// Handle parser tag if present. The tag may list several parsers separated by "|",
+		// which are attempted in order until one of them succeeds.
+		if parserType != "" {
+			if envVal != "" {
+				var errs parserErrors
+				pc := parserContext{decimal: decimal, strict: strict, trueToken: trueToken, falseToken: falseToken, splitter: splitter, opts: opts.withPath(field.Name)}
+				if indexedVals != nil {
+					pc.elems = indexedVals
+				}
+				for _, name := range strings.Split(parserType, "|") {
+					// Parse into a fresh value so a failed attempt doesn't leave the field half-populated
+					parsed := reflect.New(field.Type).Elem()
+					err := applyParser(name, parsed, envVal, pc)
+					if err == nil {
+						if appendSlice {
+							parsed = appendSlices(v.Field(i), parsed)
+						}
+						v.Field(i).Set(parsed)
+						errs = nil
+						break
+					}
+					errs = append(errs, err)
+				}
+				if len(errs) == 1 {
+					return fmt.Errorf("%s: field %s: %w", op, fieldPath, errs[0])
+				}
+				if len(errs) > 1 {
+					return fmt.Errorf("%s: all parsers failed for field %s: %w", op, fieldPath, errs)
+				}
+				if unique {
+					deduped, err := uniqueSlice(v.Field(i), uniqueStrict)
+					if err != nil {
+						return fmt.Errorf("%s: field %s: %v", op, fieldPath, err)
+					}
+					v.Field(i).Set(deduped)
+				}
+				if hasLength {
+					if err := fieldLength.check(v.Field(i)); err != nil {
+						return fmt.Errorf("%s: field %s: %v", op, fieldPath, err)
+					}
+				}
+				if hasBounds {
+					if err := fieldBounds.check(v.Field(i)); err != nil {
+						return fmt.Errorf("%s: field %s: %v", op, fieldPath, err)
+					}
+				}
+				if opts.RejectNonFinite {
+					if err := checkFinite(v.Field(i)); err != nil {
+						return fmt.Errorf("%s: field %s: %v", op, fieldPath, err)
+					}
+				}
+				if nonEmpty {
+					if err := checkNonEmpty(v.Field(i)); err != nil {
+						return fmt.Errorf("%s: field %s: %v", op, fieldPath, err)
+					}
+				}
+				if validJSON && !json.Valid([]byte(v.Field(i).String())) {
+					return fmt.Errorf("%s: field %s: value is not valid JSON", op, fieldPath)
+				}
+				continue
+			}
+		}
+
+		// Set the value based on the field type
+		if envVal != "" {
+			// Parsers registered with RegisterType take precedence over the type's own methods
+			if parse, ok := registeredParser(field.Type); ok {
+				parsed, err := parse(envVal)
+				if err != nil {
+					return fmt.Errorf("%s: invalid value for field %s: %w", op, fieldPath, err)
+				}
+				v.Field(i).Set(parsed)
+				continue
+			}
+
+			// ParseString takes precedence over the unmarshalers
+			if checkStringParser(field.Type) {
+				if err := v.Field(i).Addr().Interface().(StringParser).ParseString(envVal); err != nil {
+					return fmt.Errorf("%s: failed to parse value for field %s: %w", op, fieldPath, err)
+				}
+				continue
+			}
+
+			// Try UnmarshalText/JSON first for all types
+			// time.Time is left to the built-in parsing below, which honors layout= and the fallbacks
+			var unmarshalErr error
+			if !opts.DisableUnmarshalFallback && !checkTime(field.Type) {
+				var ok bool
+				if ok, unmarshalErr = tryUnmarshalMethods(v.Field(i), field.Type, envVal); ok {
+					continue
+				}
+			}
+
+			// Values of types without built-in parsing that look like JSON are decoded as JSON
+			if opts.AutoJSON && looksLikeJSON(envVal) && !checkValueType(field.Type, opts) {
+				if err := json.Unmarshal([]byte(envVal), v.Field(i).Addr().Interface()); err != nil {
+					return fmt.Errorf("%s: invalid JSON for field %s: %v", op, fieldPath, err)
+				}
+				continue
+			}
+
+			// Months and weekdays are also accepted by name
+			if checkCalendarEnum(field.Type) {
+				n, err := parseCalendarEnum(field.Type, envVal)
+				if err != nil {
+					return fmt.Errorf("%s: invalid value for field %s: %v", op, fieldPath, err)
+				}
+				v.Field(i).SetInt(n)
+				continue
 			}
 
 			switch field.Type.Kind() {
 			case reflect.String:
 				v.Field(i).SetString(envVal)
 			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
-				vl, err := strconv.ParseInt(envVal, 10, 64)
+				vl, err := strconv.ParseInt(trimDigitSeparators(envVal), 10, field.Type.Bits())
 				if err != nil {
-					return fmt.Errorf("%s: invalid int value for %s: %v", op, envKey, err)
+					if errors.Is(err, strconv.ErrRange) {
+						minVal, maxVal := intLimits(field.Type.Bits())
+						return fmt.Errorf("%s: value %s for field %s overflows %s (range %d to %d)", op, envVal, fieldPath, field.Type.Kind(), minVal, maxVal)
+					}
+					// Surface the rejection of the type's own unmarshaler rather than a generic parse error
+					if unmarshalErr != nil {
+						return fmt.Errorf("%s: failed to unmarshal value for field %s: %w", op, fieldPath, unmarshalErr)
+					}
+					return fmt.Errorf("%s: invalid int value for %s (field %s): %v", op, envKey, fieldPath, err)
 				}
 				v.Field(i).SetInt(vl)
 			case reflect.Int64:
 				if checkTimeDuration(field.Type) {
-					dur, err := time.ParseDuration(envVal)
+					dur, err := parseDuration(envVal)
 					if err != nil {
-						return fmt.Errorf("%s: invalid time duration value for field \"%s\", env var \"%s\": %s, error: %v", op, field.Name, envKey, envVal, err)
+						return fmt.Errorf("%s: invalid time duration value for field \"%s\", env var \"%s\": %s, error: %v", op, fieldPath, envKey, envVal, err)
 					}
 					v.Field(i).Set(reflect.ValueOf(dur))
 					break
 				}
-				vl, err := strconv.ParseInt(envVal, 10, 64)
+				vl, err := strconv.ParseInt(trimDigitSeparators(envVal), 10, 64)
 				if err != nil {
-					return fmt.Errorf("%s: invalid %s value for %s: %v", op, field.Type.Kind(), envKey, err)
+					if errors.Is(err, strconv.ErrRange) {
+						return fmt.Errorf("%s: value %s for field %s overflows %s (range %d to %d)", op, envVal, fieldPath, field.Type.Kind(), math.MinInt64, math.MaxInt64)
+					}
+					if unmarshalErr != nil {
+						return fmt.Errorf("%s: failed to unmarshal value for field %s: %w", op, fieldPath, unmarshalErr)
+					}
+					return fmt.Errorf("%s: invalid %s value for %s (field %s): %v", op, field.Type.Kind(), envKey, fieldPath, err)
 				}
 				v.Field(i).SetInt(vl)
-			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-				vl, err := strconv.ParseUint(envVal, 10, 64)
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+				vl, err := strconv.ParseUint(trimDigitSeparators(envVal), 10, field.Type.Bits())
 				if err != nil {
-					return fmt.Errorf("%s: invalid unsigned integer value for %s: %v", op, envKey, err)
+					if errors.Is(err, strconv.ErrRange) {
+						return fmt.Errorf("%s: value %s for field %s overflows %s (max %d)", op, envVal, fieldPath, field.Type.Kind(), uintLimit(field.Type.Bits()))
+					}
+					if unmarshalErr != nil {
+						return fmt.Errorf("%s: failed to unmarshal value for field %s: %w", op, fieldPath, unmarshalErr)
+					}
+					return fmt.Errorf("%s: invalid unsigned integer value for %s (field %s): %v", op, envKey, fieldPath, err)
 				}
 				v.Field(i).SetUint(vl)
 			case reflect.Float32, reflect.Float64:
-				vl, err := strconv.ParseFloat(envVal, 64)
+				vl, err := strconv.ParseFloat(trimDigitSeparators(envVal), 64)
 				if err != nil {
-					return fmt.Errorf("%s: invalid float value for %s: %v", op, envKey, err)
+					if unmarshalErr != nil {
+						return fmt.Errorf("%s: failed to unmarshal value for field %s: %w", op, fieldPath, unmarshalErr)
+					}
+					return fmt.Errorf("%s: invalid float value for %s (field %s): %v", op, envKey, fieldPath, err)
 				}
 				v.Field(i).SetFloat(vl)
 			case reflect.Bool:
-				val, err := strconv.ParseBool(envVal)
+				val, err := parseBool(envVal, trueToken, falseToken)
 				if err != nil {
-					return fmt.Errorf("%s: invalid boolean value for %s: %v", op, envKey, err)
+					if unmarshalErr != nil {
+						return fmt.Errorf("%s: failed to unmarshal value for field %s: %w", op, fieldPath, unmarshalErr)
+					}
+					return fmt.Errorf("%s: invalid boolean value for %s (field %s): %v", op, envKey, fieldPath, err)
 				}
 				v.Field(i).SetBool(val)
 			case reflect.Slice:
-				// If the field is a slice, split the value by comma and set the elements
-				vals := strings.Split(envVal, ",")
+				// net.HardwareAddr is a byte slice, but it's written as a single MAC address
+				if checkHardwareAddr(field.Type) {
+					mac, err := net.ParseMAC(envVal)
+					if err != nil {
+						return fmt.Errorf("%s: invalid MAC address for field %s: %v", op, fieldPath, err)
+					}
+					v.Field(i).Set(reflect.ValueOf(mac))
+					break
+				}
+
+				// Slice elements fall back to UnmarshalText/JSON unless disabled
+				tryElement := tryUnmarshalSliceElement
+				if opts.DisableUnmarshalFallback {
+					tryElement = func(reflect.Type, string) (reflect.Value, bool) { return reflect.Value{}, false }
+				}
+
+				// If the field is a slice, split the value by the delimiter and set the elements
+				var vals []string
+				if indexedVals != nil {
+					vals = indexedVals
+				} else {
+					vals = splitter.split(envVal)
+				}
 				ln := len(vals)
 				refSlice := reflect.MakeSlice(field.Type, 0, ln)
 
 				// If Slice elements implement Setter interface then set the value
-				if checkSliceElementsSetter(field.Type) {
+				if checkSliceElementsSetter(field.Type, opts.setterMethod()) {
 					for _, vl := range vals {
-						elem := reflect.New(field.Type.Elem()).Interface().(Setter)
-						if err := elem.Scan(vl); err != nil {
-							return fmt.Errorf("%s: failed to set value for field %s: %v", op, field.Name, err)
+						elem := reflect.New(field.Type.Elem())
+						set, _ := setterMethod(elem, opts.setterMethod())
+						if errs := set.Call([]reflect.Value{reflect.ValueOf(vl)}); !errs[0].IsNil() {
+							return fmt.Errorf("%s: failed to set value for field %s: %v", op, fieldPath, errs[0].Interface())
+						}
+						refSlice = reflect.Append(refSlice, elem.Elem())
+					}
+				} else if parse, ok := registeredParser(field.Type.Elem()); ok {
+					for idx, vl := range vals {
+						parsed, err := parse(vl)
+						if err != nil {
+							return fmt.Errorf("%s: invalid element %d of field %s: %w", op, idx, fieldPath, err)
+						}
+						refSlice = reflect.Append(refSlice, parsed)
+					}
+				} else if checkStringParser(field.Type.Elem()) {
+					for idx, vl := range vals {
+						elem := reflect.New(field.Type.Elem())
+						if err := elem.Interface().(StringParser).ParseString(vl); err != nil {
+							return fmt.Errorf("%s: failed to parse element %d of field %s: %w", op, idx, fieldPath, err)
 						}
-						refSlice = reflect.Append(refSlice, reflect.ValueOf(elem).Elem())
+						refSlice = reflect.Append(refSlice, elem.Elem())
 					}
 				} else {
 					// If Slice elements are of basic types then set the value
@@ -203,240 +1222,1177 @@ func ParseEnv(cfg any) error {
 					case reflect.String:
 						// Try UnmarshalText/JSON for each string element first
 						for _, vl := range vals {
-							if elem, ok := tryUnmarshalSliceElement(field.Type.Elem(), vl); ok {
+							if elem, ok := tryElement(field.Type.Elem(), vl); ok {
 								refSlice = reflect.Append(refSlice, elem)
 							} else {
-								refSlice = reflect.Append(refSlice, reflect.ValueOf(vl))
+								refSlice = reflect.Append(refSlice, reflect.ValueOf(vl).Convert(field.Type.Elem()))
 							}
 						}
-					case reflect.Int:
-						for _, vl := range vals {
-							if elem, ok := tryUnmarshalSliceElement(field.Type.Elem(), vl); ok {
-								refSlice = reflect.Append(refSlice, elem)
-							} else {
-								intVal, err := strconv.ParseInt(vl, 10, 32)
-								if err != nil {
-									return fmt.Errorf("%s: invalid integer value for %s: %v", op, envKey, err)
-								}
-								refSlice = reflect.Append(refSlice, reflect.ValueOf(int(intVal)))
-							}
-						}
-					case reflect.Int8:
-						for _, vl := range vals {
-							if elem, ok := tryUnmarshalSliceElement(field.Type.Elem(), vl); ok {
-								refSlice = reflect.Append(refSlice, elem)
-							} else {
-								intVal, err := strconv.ParseInt(vl, 10, 8)
-								if err != nil {
-									return fmt.Errorf("%s: invalid integer value for %s: %v", op, envKey, err)
-								}
-								refSlice = reflect.Append(refSlice, reflect.ValueOf(int8(intVal)))
-							}
-						}
-					case reflect.Int16:
-						for _, vl := range vals {
-							if elem, ok := tryUnmarshalSliceElement(field.Type.Elem(), vl); ok {
-								refSlice = reflect.Append(refSlice, elem)
-							} else {
-								intVal, err := strconv.ParseInt(vl, 10, 16)
+					case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+						if checkCalendarEnum(field.Type.Elem()) {
+							for idx, vl := range vals {
+								n, err := parseCalendarEnum(field.Type.Elem(), vl)
 								if err != nil {
-									return fmt.Errorf("%s: invalid integer value for %s: %v", op, envKey, err)
+									return fmt.Errorf("%s: invalid element %d of field %s: %v", op, idx, fieldPath, err)
 								}
-								refSlice = reflect.Append(refSlice, reflect.ValueOf(int16(intVal)))
+								refSlice = reflect.Append(refSlice, reflect.ValueOf(n).Convert(field.Type.Elem()))
 							}
+							break
 						}
-					case reflect.Int32:
-						for _, vl := range vals {
-							if elem, ok := tryUnmarshalSliceElement(field.Type.Elem(), vl); ok {
-								refSlice = reflect.Append(refSlice, elem)
-							} else {
-								intVal, err := strconv.ParseInt(vl, 10, 32)
-								if err != nil {
-									return fmt.Errorf("%s: invalid integer value for %s: %v", op, envKey, err)
-								}
-								refSlice = reflect.Append(refSlice, reflect.ValueOf(int32(intVal)))
-							}
-						}
-					case reflect.Int64:
 						if checkTimeDuration(field.Type.Elem()) {
 							for _, vl := range vals {
-								if elem, ok := tryUnmarshalSliceElement(field.Type.Elem(), vl); ok {
+								if elem, ok := tryElement(field.Type.Elem(), vl); ok {
 									refSlice = reflect.Append(refSlice, elem)
 								} else {
-									dur, err := time.ParseDuration(vl)
+									dur, err := parseDuration(vl)
 									if err != nil {
-										return fmt.Errorf("%s: invalid time duration value for %s: %v", op, envKey, err)
+										return fmt.Errorf("%s: invalid time duration value for %s (field %s): %v", op, envKey, fieldPath, err)
 									}
-									refSlice = reflect.Append(refSlice, reflect.ValueOf(dur))
+									refSlice = reflect.Append(refSlice, reflect.ValueOf(dur).Convert(field.Type.Elem()))
 								}
 							}
-						} else {
-							for _, vl := range vals {
-								if elem, ok := tryUnmarshalSliceElement(field.Type.Elem(), vl); ok {
-									refSlice = reflect.Append(refSlice, elem)
-								} else {
-									intVal, err := strconv.ParseInt(vl, 10, 64)
-									if err != nil {
-										return fmt.Errorf("%s: invalid integer value for %s: %v", op, envKey, err)
+							break
+						}
+						bits := field.Type.Elem().Bits()
+						for idx, vl := range vals {
+							if elem, ok := tryElement(field.Type.Elem(), vl); ok {
+								refSlice = reflect.Append(refSlice, elem)
+							} else {
+								intVal, err := strconv.ParseInt(trimDigitSeparators(vl), 10, bits)
+								if err != nil {
+									if errors.Is(err, strconv.ErrRange) {
+										minVal, maxVal := intLimits(bits)
+										return fmt.Errorf("%s: value %s at index %d of field %s overflows %s (range %d to %d)", op, vl, idx, fieldPath, field.Type.Elem().Kind(), minVal, maxVal)
 									}
-									refSlice = reflect.Append(refSlice, reflect.ValueOf(intVal))
+									return fmt.Errorf("%s: invalid integer value for %s (field %s): %v", op, envKey, fieldPath, err)
 								}
+								refSlice = reflect.Append(refSlice, reflect.ValueOf(intVal).Convert(field.Type.Elem()))
 							}
 						}
-					case reflect.Uint:
-						for _, vl := range vals {
-							uintVal, err := strconv.ParseUint(vl, 10, 32)
-							if err != nil {
-								return fmt.Errorf("%s: invalid unsigned integer value for %s: %v", op, envKey, err)
-							}
-							refSlice = reflect.Append(refSlice, reflect.ValueOf(uint(uintVal)))
-						}
-					case reflect.Uint8:
-						for _, vl := range vals {
-							uintVal, err := strconv.ParseUint(vl, 10, 8)
-							if err != nil {
-								return fmt.Errorf("%s: invalid unsigned integer value for %s: %v", op, envKey, err)
-							}
-							refSlice = reflect.Append(refSlice, reflect.ValueOf(uint8(uintVal)))
-						}
-					case reflect.Uint16:
-						for _, vl := range vals {
-							uintVal, err := strconv.ParseUint(vl, 10, 16)
-							if err != nil {
-								return fmt.Errorf("%s: invalid unsigned integer value for %s: %v", op, envKey, err)
-							}
-							refSlice = reflect.Append(refSlice, reflect.ValueOf(uint16(uintVal)))
-						}
-					case reflect.Uint32:
-						for _, vl := range vals {
-							uintVal, err := strconv.ParseUint(vl, 10, 32)
-							if err != nil {
-								return fmt.Errorf("%s: invalid unsigned integer value for %s: %v", op, envKey, err)
+					case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+						bits := field.Type.Elem().Bits()
+						for idx, vl := range vals {
+							if elem, ok := tryElement(field.Type.Elem(), vl); ok {
+								refSlice = reflect.Append(refSlice, elem)
+								continue
 							}
-							refSlice = reflect.Append(refSlice, reflect.ValueOf(uint32(uintVal)))
-						}
-					case reflect.Uint64:
-						for _, vl := range vals {
-							uintVal, err := strconv.ParseUint(vl, 10, 64)
+							uintVal, err := strconv.ParseUint(trimDigitSeparators(vl), 10, bits)
 							if err != nil {
-								return fmt.Errorf("%s: invalid unsigned integer value for %s: %v", op, envKey, err)
+								if errors.Is(err, strconv.ErrRange) {
+									return fmt.Errorf("%s: value %s at index %d of field %s overflows %s (max %d)", op, vl, idx, fieldPath, field.Type.Elem().Kind(), uintLimit(bits))
+								}
+								return fmt.Errorf("%s: invalid unsigned integer value for %s (field %s): %v", op, envKey, fieldPath, err)
 							}
-							refSlice = reflect.Append(refSlice, reflect.ValueOf(uintVal))
+							refSlice = reflect.Append(refSlice, reflect.ValueOf(uintVal).Convert(field.Type.Elem()))
 						}
 					case reflect.Float32:
 						for _, vl := range vals {
-							floatVal, err := strconv.ParseFloat(vl, 32)
+							if elem, ok := tryElement(field.Type.Elem(), vl); ok {
+								refSlice = reflect.Append(refSlice, elem)
+								continue
+							}
+							floatVal, err := strconv.ParseFloat(trimDigitSeparators(vl), 32)
 							if err != nil {
-								return fmt.Errorf("%s: invalid float value for %s: %v", op, envKey, err)
+								return fmt.Errorf("%s: invalid float value for %s (field %s): %v", op, envKey, fieldPath, err)
 							}
-							refSlice = reflect.Append(refSlice, reflect.ValueOf(float32(floatVal)))
+							refSlice = reflect.Append(refSlice, reflect.ValueOf(float32(floatVal)).Convert(field.Type.Elem()))
 						}
 					case reflect.Float64:
 						for _, vl := range vals {
-							floatVal, err := strconv.ParseFloat(vl, 64)
+							if elem, ok := tryElement(field.Type.Elem(), vl); ok {
+								refSlice = reflect.Append(refSlice, elem)
+								continue
+							}
+							floatVal, err := strconv.ParseFloat(trimDigitSeparators(vl), 64)
 							if err != nil {
-								return fmt.Errorf("%s: invalid float value for %s: %v", op, envKey, err)
+								return fmt.Errorf("%s: invalid float value for %s (field %s): %v", op, envKey, fieldPath, err)
 							}
-							refSlice = reflect.Append(refSlice, reflect.ValueOf(floatVal))
+							refSlice = reflect.Append(refSlice, reflect.ValueOf(floatVal).Convert(field.Type.Elem()))
 						}
 					case reflect.Bool:
 						for _, vl := range vals {
-							boolVal, err := strconv.ParseBool(vl)
+							if elem, ok := tryElement(field.Type.Elem(), vl); ok {
+								refSlice = reflect.Append(refSlice, elem)
+								continue
+							}
+							boolVal, err := parseBool(vl, trueToken, falseToken)
 							if err != nil {
-								return fmt.Errorf("%s: invalid boolean value for %s: %v", op, envKey, err)
+								return fmt.Errorf("%s: invalid boolean value for %s (field %s): %v", op, envKey, fieldPath, err)
 							}
-							refSlice = reflect.Append(refSlice, reflect.ValueOf(boolVal))
+							refSlice = reflect.Append(refSlice, reflect.ValueOf(boolVal).Convert(field.Type.Elem()))
 						}
 					case reflect.Struct:
 						if checkTime(field.Type.Elem()) {
-							for _, vl := range vals {
-								timeVal, err := time.Parse(time.RFC3339, vl)
+							for idx, vl := range vals {
+								timeVal, err := parseTime(vl, layout)
 								if err != nil {
-									return fmt.Errorf("%s: invalid time value for %s: %v", op, envKey, err)
+									return fmt.Errorf("%s: invalid time value %q at index %d of field %s: %v", op, vl, idx, fieldPath, err)
 								}
 								refSlice = reflect.Append(refSlice, reflect.ValueOf(timeVal))
 							}
+						} else if !opts.DisableUnmarshalFallback && (checkTextUnmarshaler(field.Type.Elem()) || checkJSONUnmarshaler(field.Type.Elem())) {
+							for idx, vl := range vals {
+								elem, err := unmarshalSliceElement(field.Type.Elem(), vl)
+								if err != nil {
+									return fmt.Errorf("%s: failed to unmarshal element %d of field %s: %w", op, idx, fieldPath, err)
+								}
+								refSlice = reflect.Append(refSlice, elem)
+							}
 						} else {
-							return fmt.Errorf("%s: unsupported struct slice type for field %s", op, field.Name)
+							return fmt.Errorf("%s: unsupported struct slice type for field %s", op, fieldPath)
+						}
+					case reflect.Slice:
+						if checkHardwareAddr(field.Type.Elem()) {
+							for idx, vl := range vals {
+								mac, err := net.ParseMAC(vl)
+								if err != nil {
+									return fmt.Errorf("%s: invalid MAC address at index %d of field %s: %v", op, idx, fieldPath, err)
+								}
+								refSlice = reflect.Append(refSlice, reflect.ValueOf(mac))
+							}
+							break
+						}
+
+						// Nested slices split every outer element again by the inner delimiter
+						innerType := field.Type.Elem()
+						for outer, vl := range vals {
+							innerVals := splitList(vl, innerDelim)
+							inner := reflect.MakeSlice(innerType, len(innerVals), len(innerVals))
+							for idx, innerVal := range innerVals {
+								if err := setPrimitive(inner.Index(idx), innerVal); err != nil {
+									return fmt.Errorf("%s: invalid value %q at index [%d][%d] of field %s: %v", op, innerVal, outer, idx, fieldPath, err)
+								}
+							}
+							refSlice = reflect.Append(refSlice, inner)
+						}
+					case reflect.Ptr:
+						if !checkRegexp(field.Type.Elem()) {
+							return fmt.Errorf("%s: unsupported slice type for field %s", op, fieldPath)
+						}
+						for idx, vl := range vals {
+							re, err := regexp.Compile(vl)
+							if err != nil {
+								return fmt.Errorf("%s: invalid regular expression at index %d of field %s: %v", op, idx, fieldPath, err)
+							}
+							refSlice = reflect.Append(refSlice, reflect.ValueOf(re))
 						}
 					default:
-						return fmt.Errorf("%s: unsupported slice type for field %s", op, field.Name)
+						return fmt.Errorf("%s: unsupported slice type for field %s", op, fieldPath)
+					}
+				}
+				if appendSlice {
+					refSlice = appendSlices(v.Field(i), refSlice)
+				}
+				if unique {
+					deduped, err := uniqueSlice(refSlice, uniqueStrict)
+					if err != nil {
+						return fmt.Errorf("%s: field %s: %v", op, fieldPath, err)
 					}
+					refSlice = deduped
+				}
+				if required && refSlice.Len() == 0 {
+					return fmt.Errorf("%s: required slice field %s is empty", op, fieldPath)
 				}
 				v.Field(i).Set(refSlice)
+			case reflect.Map:
+				if unmarshalErr != nil {
+					return fmt.Errorf("%s: failed to unmarshal value for field %s: %w", op, fieldPath, unmarshalErr)
+				}
+				// url.Values are written as a query string, which may repeat keys, e.g. "tag=a&tag=b"
+				if checkURLValues(field.Type) {
+					query, err := url.ParseQuery(envVal)
+					if err != nil {
+						return fmt.Errorf("%s: invalid query string for field %s: %v", op, fieldPath, err)
+					}
+					v.Field(i).Set(reflect.ValueOf(query))
+					break
+				}
+				// Sets are written as a list of their keys, e.g. "a,b"
+				if checkSet(field.Type) {
+					refMap := reflect.MakeMap(field.Type)
+					for _, token := range splitList(envVal, delim) {
+						if len(oneOf) > 0 && !slices.Contains(oneOf, token) {
+							return fmt.Errorf("%s: value '%s' for field %s must be one of [%s]", op, token, fieldPath, strings.Join(oneOf, " "))
+						}
+						mapKey := reflect.New(field.Type.Key()).Elem()
+						if err := setPrimitive(mapKey, token); err != nil {
+							return fmt.Errorf("%s: invalid set element %q of field %s: %v", op, token, fieldPath, err)
+						}
+						refMap.SetMapIndex(mapKey, reflect.Zero(field.Type.Elem()))
+					}
+					v.Field(i).Set(refMap)
+					break
+				}
+				// Maps are written as key/value pairs, e.g. "a:1,b:2"
+				refMap := reflect.MakeMap(field.Type)
+				for idx, entry := range splitList(envVal, mapSep) {
+					key, value, ok := strings.Cut(entry, kvSep)
+					if !ok {
+						return fmt.Errorf("%s: invalid map entry %q at index %d of field %s, expected key%svalue", op, entry, idx, fieldPath, kvSep)
+					}
+					// Keys are normalized before the value is converted
+					key, err := folder.apply(key)
+					if err != nil {
+						return fmt.Errorf("%s: field %s: %v", op, fieldPath, err)
+					}
+					mapKey := reflect.New(field.Type.Key()).Elem()
+					if err := setPrimitive(mapKey, key); err != nil {
+						return fmt.Errorf("%s: invalid map key %q of field %s: %v", op, key, fieldPath, err)
+					}
+					mapValue := reflect.New(field.Type.Elem()).Elem()
+					if err := setPrimitive(mapValue, value); err != nil {
+						return fmt.Errorf("%s: invalid map value %q for key %q of field %s: %v", op, value, key, fieldPath, err)
+					}
+					refMap.SetMapIndex(mapKey, mapValue)
+				}
+				v.Field(i).Set(refMap)
 			case reflect.Complex64, reflect.Complex128:
 				val, err := strconv.ParseComplex(envVal, 128)
 				if err != nil {
-					return fmt.Errorf("%s: invalid complex value for %s: %v", op, envKey, err)
+					return fmt.Errorf("%s: invalid complex value for %s (field %s): %v", op, envKey, fieldPath, err)
 				}
 				v.Field(i).SetComplex(val)
 			case reflect.Struct:
 				if checkTime(field.Type) {
-					timeVal, err := time.Parse(time.RFC3339, envVal)
+					timeVal, err := parseTime(envVal, layout)
 					if err != nil {
-						return fmt.Errorf("%s: invalid time value for field \"%s\", env var \"%s\": %s, error: %v", op, field.Name, envKey, envVal, err)
+						return fmt.Errorf("%s: invalid time value for field \"%s\", env var \"%s\": %s, error: %v", op, fieldPath, envKey, envVal, err)
 					}
 					v.Field(i).Set(reflect.ValueOf(timeVal))
 				} else {
-					// Try UnmarshalText and UnmarshalJSON as fallback for struct types
-					if v.Field(i).CanAddr() {
-						if checkTextUnmarshaler(field.Type) {
-							unmarshaler := v.Field(i).Addr().Interface().(encoding.TextUnmarshaler)
-							if err := unmarshaler.UnmarshalText([]byte(envVal)); err == nil {
-								break // Successfully unmarshaled, exit switch
-							}
-						}
-						if checkJSONUnmarshaler(field.Type) {
-							unmarshaler := v.Field(i).Addr().Interface().(json.Unmarshaler)
-							if err := unmarshaler.UnmarshalJSON([]byte(envVal)); err == nil {
-								break // Successfully unmarshaled, exit switch
-							}
-						}
+					// Surface the unmarshaler's error, if it was attempted, rather than a generic one
+					if unmarshalErr != nil {
+						return fmt.Errorf("%s: failed to unmarshal value for field %s: %w", op, fieldPath, unmarshalErr)
 					}
-					return fmt.Errorf("%s: unsupported struct type for field %s", op, field.Name)
+					return fmt.Errorf("%s: unsupported struct type for field %s", op, fieldPath)
 				}
-			default:
-				// Try UnmarshalText and UnmarshalJSON as fallback before returning error
-				if v.Field(i).CanAddr() {
-					if checkTextUnmarshaler(field.Type) {
-						unmarshaler := v.Field(i).Addr().Interface().(encoding.TextUnmarshaler)
-						if err := unmarshaler.UnmarshalText([]byte(envVal)); err == nil {
-							break // Successfully unmarshaled, exit switch
-						}
-					}
-					if checkJSONUnmarshaler(field.Type) {
-						unmarshaler := v.Field(i).Addr().Interface().(json.Unmarshaler)
-						if err := unmarshaler.UnmarshalJSON([]byte(envVal)); err == nil {
-							break // Successfully unmarshaled, exit switch
-						}
+			case reflect.Ptr:
+				if checkRegexp(field.Type) {
+					re, err := regexp.Compile(envVal)
+					if err != nil {
+						return fmt.Errorf("%s: invalid regular expression for field %s: %v", op, fieldPath, err)
 					}
+					v.Field(i).Set(reflect.ValueOf(re))
+					break
+				}
+				return fmt.Errorf("%s: unsupported type for field %s", op, fieldPath)
+			default:
+				// Surface the unmarshaler's error, if it was attempted, rather than a generic one
+				if unmarshalErr != nil {
+					return fmt.Errorf("%s: failed to unmarshal value for field %s: %w", op, fieldPath, unmarshalErr)
+				}
+				return fmt.Errorf("%s: unsupported type for field %s", op, fieldPath)
+			}
+
+			if hasLength {
+				if err := fieldLength.check(v.Field(i)); err != nil {
+					return fmt.Errorf("%s: field %s: %v", op, fieldPath, err)
+				}
+			}
+			if hasBounds {
+				if err := fieldBounds.check(v.Field(i)); err != nil {
+					return fmt.Errorf("%s: field %s: %v", op, fieldPath, err)
 				}
-				return fmt.Errorf("%s: unsupported type for field %s", op, field.Name)
 			}
+			if opts.RejectNonFinite {
+				if err := checkFinite(v.Field(i)); err != nil {
+					return fmt.Errorf("%s: field %s: %v", op, fieldPath, err)
+				}
+			}
+			// The json option only validates, the field keeps the raw string
+			if validJSON && !json.Valid([]byte(v.Field(i).String())) {
+				return fmt.Errorf("%s: field %s: value is not valid JSON", op, fieldPath)
+			}
+		}
+
+		// Unlike required, nonempty also rejects unset fields and values that are only whitespace
+		if nonEmpty {
+			if err := checkNonEmpty(v.Field(i)); err != nil {
+				return fmt.Errorf("%s: field %s: %v", op, fieldPath, err)
+			}
+		}
+	}
+
+	if err := renderTemplates(val, templates, opts); err != nil {
+		return err
+	}
+
+	// Cross-field constraints are checked on the final values, defaults and templates included
+	if err := checkRequiredGroups(v, groups, opts.path); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+	if err := checkExclusiveGroups(v, exclusives, opts.path); err != nil {
+		return fmt.Errorf("%s: %v", op, err)
+	}
+
+	if opts.OnField != nil {
+		for _, f := range setFields {
+			opts.OnField(f.path, f.key, v.Field(f.index), f.fromDefault)
+		}
+	}
+
+	// Validate first, so AfterParse only ever sees a valid struct
+	if validatable, ok := cfg.(Validatable); ok {
+		if err := validatable.Validate(); err != nil {
+			return fmt.Errorf("%s: validation of %s failed: %w", op, t, err)
+		}
+	}
+	if afterParser, ok := cfg.(AfterParser); ok {
+		if err := afterParser.AfterParse(); err != nil {
+			return fmt.Errorf("%s: AfterParse of %s failed: %w", op, t, err)
+		}
+	}
+	return nil
+}
+
+// checkDecoderType returns an error unless decoderType is a func(string) (T, error) whose T is an
+// interface, such as any, or assignable to fieldType.
+func checkDecoderType(decoderType, fieldType reflect.Type) error {
+	errorType := reflect.TypeOf((*error)(nil)).Elem()
+	if decoderType.NumIn() != 1 || decoderType.In(0).Kind() != reflect.String ||
+		decoderType.NumOut() != 2 || decoderType.Out(1) != errorType {
+		return errors.New("must have signature func(string) (any, error)")
+	}
+	if out := decoderType.Out(0); out.Kind() != reflect.Interface && !out.AssignableTo(fieldType) {
+		return fmt.Errorf("returns %s, which is not assignable to %s", out, fieldType)
+	}
+	return nil
+}
+
+// typeName returns the name of the value's type, or "nil" for an invalid value.
+func typeName(v reflect.Value) string {
+	if !v.IsValid() {
+		return "nil"
+	}
+	return v.Type().String()
+}
+
+// setField records a field that was set while parsing a struct, to be reported to OnField.
+type setField struct {
+	index       int
+	path, key   string
+	fromDefault bool
+}
+
+// zeroAsUnsetSkipped are the options left out when checking whether a value of a zeroasunset field
+// parses to the zero value. Only the conversion matters there: constraints apply to the final value,
+// and secrets are resolved once.
+var zeroAsUnsetSkipped = []string{"zeroasunset", "secret", "min=", "max=", "oneof=", "group=", "exclusive="}
+
+// parseSingleField parses value into a new value of field's type, applying the options of the field's
+// env tag except those that were already handled for the raw value (default=, required and template)
+// and those starting with one of skip. The value is read under key through a single-field struct, so
+// every type ParseEnv supports is supported.
+func parseSingleField(field reflect.StructField, key, value string, opts ParseEnvOptions, skip ...string) (reflect.Value, error) {
+	// reflect.StructOf can't build a struct around an unexported field
+	if !field.IsExported() {
+		return reflect.Value{}, fmt.Errorf("field %s is not exported", field.Name)
+	}
+
+	parts := splitTag(field.Tag.Get("env"))
+	tagParts := []string{key}
+	for _, opt := range parts[1:] {
+		skipped := slices.ContainsFunc(skip, func(prefix string) bool { return strings.HasPrefix(opt, prefix) })
+		if opt != "template" && opt != "required" && !strings.HasPrefix(opt, "default=") && !skipped {
+			tagParts = append(tagParts, opt)
+		}
+	}
+	single := reflect.New(reflect.StructOf([]reflect.StructField{{
+		Name: field.Name,
+		Type: field.Type,
+		Tag:  reflect.StructTag(fmt.Sprintf("env:%q", strings.Join(tagParts, ","))),
+	}}))
+
+	// The value and the other lookups are already unquoted, and the field already passed the filter
+	singleOpts := opts
+	singleOpts.TrimQuotes = false
+	singleOpts.OnField = nil
+	singleOpts.FieldFilter = nil
+	singleOpts.Lookup = func(k string) (string, bool) {
+		if k == key {
+			return value, true
+		}
+		return opts.lookup(k)
+	}
+	if err := ParseEnvWithOptions(single.Interface(), singleOpts); err != nil {
+		return reflect.Value{}, err
+	}
+	return single.Elem().Field(0), nil
+}
+
+// mapElements applies fn to envVal or, when elems holds the already split values of an indexed or
+// merged slice, to each of them in place, and returns them joined by delim.
+func mapElements(envVal string, elems []string, delim string, fn func(string) (string, error)) (string, error) {
+	if elems == nil {
+		return fn(envVal)
+	}
+	for idx, elem := range elems {
+		mapped, err := fn(elem)
+		if err != nil {
+			return "", err
+		}
+		elems[idx] = mapped
+	}
+	return strings.Join(elems, delim), nil
+}
+
+// lookupIndexed returns the values of key_0, key_1, ... up to the first index that isn't set.
+func lookupIndexed(key string, opts ParseEnvOptions) []string {
+	var vals []string
+	for idx := 0; ; idx++ {
+		val, ok := opts.lookup(fmt.Sprintf("%s_%d", key, idx))
+		if !ok {
+			return vals
+		}
+		vals = append(vals, val)
+	}
+}
+
+// lookupMerged splits the KEY variable into a list and applies the KEY_<i> overrides. An index within
+// the list replaces that element, and the indices following the list extend it up to the first missing
+// one. An override past that gap is an error rather than being dropped; it can only be detected when
+// the variables can be listed. It reports whether KEY or any override is set.
+func lookupMerged(key string, splitter listSplitter, opts ParseEnvOptions) ([]string, bool, error) {
+	base, present := opts.lookup(key)
+
+	var vals []string
+	if base != "" {
+		vals = splitter.split(base)
+	}
+
+	for idx := 0; ; idx++ {
+		override, ok := opts.lookup(fmt.Sprintf("%s_%d", key, idx))
+		if idx >= len(vals) {
+			if !ok {
+				break
+			}
+			vals = append(vals, override)
+		} else if ok {
+			vals[idx] = override
+		}
+		present = present || ok
+	}
+
+	// A custom Lookup without ListKeys can't be enumerated, so gaps go unnoticed there
+	keys, err := opts.envKeys()
+	if err != nil {
+		return vals, present, nil
+	}
+	for _, k := range keys {
+		suffix, ok := strings.CutPrefix(k, key+"_")
+		if !ok {
+			continue
+		}
+		if idx, err := strconv.Atoi(suffix); err == nil && strconv.Itoa(idx) == suffix && idx > len(vals) {
+			return nil, false, fmt.Errorf("override %s follows a gap, %s_%d is not set", k, key, len(vals))
+		}
+	}
+	return vals, present, nil
+}
+
+// isNestedStruct reports whether the field is a struct whose own fields are parsed recursively,
+// as opposed to structs parsed from a single value: parser=kv structs, ranges and registered types.
+func isNestedStruct(field reflect.StructField, tag string) bool {
+	return field.Type.Kind() == reflect.Struct && field.IsExported() && !hasTagOption(tag, "parser=kv") &&
+		!checkTimeRange(field.Type) && !checkDurationRange(field.Type) && !checkDecimal(field.Type) && !isRegisteredType(field.Type)
+}
+
+// isIndexedStructSlice reports whether the type is a slice of structs that are parsed field by field,
+// which the indexed option reads from prefixed variables per element.
+func isIndexedStructSlice(fieldType reflect.Type) bool {
+	return fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Struct && !isValueStruct(fieldType.Elem())
+}
+
+// parseIndexedStructs parses the elements of a struct slice from the variables prefixed with
+// KEY_0_, KEY_1_, ... up to the first index none of whose variables are set, and returns their count.
+// The field is left untouched when there are none.
+func parseIndexedStructs(fieldValue reflect.Value, key string, opts ParseEnvOptions) (int, error) {
+	elemType := fieldValue.Type().Elem()
+	elems := reflect.MakeSlice(fieldValue.Type(), 0, 0)
+	for idx := 0; ; idx++ {
+		elemOpts := opts.withPrefix(fmt.Sprintf("%s_%d_", key, idx))
+		elemOpts.path = strings.TrimSuffix(opts.path, ".") + fmt.Sprintf("[%d].", idx)
+		if !hasEnvValues(elemType, elemOpts, nil) {
+			break
+		}
+		elem := reflect.New(elemType)
+		if err := ParseEnvWithOptions(elem.Interface(), elemOpts); err != nil {
+			return 0, err
+		}
+		elems = reflect.Append(elems, elem.Elem())
+	}
+	if elems.Len() > 0 {
+		fieldValue.Set(elems)
+	}
+	return elems.Len(), nil
+}
+
+// structPrefix returns the key prefix a struct declares with a blank field, e.g.
+//
+//	_ struct{} `env:",prefix=APP_"`
+//
+// or "" if it declares none.
+func structPrefix(structType reflect.Type) string {
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		if field.Name != "_" {
+			continue
+		}
+		for _, opt := range strings.Split(field.Tag.Get("env"), ",")[1:] {
+			if strings.HasPrefix(opt, "prefix=") {
+				return strings.TrimPrefix(opt, "prefix=")
+			}
+		}
+	}
+	return ""
+}
+
+// checkStructPointer returns an error unless cfg is a non-nil pointer to a struct.
+func checkStructPointer(cfg any) error {
+	val := reflect.ValueOf(cfg)
+	switch {
+	case !val.IsValid():
+		return errors.New("requires a non-nil pointer to a struct, got nil")
+	case val.Kind() != reflect.Ptr:
+		return fmt.Errorf("requires a non-nil pointer to a struct, got %s", val.Type())
+	case val.IsNil():
+		return fmt.Errorf("requires a non-nil pointer to a struct, got nil %s", val.Type())
+	case val.Elem().Kind() != reflect.Struct:
+		return fmt.Errorf("requires a non-nil pointer to a struct, got %s", val.Type())
+	}
+	return nil
+}
+
+// isIgnoredTag reports whether the env tag is "-" (optionally followed by options),
+// which excludes the field from parsing.
+func isIgnoredTag(tag string) bool {
+	return tag == "-" || strings.HasPrefix(tag, "-,")
+}
+
+// hasTagOption reports whether the env tag contains the given option.
+func hasTagOption(tag, option string) bool {
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// hasEnvValues reports whether any tagged field of the struct type, including fields of
+// nested structs, has its environment variable set.
+func hasEnvValues(structType reflect.Type, opts ParseEnvOptions, visited map[reflect.Type]bool) bool {
+	if visited == nil {
+		visited = make(map[reflect.Type]bool)
+	}
+	if visited[structType] {
+		return false
+	}
+	visited[structType] = true
+	defer delete(visited, structType)
+
+	if prefix := structPrefix(structType); prefix != "" {
+		opts = opts.withPrefix(prefix)
+	}
+
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		if isIgnoredTag(field.Tag.Get("env")) {
+			continue
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct && hasEnvValues(fieldType, opts, visited) {
+			return true
+		}
+
+		envKey, _, _ := strings.Cut(field.Tag.Get("env"), ",")
+		if envKey == "" && opts.KeyFromField != nil && field.IsExported() && (fieldType.Kind() != reflect.Struct || isValueStruct(fieldType)) {
+			envKey = opts.KeyFromField(field.Name)
+		}
+		if envKey != "" && envKey != "_" {
+			if val, _ := opts.lookup(envKey); val != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parserErrors collects the errors of every parser attempted for a parser= chain.
+type parserErrors []error
+
+func (e parserErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e parserErrors) Unwrap() []error {
+	return e
+}
+
+// parserContext carries the tag options and parse options some parsers depend on.
+type parserContext struct {
+	decimal    string // decimal separator used by parser=number
+	strict     bool   // reject unknown keys in parser=kv and reversed ranges in parser=timerange
+	trueToken  string // custom true token used by parser=tribool
+	falseToken string // custom false token used by parser=tribool
+	splitter   listSplitter
+	elems      []string // values of indexed and merged slices, which are already split
+	opts       ParseEnvOptions
+}
+
+// elementParsers are the parsers that parse slices element by element.
+var elementParsers = []string{"bytesize", "hexnum", "be", "le", "percent", "duration", "tribool", "durationrange", "timerange", "decimal"}
+
+// split returns the elements of the slice value envVal.
+func (pc parserContext) split(envVal string) []string {
+	if pc.elems != nil {
+		return pc.elems
+	}
+	return pc.splitter.split(envVal)
+}
+
+// applyParser parses envVal into the addressable fieldValue using the parser named in the parser= tag option.
+func applyParser(parserType string, fieldValue reflect.Value, envVal string, pc parserContext) error {
+	fieldType := fieldValue.Type()
+
+	// Slices are split like any other slice field, and every element is parsed on its own
+	if fieldType.Kind() == reflect.Slice && slices.Contains(elementParsers, parserType) {
+		return setSliceElements(fieldValue, pc.split(envVal), func(elem reflect.Value, s string) error {
+			return applyParser(parserType, elem, s, pc)
+		})
+	}
+
+	switch {
+	case parserType == "text" && checkTextUnmarshaler(fieldType):
+		unmarshaler := fieldValue.Addr().Interface().(encoding.TextUnmarshaler)
+		if err := unmarshaler.UnmarshalText([]byte(envVal)); err != nil {
+			return fmt.Errorf("failed to unmarshal text: %v", err)
+		}
+	case parserType == "json" && checkJSONUnmarshaler(fieldType):
+		unmarshaler := fieldValue.Addr().Interface().(json.Unmarshaler)
+		if err := unmarshaler.UnmarshalJSON([]byte(envVal)); err != nil {
+			return fmt.Errorf("failed to unmarshal JSON: %v", err)
+		}
+	case parserType == "json" && fieldType.Kind() == reflect.Slice:
+		if err := setJSONSlice(fieldValue, envVal); err != nil {
+			return err
+		}
+	case parserType == "bytesize" && checkIntegerKind(fieldType):
+		if err := setByteSize(fieldValue, envVal); err != nil {
+			return fmt.Errorf("invalid byte size value: %w", err)
+		}
+	case parserType == "hexnum" && checkIntegerKind(fieldType):
+		if err := setHexNum(fieldValue, envVal); err != nil {
+			return fmt.Errorf("invalid hex number value: %v", err)
+		}
+	case (parserType == "be" || parserType == "le") && checkIntegerKind(fieldType):
+		if err := setEndianNum(fieldValue, envVal, parserType); err != nil {
+			return fmt.Errorf("invalid %s hex bytes value: %v", parserType, err)
+		}
+	case parserType == "percent" && checkFloatKind(fieldType):
+		if err := setPercent(fieldValue, envVal); err != nil {
+			return fmt.Errorf("invalid percent value: %v", err)
+		}
+	case parserType == "number" && (checkIntegerKind(fieldType) || checkFloatKind(fieldType)) && fieldType.Kind() != reflect.Slice:
+		if err := setNumber(fieldValue, envVal, pc.decimal); err != nil {
+			return fmt.Errorf("invalid number value: %v", err)
+		}
+	case parserType == "duration" && checkDurationKind(fieldType):
+		if err := setDuration(fieldValue, envVal); err != nil {
+			return fmt.Errorf("invalid duration value: %v", err)
+		}
+	case parserType == "reltime" && checkTime(fieldType):
+		if err := setRelTime(fieldValue, envVal); err != nil {
+			return fmt.Errorf("invalid relative time value: %v", err)
+		}
+	case parserType == "tribool" && checkTriState(fieldType):
+		if err := setTriState(fieldValue, envVal, pc.trueToken, pc.falseToken); err != nil {
+			return fmt.Errorf("invalid tribool value: %v", err)
+		}
+	case parserType == "ints" && checkBytes(fieldType):
+		if err := setByteInts(fieldValue, pc.split(envVal)); err != nil {
+			return fmt.Errorf("invalid bytes value: %v", err)
+		}
+	case (parserType == "hex" || parserType == "base64") && checkBytes(fieldType):
+		if err := setEncodedBytes(fieldValue, envVal, parserType); err != nil {
+			return fmt.Errorf("invalid %s value: %v", parserType, err)
+		}
+	case parserType == "durationrange" && checkDurationRange(fieldType):
+		if err := setDurationRange(fieldValue, envVal); err != nil {
+			return fmt.Errorf("invalid duration range value: %v", err)
+		}
+	case parserType == "decimal" && checkDecimal(fieldType):
+		if err := setDecimal(fieldValue, envVal); err != nil {
+			return fmt.Errorf("invalid decimal value: %v", err)
+		}
+	case parserType == "timerange" && checkTimeRange(fieldType):
+		if err := setTimeRange(fieldValue, envVal, pc.strict); err != nil {
+			return fmt.Errorf("invalid time range value: %v", err)
+		}
+	case parserType == "yaml" && checkJSONContainer(fieldType):
+		if pc.opts.YAMLUnmarshal == nil {
+			return errors.New("parser=yaml requires ParseEnvOptions.YAMLUnmarshal")
+		}
+		if err := pc.opts.YAMLUnmarshal([]byte(envVal), fieldValue.Addr().Interface()); err != nil {
+			return fmt.Errorf("failed to unmarshal YAML: %v", err)
+		}
+	case parserType == "kv" && fieldType.Kind() == reflect.Struct:
+		if err := setKV(fieldValue, envVal, pc.strict, pc.opts); err != nil {
+			return fmt.Errorf("invalid key=value pairs: %v", err)
+		}
+	default:
+		// If parser tag is specified but type doesn't implement the interface, return error
+		return fmt.Errorf("type %s does not implement required unmarshaler interface for parser=%s", fieldType, parserType)
+	}
+	return nil
+}
+
+// setJSONSlice decodes envVal as a JSON array into the slice fieldValue, then validates every element
+// implementing Validatable, by value or by pointer.
+func setJSONSlice(fieldValue reflect.Value, envVal string) error {
+	if err := json.Unmarshal([]byte(envVal), fieldValue.Addr().Interface()); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON: %v", err)
+	}
+	for i := range fieldValue.Len() {
+		validatable, ok := fieldValue.Index(i).Addr().Interface().(Validatable)
+		if !ok {
+			continue
+		}
+		if err := validatable.Validate(); err != nil {
+			return fmt.Errorf("validation of element %d failed: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// setHexNum parses envVal as prefix-less base 16 digits, e.g. "DEADBEEF", and stores the result in
+// the integer fieldValue.
+func setHexNum(fieldValue reflect.Value, envVal string) error {
+	switch fieldValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		vl, err := strconv.ParseInt(envVal, 16, fieldValue.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fieldValue.SetInt(vl)
+	default:
+		vl, err := strconv.ParseUint(envVal, 16, fieldValue.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fieldValue.SetUint(vl)
+	}
+	return nil
+}
+
+// setPercent parses envVal as a percentage, e.g. "25%" becomes 0.25, and stores the result in the
+// float fieldValue. Values without "%" are parsed as plain floats.
+func setPercent(fieldValue reflect.Value, envVal string) error {
+	num, isPercent := strings.CutSuffix(strings.TrimSpace(envVal), "%")
+	if strings.Contains(num, "%") {
+		return fmt.Errorf("malformed percentage %q", envVal)
+	}
+	vl, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+	if err != nil {
+		return err
+	}
+	if isPercent {
+		vl /= 100
+	}
+	if fieldValue.OverflowFloat(vl) {
+		return fmt.Errorf("value %q overflows %s", envVal, fieldValue.Type())
+	}
+	fieldValue.SetFloat(vl)
+	return nil
+}
+
+// splitEscaped splits s by sep, treating a backslash-escaped separator as part of the token.
+// The escape sequences "\\" and "\<sep>" are unescaped in the resulting tokens, any other
+// backslash is kept literally.
+func splitEscaped(s string, sep byte) []string {
+	var tokens []string
+	var token strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && (s[i+1] == sep || s[i+1] == '\\'):
+			i++
+			token.WriteByte(s[i])
+		case s[i] == sep:
+			tokens = append(tokens, token.String())
+			token.Reset()
+		default:
+			token.WriteByte(s[i])
+		}
+	}
+	return append(tokens, token.String())
+}
+
+// setPrimitive parses a single string, bool, integer, float or duration value, such as an element
+// of a nested slice or a map key or value.
+func setPrimitive(fieldValue reflect.Value, s string) error {
+	switch fieldValue.Kind() {
+	case reflect.String:
+		fieldValue.SetString(s)
+	case reflect.Bool:
+		vl, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		fieldValue.SetBool(vl)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if checkTimeDuration(fieldValue.Type()) {
+			dur, err := parseDuration(s)
+			if err != nil {
+				return err
+			}
+			fieldValue.SetInt(int64(dur))
+			break
+		}
+		vl, err := strconv.ParseInt(trimDigitSeparators(s), 10, fieldValue.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fieldValue.SetInt(vl)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		vl, err := strconv.ParseUint(trimDigitSeparators(s), 10, fieldValue.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fieldValue.SetUint(vl)
+	case reflect.Float32, reflect.Float64:
+		vl, err := strconv.ParseFloat(trimDigitSeparators(s), fieldValue.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fieldValue.SetFloat(vl)
+	default:
+		return fmt.Errorf("unsupported type %s", fieldValue.Type())
+	}
+	return nil
+}
+
+// checkSet reports whether the type is a set, a map with empty struct values such as map[string]struct{}.
+func checkSet(fieldType reflect.Type) bool {
+	return fieldType.Kind() == reflect.Map && fieldType.Elem().Kind() == reflect.Struct && fieldType.Elem().NumField() == 0
+}
+
+// checkPrimitive reports whether setPrimitive supports the type.
+func checkPrimitive(fieldType reflect.Type) bool {
+	switch fieldType.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// separatorEscapes replaces the escape sequences accepted in separator options, e.g. delim=\n.
+var separatorEscapes = strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t")
+
+// unescapeSeparator turns the escape sequences of a separator option into the characters they stand for.
+func unescapeSeparator(s string) string {
+	return separatorEscapes.Replace(s)
+}
+
+// listSplitter splits slice values by the delimiter, honoring escapes with the escaped option,
+// or by the regular expression of the regexsplit option.
+type listSplitter struct {
+	delim   string
+	escaped bool
+	re      *regexp.Regexp
+}
+
+func (l listSplitter) split(s string) []string {
+	switch {
+	case l.re != nil:
+		// Separators at either end would yield empty elements, so the value and its elements are trimmed
+		vals := l.re.Split(strings.TrimSpace(s), -1)
+		for i, val := range vals {
+			vals[i] = strings.TrimSpace(val)
+		}
+		return vals
+	case l.escaped:
+		return splitEscaped(s, l.delim[0])
+	}
+	return splitList(s, l.delim)
+}
+
+// splitRegexps caches the patterns of regexsplit options, which are compiled once per pattern.
+var splitRegexps sync.Map // map[string]*regexp.Regexp
+
+// compileSplitRegexp returns the compiled pattern of a regexsplit option.
+func compileSplitRegexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := splitRegexps.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	splitRegexps.Store(pattern, re)
+	return re, nil
+}
+
+// splitList splits s by delim. Newline separated lists may use CRLF line endings and end with a
+// line break, so "\r" is trimmed from their elements and trailing line breaks are dropped.
+func splitList(s, delim string) []string {
+	if delim != "\n" {
+		return strings.Split(s, delim)
+	}
+	vals := strings.Split(strings.TrimRight(s, "\r\n"), delim)
+	for i, val := range vals {
+		vals[i] = strings.TrimSuffix(val, "\r")
+	}
+	return vals
+}
+
+// commaOptions are the tag options that accept a comma as their value.
+var commaOptions = []string{"delim=", "innerdelim=", "mapsep=", "kvsep=", "decimal="}
+
+// splitTag splits an env tag into its key and options. Since options are separated by commas,
+// "delim=," is written as "delim=,," and shows up as "delim=" followed by an empty part,
+// which is merged back into a single option.
+func splitTag(tag string) []string {
+	parts := strings.Split(tag, ",")
+	merged := make([]string, 0, len(parts))
+	for i := 0; i < len(parts); i++ {
+		if slices.Contains(commaOptions, parts[i]) && i+1 < len(parts) && parts[i+1] == "" {
+			merged = append(merged, parts[i]+",")
+			i++
+			continue
+		}
+		merged = append(merged, parts[i])
+	}
+	return merged
+}
+
+// checkStringKind reports whether the type is a string kind or a slice of string kinds.
+func checkStringKind(fieldType reflect.Type) bool {
+	if fieldType.Kind() == reflect.Slice {
+		fieldType = fieldType.Elem()
+	}
+	return fieldType.Kind() == reflect.String
+}
+
+// checkNonEmpty returns an error if the string, or any element of a string slice, is empty or
+// only whitespace. An empty slice is an error too.
+func checkNonEmpty(fieldValue reflect.Value) error {
+	if fieldValue.Kind() != reflect.Slice {
+		if strings.TrimSpace(fieldValue.String()) == "" {
+			return errors.New("value must not be empty or whitespace")
+		}
+		return nil
+	}
+	if fieldValue.Len() == 0 {
+		return errors.New("value must have at least one element")
+	}
+	for i := range fieldValue.Len() {
+		if strings.TrimSpace(fieldValue.Index(i).String()) == "" {
+			return fmt.Errorf("element %d must not be empty or whitespace", i)
 		}
 	}
 	return nil
 }
 
-func checkSliceElementsSetter(sliceType reflect.Type) bool {
+// appendSlices returns a new slice holding the elements of existing followed by those of parsed,
+// without writing to the backing array of existing.
+func appendSlices(existing, parsed reflect.Value) reflect.Value {
+	merged := reflect.MakeSlice(existing.Type(), 0, existing.Len()+parsed.Len())
+	return reflect.AppendSlice(reflect.AppendSlice(merged, existing), parsed)
+}
+
+// uniqueSlice returns a copy of the slice with duplicate elements removed, keeping the first occurrence
+// of each. In strict mode a duplicate is reported as an error instead.
+func uniqueSlice(slice reflect.Value, strict bool) (reflect.Value, error) {
+	seen := make(map[any]bool, slice.Len())
+	deduped := reflect.MakeSlice(slice.Type(), 0, slice.Len())
+	for i := range slice.Len() {
+		elem := slice.Index(i)
+		if seen[elem.Interface()] {
+			if strict {
+				return reflect.Value{}, fmt.Errorf("duplicate element %v at index %d", elem.Interface(), i)
+			}
+			continue
+		}
+		seen[elem.Interface()] = true
+		deduped = reflect.Append(deduped, elem)
+	}
+	return deduped, nil
+}
+
+// setSliceElements stores every one of vals into a new element of the slice fieldValue using the
+// given set function.
+func setSliceElements(fieldValue reflect.Value, vals []string, set func(reflect.Value, string) error) error {
+	refSlice := reflect.MakeSlice(fieldValue.Type(), len(vals), len(vals))
+	for i, vl := range vals {
+		if err := set(refSlice.Index(i), vl); err != nil {
+			return err
+		}
+	}
+	fieldValue.Set(refSlice)
+	return nil
+}
+
+// checkIntegerKind reports whether the type is an integer kind or a slice of integer kinds.
+func checkIntegerKind(fieldType reflect.Type) bool {
+	if fieldType.Kind() == reflect.Slice {
+		fieldType = fieldType.Elem()
+	}
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
+
+// checkFloatKind reports whether the type is a float kind or a slice of float kinds.
+func checkFloatKind(fieldType reflect.Type) bool {
+	if fieldType.Kind() == reflect.Slice {
+		fieldType = fieldType.Elem()
+	}
+	return fieldType.Kind() == reflect.Float32 || fieldType.Kind() == reflect.Float64
+}
+
+func checkSliceElementsSetter(sliceType reflect.Type, name string) bool {
 	if sliceType.Kind() != reflect.Slice {
 		return false
 	}
 
-	// Get the element type of the slice
-	elemType := sliceType.Elem()
+	// Check if a pointer to the element type has the setter method
+	_, ok := setterMethodType(reflect.PointerTo(sliceType.Elem()), name)
+	return ok
+}
+
+// setterMethod returns the named setter method of the pointer ptr, if its type has one.
+func setterMethod(ptr reflect.Value, name string) (reflect.Value, bool) {
+	if _, ok := setterMethodType(ptr.Type(), name); !ok {
+		return reflect.Value{}, false
+	}
+	return ptr.MethodByName(name), true
+}
+
+// setterMethodType returns the named method of the type if it has a setter signature: a single
+// string or any argument and a single error result, like Setter's Scan.
+func setterMethodType(ptrType reflect.Type, name string) (reflect.Method, bool) {
+	method, ok := ptrType.MethodByName(name)
+	if !ok {
+		return reflect.Method{}, false
+	}
+	// The receiver is the first argument of the method type
+	mt := method.Type
+	if mt.NumIn() != 2 || mt.NumOut() != 1 || mt.Out(0) != reflect.TypeOf((*error)(nil)).Elem() {
+		return reflect.Method{}, false
+	}
+	if arg := mt.In(1); arg.Kind() != reflect.String && !(arg.Kind() == reflect.Interface && arg.NumMethod() == 0) {
+		return reflect.Method{}, false
+	}
+	return method, true
+}
+
+// looksLikeJSON reports whether the value starts like a JSON object or array.
+func looksLikeJSON(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[")
+}
+
+// checkStringParser reports whether a pointer to the type implements StringParser.
+func checkStringParser(fieldType reflect.Type) bool {
+	return reflect.PointerTo(fieldType).Implements(reflect.TypeOf((*StringParser)(nil)).Elem())
+}
 
-	// Get the Setter interface type
-	setterType := reflect.TypeOf((*Setter)(nil)).Elem()
+// parseBool parses a boolean using the custom true/false tokens from the tag, if any.
+// Tokens are matched case-insensitively. When both tokens are configured any other value is an error,
+// when only one is configured other values fall back to strconv.ParseBool.
+func parseBool(s, trueToken, falseToken string) (bool, error) {
+	if trueToken != "" && strings.EqualFold(s, trueToken) {
+		return true, nil
+	}
+	if falseToken != "" && strings.EqualFold(s, falseToken) {
+		return false, nil
+	}
+	if trueToken != "" && falseToken != "" {
+		return false, fmt.Errorf("value %q matches neither true=%s nor false=%s", s, trueToken, falseToken)
+	}
+	return strconv.ParseBool(s)
+}
 
-	// Check if the element type implements Setter
-	return reflect.PointerTo(elemType).Implements(setterType)
+// intLimits returns the range of values representable by a signed integer of the given bit size.
+func intLimits(bits int) (int64, int64) {
+	maxVal := int64(1)<<(bits-1) - 1
+	return -maxVal - 1, maxVal
+}
+
+// uintLimit returns the maximum value representable by an unsigned integer of the given bit size.
+func uintLimit(bits int) uint64 {
+	return math.MaxUint64 >> (64 - bits)
+}
+
+// checkAtomicStore reports whether the struct type has a Store method on its pointer taking a single
+// primitive value, as the typed atomics of sync/atomic do, and returns the type of that value.
+func checkAtomicStore(fieldType reflect.Type) (reflect.Type, bool) {
+	if fieldType.Kind() != reflect.Struct {
+		return nil, false
+	}
+	method, ok := reflect.PointerTo(fieldType).MethodByName("Store")
+	if !ok || method.Type.NumIn() != 2 || method.Type.NumOut() != 0 {
+		return nil, false
+	}
+	storeType := method.Type.In(1)
+	switch storeType.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return storeType, true
+	}
+	return nil, false
+}
+
+// isValueStruct reports whether a struct type is parsed from a single value, like time.Time, rather
+// than being a nested config struct.
+func isValueStruct(fieldType reflect.Type) bool {
+	_, isAtomic := checkAtomicStore(fieldType)
+	return checkTime(fieldType) || isAtomic || isRegisteredType(fieldType) || checkTextUnmarshaler(fieldType) || checkJSONUnmarshaler(fieldType)
+}
+
+// checkDurationKind reports whether the type is an int64 kind or a slice of them, which covers named
+// duration types like "type Timeout time.Duration". Reflection can't tell those apart from other
+// int64 types, so they are only parsed as durations with parser=duration.
+func checkDurationKind(fieldType reflect.Type) bool {
+	if fieldType.Kind() == reflect.Slice {
+		fieldType = fieldType.Elem()
+	}
+	return fieldType.Kind() == reflect.Int64
 }
 
 func checkTimeDuration(fieldType reflect.Type) bool {
 	return fieldType == reflect.TypeOf(time.Duration(0))
 }
 
+func checkRegexp(fieldType reflect.Type) bool {
+	return fieldType == reflect.TypeOf((*regexp.Regexp)(nil))
+}
+
+func checkHardwareAddr(fieldType reflect.Type) bool {
+	return fieldType == reflect.TypeOf(net.HardwareAddr{})
+}
+
+func checkURLValues(fieldType reflect.Type) bool {
+	return fieldType == reflect.TypeOf(url.Values{})
+}
+
 func checkTime(fieldType reflect.Type) bool {
 	return fieldType == reflect.TypeOf(time.Time{})
 }
@@ -451,57 +2407,92 @@ func checkJSONUnmarshaler(fieldType reflect.Type) bool {
 	return reflect.PointerTo(fieldType).Implements(jsonUnmarshalerType)
 }
 
+func checkTextMarshaler(fieldType reflect.Type) bool {
+	textMarshalerType := reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	return reflect.PointerTo(fieldType).Implements(textMarshalerType)
+}
+
+func checkJSONMarshaler(fieldType reflect.Type) bool {
+	jsonMarshalerType := reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	return reflect.PointerTo(fieldType).Implements(jsonMarshalerType)
+}
+
 // tryUnmarshalMethods attempts to unmarshal using UnmarshalText or UnmarshalJSON
-// before falling back to standard parsing. Returns true if successfully unmarshaled.
-func tryUnmarshalMethods(fieldValue reflect.Value, fieldType reflect.Type, envVal string) bool {
+// before falling back to standard parsing. Returns true if successfully unmarshaled,
+// otherwise the errors returned by the attempted unmarshalers, if any.
+func tryUnmarshalMethods(fieldValue reflect.Value, fieldType reflect.Type, envVal string) (bool, error) {
 	if envVal == "" || !fieldValue.CanAddr() {
-		return false
+		return false, nil
 	}
 
+	var errs parserErrors
+
 	// Try UnmarshalText first
 	if checkTextUnmarshaler(fieldType) {
 		unmarshaler := fieldValue.Addr().Interface().(encoding.TextUnmarshaler)
-		if err := unmarshaler.UnmarshalText([]byte(envVal)); err == nil {
-			return true
+		err := unmarshaler.UnmarshalText([]byte(envVal))
+		if err == nil {
+			return true, nil
 		}
+		errs = append(errs, err)
 	}
 
 	// Try UnmarshalJSON second
 	if checkJSONUnmarshaler(fieldType) {
 		unmarshaler := fieldValue.Addr().Interface().(json.Unmarshaler)
-		if err := unmarshaler.UnmarshalJSON([]byte(envVal)); err == nil {
-			return true
+		err := unmarshaler.UnmarshalJSON([]byte(envVal))
+		if err == nil {
+			return true, nil
 		}
+		errs = append(errs, err)
 	}
 
-	return false
+	switch len(errs) {
+	case 0:
+		return false, nil
+	case 1:
+		return false, errs[0]
+	default:
+		return false, errs
+	}
 }
 
 // tryUnmarshalSliceElement attempts to unmarshal a slice element using UnmarshalText or UnmarshalJSON
 // before falling back to standard parsing. Returns the parsed value and true if successful.
 func tryUnmarshalSliceElement(elemType reflect.Type, val string) (reflect.Value, bool) {
+	elem, err := unmarshalSliceElement(elemType, val)
+	return elem, err == nil
+}
+
+// unmarshalSliceElement unmarshals a slice element using UnmarshalText, then UnmarshalJSON. If both
+// fail, the error of the last attempt is returned.
+func unmarshalSliceElement(elemType reflect.Type, val string) (reflect.Value, error) {
 	if val == "" {
-		return reflect.Value{}, false
+		return reflect.Value{}, errors.New("empty value")
 	}
 
 	// Create a new element of the slice type
 	elem := reflect.New(elemType)
 
 	// Try UnmarshalText first
+	var err error
 	if checkTextUnmarshaler(elemType) {
 		unmarshaler := elem.Interface().(encoding.TextUnmarshaler)
-		if err := unmarshaler.UnmarshalText([]byte(val)); err == nil {
-			return elem.Elem(), true
+		if err = unmarshaler.UnmarshalText([]byte(val)); err == nil {
+			return elem.Elem(), nil
 		}
 	}
 
 	// Try UnmarshalJSON second
 	if checkJSONUnmarshaler(elemType) {
 		unmarshaler := elem.Interface().(json.Unmarshaler)
-		if err := unmarshaler.UnmarshalJSON([]byte(val)); err == nil {
-			return elem.Elem(), true
+		if err = unmarshaler.UnmarshalJSON([]byte(val)); err == nil {
+			return elem.Elem(), nil
 		}
 	}
 
-	return reflect.Value{}, false
+	if err == nil {
+		err = fmt.Errorf("type %s implements neither UnmarshalText nor UnmarshalJSON", elemType)
+	}
+	return reflect.Value{}, err
 }
@@ -1,11 +1,20 @@
 package lazyconf
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/url"
 	"os"
 	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -1048,3 +1057,3643 @@ func TestParseEnvTypeAliasUnmarshalJSON(t *testing.T) {
 		t.Errorf("JSONAlias should use UnmarshalJSON with custom prefix. Expected custom_key=value, got %+v", cfg.JSONField)
 	}
 }
+
+// TestParseEnvNestedPointerStruct tests that a nested pointer struct is allocated and populated.
+func TestParseEnvNestedPointerStruct(t *testing.T) {
+	type NestedConfig struct {
+		Host string `env:"NESTED_PTR_HOST"`
+		Port int    `env:"NESTED_PTR_PORT,default=5432"`
+	}
+
+	type ParentConfig struct {
+		Nested *NestedConfig
+	}
+
+	_ = os.Setenv("NESTED_PTR_HOST", "db.local")
+
+	cfg := &ParentConfig{}
+	err := ParseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.Nested == nil {
+		t.Fatal("expected Nested to be allocated, got nil")
+	}
+	if cfg.Nested.Host != "db.local" {
+		t.Errorf("expected Nested.Host to be 'db.local', got '%s'", cfg.Nested.Host)
+	}
+	if cfg.Nested.Port != 5432 {
+		t.Errorf("expected Nested.Port to be 5432, got %d", cfg.Nested.Port)
+	}
+}
+
+// TestParseEnvNestedPointerStructAbsent tests that a nested pointer struct stays nil when none of its variables are set.
+func TestParseEnvNestedPointerStructAbsent(t *testing.T) {
+	type NestedConfig struct {
+		Host string `env:"NESTED_ABSENT_HOST,required"`
+		Port int    `env:"NESTED_ABSENT_PORT,default=5432"`
+	}
+
+	type ParentConfig struct {
+		Nested *NestedConfig
+	}
+
+	_ = os.Unsetenv("NESTED_ABSENT_HOST")
+	_ = os.Unsetenv("NESTED_ABSENT_PORT")
+
+	cfg := &ParentConfig{}
+	err := ParseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Nested != nil {
+		t.Errorf("expected Nested to stay nil, got %+v", cfg.Nested)
+	}
+
+	// With AllocateNilStructs the struct is allocated and its required fields are enforced
+	err = ParseEnvWithOptions(cfg, ParseEnvOptions{AllocateNilStructs: true})
+	if err == nil {
+		t.Fatal("expected an error for the required nested field when the struct is allocated, but got none")
+	}
+}
+
+// TestParseEnvNestedPointerStructSharedType tests that a struct type used twice under different prefixes
+// is checked for set variables under each of them.
+func TestParseEnvNestedPointerStructSharedType(t *testing.T) {
+	type Endpoint struct {
+		Host string `env:"HOST"`
+	}
+	type Primary struct {
+		_        struct{} `env:",prefix=SHARED_PRIMARY_"`
+		Endpoint Endpoint
+	}
+	type Replica struct {
+		_        struct{} `env:",prefix=SHARED_REPLICA_"`
+		Endpoint Endpoint
+	}
+	type Cluster struct {
+		Primary Primary
+		Replica Replica
+	}
+	type ParentConfig struct {
+		Cluster *Cluster
+	}
+
+	cfg := &ParentConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{"SHARED_REPLICA_HOST": "db1"})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Cluster == nil || cfg.Cluster.Replica.Endpoint.Host != "db1" {
+		t.Errorf("expected Cluster to be allocated with the replica host, got %+v", cfg.Cluster)
+	}
+}
+
+// TestParseEnvNestedPointerStructRequired tests that a required nested pointer struct needs at least one variable set.
+func TestParseEnvNestedPointerStructRequired(t *testing.T) {
+	type NestedConfig struct {
+		Host string `env:"NESTED_REQUIRED_HOST"`
+		Port int    `env:"NESTED_REQUIRED_PORT,default=5432"`
+	}
+
+	type ParentConfig struct {
+		Nested *NestedConfig `env:",required"`
+	}
+
+	_ = os.Unsetenv("NESTED_REQUIRED_HOST")
+	_ = os.Unsetenv("NESTED_REQUIRED_PORT")
+
+	cfg := &ParentConfig{}
+	err := ParseEnv(cfg)
+	if err == nil {
+		t.Fatal("expected an error when required nested pointer struct has no variables set, but got none")
+	}
+
+	_ = os.Setenv("NESTED_REQUIRED_HOST", "db.local")
+
+	cfg = &ParentConfig{}
+	err = ParseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Nested == nil || cfg.Nested.Host != "db.local" || cfg.Nested.Port != 5432 {
+		t.Errorf("expected Nested to be allocated with Host 'db.local' and Port 5432, got %+v", cfg.Nested)
+	}
+}
+
+// TestParseEnvParserChain tests parser="json|text" falling back to the next parser in the chain.
+func TestParseEnvParserChain(t *testing.T) {
+	type ChainConfig struct {
+		BothField BothUnmarshalType `env:"CHAIN_BOTH,parser=json|text"`
+		TextField TextUnmarshalType `env:"CHAIN_TEXT,parser=json|text"`
+	}
+
+	_ = os.Setenv("CHAIN_BOTH", "plain")
+	_ = os.Setenv("CHAIN_TEXT", "only-text")
+
+	cfg := &ChainConfig{}
+	err := ParseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.BothField.TextValue != "text:plain" {
+		t.Errorf("expected BothField.TextValue to be 'text:plain', got '%s'", cfg.BothField.TextValue)
+	}
+	if cfg.BothField.JSONData != nil {
+		t.Errorf("expected BothField.JSONData to be nil, got '%v'", cfg.BothField.JSONData)
+	}
+	if cfg.TextField.Value != "text:only-text" {
+		t.Errorf("expected TextField.Value to be 'text:only-text', got '%s'", cfg.TextField.Value)
+	}
+
+	// JSON comes first in the chain, so a valid JSON value is handled by UnmarshalJSON
+	_ = os.Setenv("CHAIN_BOTH", `{"key":"value"}`)
+
+	cfg = &ChainConfig{}
+	err = ParseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.BothField.JSONData["key"] != "value" {
+		t.Errorf("expected BothField.JSONData[\"key\"] to be 'value', got '%v'", cfg.BothField.JSONData["key"])
+	}
+	if cfg.BothField.TextValue != "" {
+		t.Errorf("expected BothField.TextValue to be empty, got '%s'", cfg.BothField.TextValue)
+	}
+}
+
+// TestParseEnvParserChainError tests that a parser chain errors only when every parser fails.
+func TestParseEnvParserChainError(t *testing.T) {
+	type ChainConfig struct {
+		JSONField JSONUnmarshalType `env:"CHAIN_JSON,parser=text|json"`
+	}
+
+	_ = os.Setenv("CHAIN_JSON", "not-json")
+
+	cfg := &ChainConfig{}
+	err := ParseEnv(cfg)
+	if err == nil {
+		t.Fatal("expected an error when all parsers in the chain fail, but got none")
+	}
+}
+
+// TestParseEnvFromMap tests parsing from a map without touching the process environment.
+func TestParseEnvFromMap(t *testing.T) {
+	type MapConfig struct {
+		Host  string `env:"FROM_MAP_HOST"`
+		Port  int    `env:"FROM_MAP_PORT,default=80"`
+		Debug bool   `env:"FROM_MAP_DEBUG,required"`
+	}
+
+	_ = os.Setenv("FROM_MAP_HOST", "from-env")
+
+	cfg := &MapConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"FROM_MAP_DEBUG": "true",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnvFromMap returned an error: %v", err)
+	}
+
+	if cfg.Host != "" {
+		t.Errorf("expected Host to be empty since it's not in the map, got '%s'", cfg.Host)
+	}
+	if cfg.Port != 80 {
+		t.Errorf("expected Port to be 80, got %d", cfg.Port)
+	}
+	if !cfg.Debug {
+		t.Errorf("expected Debug to be true, got %v", cfg.Debug)
+	}
+
+	err = ParseEnvFromMap(&MapConfig{}, map[string]string{})
+	if err == nil {
+		t.Fatal("expected an error when required variable is missing from the map, but got none")
+	}
+}
+
+// TestParseEnvFromMapConcurrent tests that concurrent parses from different maps don't interfere.
+func TestParseEnvFromMapConcurrent(t *testing.T) {
+	type MapConfig struct {
+		ID    int      `env:"CONCURRENT_ID"`
+		Names []string `env:"CONCURRENT_NAMES"`
+	}
+
+	for i := range 8 {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+
+			vars := map[string]string{
+				"CONCURRENT_ID":    strconv.Itoa(i),
+				"CONCURRENT_NAMES": fmt.Sprintf("a%d,b%d", i, i),
+			}
+			for range 100 {
+				cfg := &MapConfig{}
+				if err := ParseEnvFromMap(cfg, vars); err != nil {
+					t.Fatalf("ParseEnvFromMap returned an error: %v", err)
+				}
+				expected := []string{fmt.Sprintf("a%d", i), fmt.Sprintf("b%d", i)}
+				if cfg.ID != i || !reflect.DeepEqual(cfg.Names, expected) {
+					t.Fatalf("expected {%d %v}, got {%d %v}", i, expected, cfg.ID, cfg.Names)
+				}
+			}
+		})
+	}
+}
+
+// TestParseEnvIntegerOverflow tests that values out of range for the target width produce an error.
+func TestParseEnvIntegerOverflow(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   interface{}
+		envValue string
+	}{
+		{
+			name: "Int8",
+			config: &struct {
+				Field int8 `env:"OVERFLOW_FIELD"`
+			}{},
+			envValue: "99999",
+		},
+		{
+			name: "Int8Negative",
+			config: &struct {
+				Field int8 `env:"OVERFLOW_FIELD"`
+			}{},
+			envValue: "-129",
+		},
+		{
+			name: "Int16",
+			config: &struct {
+				Field int16 `env:"OVERFLOW_FIELD"`
+			}{},
+			envValue: "32768",
+		},
+		{
+			name: "Int32",
+			config: &struct {
+				Field int32 `env:"OVERFLOW_FIELD"`
+			}{},
+			envValue: "2147483648",
+		},
+		{
+			name: "Int64",
+			config: &struct {
+				Field int64 `env:"OVERFLOW_FIELD"`
+			}{},
+			envValue: "9223372036854775808",
+		},
+		{
+			name: "Uint8",
+			config: &struct {
+				Field uint8 `env:"OVERFLOW_FIELD"`
+			}{},
+			envValue: "256",
+		},
+		{
+			name: "Uint16",
+			config: &struct {
+				Field uint16 `env:"OVERFLOW_FIELD"`
+			}{},
+			envValue: "65536",
+		},
+		{
+			name: "Uint32",
+			config: &struct {
+				Field uint32 `env:"OVERFLOW_FIELD"`
+			}{},
+			envValue: "4294967296",
+		},
+		{
+			name: "Uint64",
+			config: &struct {
+				Field uint64 `env:"OVERFLOW_FIELD"`
+			}{},
+			envValue: "18446744073709551616",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = os.Setenv("OVERFLOW_FIELD", tt.envValue)
+			err := ParseEnv(tt.config)
+			if err == nil {
+				t.Fatalf("expected an overflow error for %s, but got none", tt.name)
+			}
+			if !strings.Contains(err.Error(), "Field") || !strings.Contains(err.Error(), "overflows") {
+				t.Errorf("expected error to name the field and the overflow, got: %v", err)
+			}
+		})
+	}
+}
+
+// TestParseEnvTransform tests the transform tag option, alone and combined with oneof.
+func TestParseEnvTransform(t *testing.T) {
+	type TransformConfig struct {
+		Code   string `env:"TRANSFORM_CODE,transform=upper"`
+		Name   string `env:"TRANSFORM_NAME,transform=trim"`
+		Mode   string `env:"TRANSFORM_MODE,transform=trim+lower,oneof=dev staging prod"`
+		Region string `env:"TRANSFORM_REGION,transform=lower,default=EU-WEST"`
+	}
+
+	_ = os.Setenv("TRANSFORM_CODE", "abc")
+	_ = os.Setenv("TRANSFORM_NAME", "  padded  ")
+	_ = os.Setenv("TRANSFORM_MODE", "  PROD ")
+	_ = os.Unsetenv("TRANSFORM_REGION")
+
+	cfg := &TransformConfig{}
+	err := ParseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.Code != "ABC" {
+		t.Errorf("expected Code to be 'ABC', got '%s'", cfg.Code)
+	}
+	if cfg.Name != "padded" {
+		t.Errorf("expected Name to be 'padded', got '%s'", cfg.Name)
+	}
+	if cfg.Mode != "prod" {
+		t.Errorf("expected Mode to be 'prod', got '%s'", cfg.Mode)
+	}
+	if cfg.Region != "eu-west" {
+		t.Errorf("expected Region to be 'eu-west', got '%s'", cfg.Region)
+	}
+}
+
+// TestParseEnvTransformErrors tests oneof rejection after transforms and unknown transform names.
+func TestParseEnvTransformErrors(t *testing.T) {
+	type OneOfConfig struct {
+		Mode string `env:"TRANSFORM_MODE,transform=trim+lower,oneof=dev staging prod"`
+	}
+
+	_ = os.Setenv("TRANSFORM_MODE", " Test ")
+	err := ParseEnv(&OneOfConfig{})
+	if err == nil {
+		t.Fatal("expected an error when value is not one of the allowed values, but got none")
+	}
+
+	type UnknownConfig struct {
+		Mode string `env:"TRANSFORM_MODE,transform=trim+reverse"`
+	}
+
+	err = ParseEnv(&UnknownConfig{})
+	if err == nil {
+		t.Fatal("expected an error for an unknown transform, but got none")
+	}
+}
+
+// TestParseEnvOneOfSlice tests that oneof checks every element of a slice.
+func TestParseEnvOneOfSlice(t *testing.T) {
+	type OneOfSliceConfig struct {
+		Modes []string `env:"ONEOF_MODES,transform=lower,oneof=read write admin"`
+		Steps []string `env:"ONEOF_STEPS,indexed,oneof=build test deploy"`
+	}
+
+	cfg := &OneOfSliceConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"ONEOF_MODES":   "Read,WRITE",
+		"ONEOF_STEPS_0": "build",
+		"ONEOF_STEPS_1": "deploy",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if expected := []string{"read", "write"}; !reflect.DeepEqual(cfg.Modes, expected) {
+		t.Errorf("expected Modes to be %v, got %v", expected, cfg.Modes)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"invalid list element", map[string]string{"ONEOF_MODES": "read,root,write"}, "value 'root' at index 1 of field Modes must be one of [read write admin]"},
+		{"invalid indexed element", map[string]string{"ONEOF_STEPS_0": "build", "ONEOF_STEPS_1": "lint"}, "value 'lint' at index 1 of field Steps must be one of [build test deploy]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&OneOfSliceConfig{}, tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// TestParseEnvFuncRegistry tests resolving function fields from a named registry.
+func TestParseEnvFuncRegistry(t *testing.T) {
+	type Strategy func(a, b int) int
+
+	type StrategyConfig struct {
+		Strategy Strategy              `env:"FUNC_STRATEGY,registry=strategies"`
+		Combine  func(a, b string) int `env:"FUNC_COMBINE,registry=strategies,default=length"`
+	}
+
+	opts := ParseEnvOptions{
+		Funcs: map[string]map[string]any{
+			"strategies": {
+				"sum":    Strategy(func(a, b int) int { return a + b }),
+				"max":    func(a, b int) int { return max(a, b) },
+				"length": func(a, b string) int { return len(a) + len(b) },
+			},
+		},
+	}
+
+	_ = os.Setenv("FUNC_STRATEGY", "max")
+	_ = os.Unsetenv("FUNC_COMBINE")
+
+	cfg := &StrategyConfig{}
+	err := ParseEnvWithOptions(cfg, opts)
+	if err != nil {
+		t.Fatalf("ParseEnvWithOptions returned an error: %v", err)
+	}
+
+	if cfg.Strategy == nil || cfg.Strategy(2, 5) != 5 {
+		t.Errorf("expected Strategy to be the 'max' function")
+	}
+	if cfg.Combine == nil || cfg.Combine("ab", "c") != 3 {
+		t.Errorf("expected Combine to be the 'length' function")
+	}
+}
+
+// TestParseEnvFuncRegistryErrors tests error handling for unregistered and mistyped functions.
+func TestParseEnvFuncRegistryErrors(t *testing.T) {
+	type StrategyConfig struct {
+		Strategy func(a, b int) int `env:"FUNC_STRATEGY,registry=strategies"`
+	}
+
+	opts := ParseEnvOptions{
+		Funcs: map[string]map[string]any{
+			"strategies": {
+				"sum":   func(a, b int) int { return a + b },
+				"upper": strings.ToUpper,
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		envValue string
+		opts     ParseEnvOptions
+	}{
+		{name: "Unregistered", envValue: "min", opts: opts},
+		{name: "TypeMismatch", envValue: "upper", opts: opts},
+		{name: "MissingRegistry", envValue: "sum", opts: ParseEnvOptions{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = os.Setenv("FUNC_STRATEGY", tt.envValue)
+			err := ParseEnvWithOptions(&StrategyConfig{}, tt.opts)
+			if err == nil {
+				t.Fatalf("expected an error for %s, but got none", tt.name)
+			}
+		})
+	}
+}
+
+// TestParseEnvRequiredNestedStruct tests that a required nested struct needs at least one variable set.
+func TestParseEnvRequiredNestedStruct(t *testing.T) {
+	type NestedConfig struct {
+		User string `env:"REQUIRED_NESTED_USER"`
+		Pass string `env:"REQUIRED_NESTED_PASS"`
+	}
+
+	type ParentConfig struct {
+		Auth NestedConfig `env:",required"`
+	}
+
+	_ = os.Unsetenv("REQUIRED_NESTED_USER")
+	_ = os.Unsetenv("REQUIRED_NESTED_PASS")
+
+	err := ParseEnv(&ParentConfig{})
+	if err == nil {
+		t.Fatal("expected an error when required nested struct has no variables set, but got none")
+	}
+	if !strings.Contains(err.Error(), "Auth") {
+		t.Errorf("expected error to name the field Auth, got: %v", err)
+	}
+
+	_ = os.Setenv("REQUIRED_NESTED_USER", "admin")
+
+	cfg := &ParentConfig{}
+	err = ParseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Auth.User != "admin" {
+		t.Errorf("expected Auth.User to be 'admin', got '%s'", cfg.Auth.User)
+	}
+}
+
+// TestParseEnvRequiredEmptySlice tests that a required slice set to an empty value produces an error naming the field.
+func TestParseEnvRequiredEmptySlice(t *testing.T) {
+	type SliceConfig struct {
+		Hosts []string `env:"REQUIRED_SLICE_HOSTS,required"`
+	}
+
+	_ = os.Setenv("REQUIRED_SLICE_HOSTS", "")
+
+	err := ParseEnv(&SliceConfig{})
+	if err == nil {
+		t.Fatal("expected an error when required slice is empty, but got none")
+	}
+	if !strings.Contains(err.Error(), "Hosts") {
+		t.Errorf("expected error to name the field Hosts, got: %v", err)
+	}
+}
+
+// TestParseEnvBoolTokens tests custom true/false tokens on bool and []bool fields.
+func TestParseEnvBoolTokens(t *testing.T) {
+	type TokenConfig struct {
+		Mode     bool   `env:"BOOL_TOKEN_MODE,true=enabled,false=disabled"`
+		Numeric  bool   `env:"BOOL_TOKEN_NUMERIC,true=1,false=2"`
+		Features []bool `env:"BOOL_TOKEN_FEATURES,true=on,false=off"`
+		Partial  bool   `env:"BOOL_TOKEN_PARTIAL,true=yes"`
+	}
+
+	_ = os.Setenv("BOOL_TOKEN_MODE", "Enabled")
+	_ = os.Setenv("BOOL_TOKEN_NUMERIC", "2")
+	_ = os.Setenv("BOOL_TOKEN_FEATURES", "on,off,on")
+	_ = os.Setenv("BOOL_TOKEN_PARTIAL", "false")
+
+	cfg := &TokenConfig{Numeric: true, Partial: true}
+	err := ParseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if !cfg.Mode {
+		t.Errorf("expected Mode to be true, got %v", cfg.Mode)
+	}
+	if cfg.Numeric {
+		t.Errorf("expected Numeric to be false, got %v", cfg.Numeric)
+	}
+	expected := []bool{true, false, true}
+	if !reflect.DeepEqual(cfg.Features, expected) {
+		t.Errorf("expected Features to be %v, got %v", expected, cfg.Features)
+	}
+	if cfg.Partial {
+		t.Errorf("expected Partial to fall back to strconv.ParseBool and be false, got %v", cfg.Partial)
+	}
+}
+
+// TestParseEnvBoolTokensError tests that values matching neither token are rejected.
+func TestParseEnvBoolTokensError(t *testing.T) {
+	type TokenConfig struct {
+		Mode bool `env:"BOOL_TOKEN_MODE,true=enabled,false=disabled"`
+	}
+
+	type SliceTokenConfig struct {
+		Features []bool `env:"BOOL_TOKEN_FEATURES,true=on,false=off"`
+	}
+
+	_ = os.Setenv("BOOL_TOKEN_MODE", "true")
+	if err := ParseEnv(&TokenConfig{}); err == nil {
+		t.Fatal("expected an error when value matches neither token, but got none")
+	}
+
+	_ = os.Setenv("BOOL_TOKEN_FEATURES", "on,maybe")
+	if err := ParseEnv(&SliceTokenConfig{}); err == nil {
+		t.Fatal("expected an error when a slice element matches neither token, but got none")
+	}
+}
+
+// TestParseEnvInvalidInput tests that invalid cfg arguments return a helpful error instead of panicking.
+func TestParseEnvInvalidInput(t *testing.T) {
+	type ValidConfig struct {
+		Field string `env:"INVALID_INPUT_FIELD"`
+	}
+
+	var nilPtr *ValidConfig
+	intVal := 42
+
+	tests := []struct {
+		name     string
+		cfg      any
+		expected string
+	}{
+		{name: "Nil", cfg: nil, expected: "got nil"},
+		{name: "NilPointer", cfg: nilPtr, expected: "got nil *lazyconf.ValidConfig"},
+		{name: "NonPointer", cfg: ValidConfig{}, expected: "got lazyconf.ValidConfig"},
+		{name: "PointerToNonStruct", cfg: &intVal, expected: "got *int"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnv(tt.cfg)
+			if err == nil {
+				t.Fatalf("expected an error for %s, but got none", tt.name)
+			}
+			if !strings.HasPrefix(err.Error(), "xconf.ParseEnv: requires a non-nil pointer to a struct") || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected a helpful error mentioning %q, got: %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+// DynamicDefaultConfig for testing dynamic default methods
+type DynamicDefaultConfig struct {
+	Host    string `env:"DYNAMIC_DEFAULT_HOST"`
+	Workers int    `env:"DYNAMIC_DEFAULT_WORKERS"`
+	Region  string `env:"DYNAMIC_DEFAULT_REGION,default=eu-west"`
+}
+
+// DefaultHost computes the default value for Host
+func (c *DynamicDefaultConfig) DefaultHost() string {
+	return "computed-host"
+}
+
+// DefaultWorkers computes the default value for Workers
+func (c DynamicDefaultConfig) DefaultWorkers() string {
+	return strconv.Itoa(4)
+}
+
+// DefaultRegion is ignored because Region has a static default
+func (c *DynamicDefaultConfig) DefaultRegion() string {
+	return "us-east"
+}
+
+// DynamicDefaultConfigBadSignature for testing default methods with a wrong signature
+type DynamicDefaultConfigBadSignature struct {
+	Host string `env:"DYNAMIC_DEFAULT_HOST"`
+}
+
+// DefaultHost has a wrong signature
+func (c *DynamicDefaultConfigBadSignature) DefaultHost() (string, error) {
+	return "computed-host", nil
+}
+
+// TestParseEnvDynamicDefault tests Default<FieldName> methods computing defaults.
+func TestParseEnvDynamicDefault(t *testing.T) {
+	_ = os.Unsetenv("DYNAMIC_DEFAULT_HOST")
+	_ = os.Unsetenv("DYNAMIC_DEFAULT_WORKERS")
+	_ = os.Unsetenv("DYNAMIC_DEFAULT_REGION")
+
+	cfg := &DynamicDefaultConfig{}
+	err := ParseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.Host != "computed-host" {
+		t.Errorf("expected Host to be 'computed-host', got '%s'", cfg.Host)
+	}
+	if cfg.Workers != 4 {
+		t.Errorf("expected Workers to be 4, got %d", cfg.Workers)
+	}
+	if cfg.Region != "eu-west" {
+		t.Errorf("expected Region to use the static default 'eu-west', got '%s'", cfg.Region)
+	}
+
+	_ = os.Setenv("DYNAMIC_DEFAULT_HOST", "env-host")
+
+	cfg = &DynamicDefaultConfig{}
+	err = ParseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Host != "env-host" {
+		t.Errorf("expected Host to be 'env-host', got '%s'", cfg.Host)
+	}
+}
+
+// TestParseEnvDynamicDefaultBadSignature tests error when the default method has a wrong signature.
+func TestParseEnvDynamicDefaultBadSignature(t *testing.T) {
+	_ = os.Unsetenv("DYNAMIC_DEFAULT_HOST")
+
+	cfg := &DynamicDefaultConfigBadSignature{}
+	err := ParseEnv(cfg)
+	if err == nil {
+		t.Fatal("expected an error when default method has a wrong signature, but got none")
+	}
+
+	type NestedBadSignatureConfig struct {
+		Nested DynamicDefaultConfigBadSignature
+	}
+	err = ParseEnv(&NestedBadSignatureConfig{})
+	want := "default method 'DefaultHost' for field 'Nested.Host'"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("expected an error containing %q, got: %v", want, err)
+	}
+}
+
+// TestParseEnvParserHexNum tests parser="hexnum" on scalar and slice integer fields.
+func TestParseEnvParserHexNum(t *testing.T) {
+	type HexConfig struct {
+		Register uint32  `env:"HEXNUM_REGISTER,parser=hexnum"`
+		Mask     uint64  `env:"HEXNUM_MASK,parser=hexnum"`
+		Offset   int16   `env:"HEXNUM_OFFSET,parser=hexnum"`
+		Values   []uint8 `env:"HEXNUM_VALUES,parser=hexnum"`
+		Signed   []int64 `env:"HEXNUM_SIGNED,parser=hexnum"`
+	}
+
+	_ = os.Setenv("HEXNUM_REGISTER", "DEADBEEF")
+	_ = os.Setenv("HEXNUM_MASK", "ffffffffffffffff")
+	_ = os.Setenv("HEXNUM_OFFSET", "-7f")
+	_ = os.Setenv("HEXNUM_VALUES", "0a,FF,10")
+	_ = os.Setenv("HEXNUM_SIGNED", "1,-10")
+
+	cfg := &HexConfig{}
+	err := ParseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.Register != 0xDEADBEEF {
+		t.Errorf("expected Register to be 0xDEADBEEF, got %#x", cfg.Register)
+	}
+	if cfg.Mask != 0xffffffffffffffff {
+		t.Errorf("expected Mask to be 0xffffffffffffffff, got %#x", cfg.Mask)
+	}
+	if cfg.Offset != -0x7f {
+		t.Errorf("expected Offset to be -0x7f, got %d", cfg.Offset)
+	}
+	if !reflect.DeepEqual(cfg.Values, []uint8{0x0a, 0xff, 0x10}) {
+		t.Errorf("expected Values to be [10 255 16], got %v", cfg.Values)
+	}
+	if !reflect.DeepEqual(cfg.Signed, []int64{1, -16}) {
+		t.Errorf("expected Signed to be [1 -16], got %v", cfg.Signed)
+	}
+}
+
+// TestParseEnvParserHexNumError tests error handling for parser="hexnum".
+func TestParseEnvParserHexNumError(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   interface{}
+		envValue string
+	}{
+		{
+			name: "NonHex",
+			config: &struct {
+				Field uint32 `env:"HEXNUM_ERROR,parser=hexnum"`
+			}{},
+			envValue: "XYZ",
+		},
+		{
+			name: "Prefixed",
+			config: &struct {
+				Field uint32 `env:"HEXNUM_ERROR,parser=hexnum"`
+			}{},
+			envValue: "0xFF",
+		},
+		{
+			name: "Overflow",
+			config: &struct {
+				Field uint8 `env:"HEXNUM_ERROR,parser=hexnum"`
+			}{},
+			envValue: "100",
+		},
+		{
+			name: "SliceElement",
+			config: &struct {
+				Field []uint16 `env:"HEXNUM_ERROR,parser=hexnum"`
+			}{},
+			envValue: "ff,zz",
+		},
+		{
+			name: "NonInteger",
+			config: &struct {
+				Field string `env:"HEXNUM_ERROR,parser=hexnum"`
+			}{},
+			envValue: "ff",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = os.Setenv("HEXNUM_ERROR", tt.envValue)
+			err := ParseEnv(tt.config)
+			if err == nil {
+				t.Fatalf("expected an error for %s, but got none", tt.name)
+			}
+		})
+	}
+}
+
+// TestParseEnvDefaultIndirection tests default values referencing other variables with "$".
+func TestParseEnvDefaultIndirection(t *testing.T) {
+	type IndirectConfig struct {
+		Region  string `env:"INDIRECT_REGION,default=$INDIRECT_AWS_REGION"`
+		Port    int    `env:"INDIRECT_PORT,default=$INDIRECT_BASE_PORT"`
+		Price   string `env:"INDIRECT_PRICE,default=$$5"`
+		Missing string `env:"INDIRECT_MISSING,default=$INDIRECT_UNSET"`
+	}
+
+	vars := map[string]string{
+		"INDIRECT_AWS_REGION": "eu-central-1",
+		"INDIRECT_BASE_PORT":  "8443",
+	}
+
+	cfg := &IndirectConfig{}
+	err := ParseEnvFromMap(cfg, vars)
+	if err != nil {
+		t.Fatalf("ParseEnvFromMap returned an error: %v", err)
+	}
+
+	if cfg.Region != "eu-central-1" {
+		t.Errorf("expected Region to be 'eu-central-1', got '%s'", cfg.Region)
+	}
+	if cfg.Port != 8443 {
+		t.Errorf("expected Port to be 8443, got %d", cfg.Port)
+	}
+	if cfg.Price != "$5" {
+		t.Errorf("expected Price to be '$5', got '%s'", cfg.Price)
+	}
+	if cfg.Missing != "" {
+		t.Errorf("expected Missing to be empty, got '%s'", cfg.Missing)
+	}
+
+	// The variable itself takes precedence over the referenced default
+	vars["INDIRECT_REGION"] = "us-east-1"
+	cfg = &IndirectConfig{}
+	err = ParseEnvFromMap(cfg, vars)
+	if err != nil {
+		t.Fatalf("ParseEnvFromMap returned an error: %v", err)
+	}
+	if cfg.Region != "us-east-1" {
+		t.Errorf("expected Region to be 'us-east-1', got '%s'", cfg.Region)
+	}
+}
+
+// TestParseEnvDefaultIndirectionRequired tests that an unset referenced variable falls through to required handling.
+func TestParseEnvDefaultIndirectionRequired(t *testing.T) {
+	type IndirectConfig struct {
+		Region string `env:"INDIRECT_REGION,required,default=$INDIRECT_AWS_REGION"`
+	}
+
+	err := ParseEnvFromMap(&IndirectConfig{}, map[string]string{})
+	if err == nil {
+		t.Fatal("expected an error when required variable and its referenced default are unset, but got none")
+	}
+}
+
+// TestParseEnvIgnoredField tests that fields tagged with "-" are skipped entirely.
+func TestParseEnvIgnoredField(t *testing.T) {
+	type ManagedConfig struct {
+		Host string `env:"IGNORED_NESTED_HOST,required"`
+	}
+
+	type IgnoredConfig struct {
+		Name     string           `env:"IGNORED_NAME"`
+		Managed  ManagedConfig    `env:"-"`
+		Pointer  *ManagedConfig   `env:"-"`
+		Unknown  map[int]chan int `env:"-"`
+		WithOpts string           `env:"-,required"`
+		private  string           `env:"-"`
+	}
+
+	_ = os.Setenv("IGNORED_NAME", "service")
+	_ = os.Setenv("IGNORED_NESTED_HOST", "db.local")
+	_ = os.Setenv("-", "dash")
+
+	cfg := &IgnoredConfig{Managed: ManagedConfig{Host: "manual"}}
+	err := ParseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.Name != "service" {
+		t.Errorf("expected Name to be 'service', got '%s'", cfg.Name)
+	}
+	if cfg.Managed.Host != "manual" {
+		t.Errorf("expected Managed.Host to keep 'manual', got '%s'", cfg.Managed.Host)
+	}
+	if cfg.Pointer != nil {
+		t.Errorf("expected Pointer to stay nil, got %+v", cfg.Pointer)
+	}
+	if cfg.WithOpts != "" || cfg.private != "" {
+		t.Errorf("expected ignored fields to stay empty, got '%s' and '%s'", cfg.WithOpts, cfg.private)
+	}
+}
+
+// TestParseEnvParserPercent tests parser="percent" on scalar and slice float fields.
+func TestParseEnvParserPercent(t *testing.T) {
+	type PercentConfig struct {
+		SampleRate float64   `env:"PERCENT_SAMPLE_RATE,parser=percent"`
+		Plain      float32   `env:"PERCENT_PLAIN,parser=percent"`
+		Weights    []float64 `env:"PERCENT_WEIGHTS,parser=percent"`
+	}
+
+	_ = os.Setenv("PERCENT_SAMPLE_RATE", "25%")
+	_ = os.Setenv("PERCENT_PLAIN", "0.5")
+	_ = os.Setenv("PERCENT_WEIGHTS", "10%,12.5%,0.75")
+
+	cfg := &PercentConfig{}
+	err := ParseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.SampleRate != 0.25 {
+		t.Errorf("expected SampleRate to be 0.25, got %f", cfg.SampleRate)
+	}
+	if cfg.Plain != 0.5 {
+		t.Errorf("expected Plain to be 0.5, got %f", cfg.Plain)
+	}
+	expected := []float64{0.1, 0.125, 0.75}
+	if !reflect.DeepEqual(cfg.Weights, expected) {
+		t.Errorf("expected Weights to be %v, got %v", expected, cfg.Weights)
+	}
+}
+
+// TestParseEnvParserPercentError tests error handling for parser="percent".
+func TestParseEnvParserPercentError(t *testing.T) {
+	type PercentConfig struct {
+		SampleRate float64 `env:"PERCENT_ERROR,parser=percent"`
+	}
+
+	for _, value := range []string{"25%%", "%25", "abc%", "2%5"} {
+		t.Run(value, func(t *testing.T) {
+			_ = os.Setenv("PERCENT_ERROR", value)
+			err := ParseEnv(&PercentConfig{})
+			if err == nil {
+				t.Fatalf("expected an error for %q, but got none", value)
+			}
+		})
+	}
+}
+
+// TestParseEnvEscapedSlice tests backslash-escaped commas and backslashes with the escaped option.
+func TestParseEnvEscapedSlice(t *testing.T) {
+	type EscapedConfig struct {
+		Paths    []string `env:"ESCAPED_PATHS,escaped"`
+		Windows  []string `env:"ESCAPED_WINDOWS,escaped"`
+		Other    []string `env:"ESCAPED_OTHER,escaped"`
+		Unescape []string `env:"ESCAPED_PATHS"`
+	}
+
+	_ = os.Setenv("ESCAPED_PATHS", `a\,b,c`)
+	_ = os.Setenv("ESCAPED_WINDOWS", `C:\\dir\\,D:\\`)
+	_ = os.Setenv("ESCAPED_OTHER", `tab\t,x`)
+
+	cfg := &EscapedConfig{}
+	err := ParseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if expected := []string{"a,b", "c"}; !reflect.DeepEqual(cfg.Paths, expected) {
+		t.Errorf("expected Paths to be %q, got %q", expected, cfg.Paths)
+	}
+	if expected := []string{`C:\dir\`, `D:\`}; !reflect.DeepEqual(cfg.Windows, expected) {
+		t.Errorf("expected Windows to be %q, got %q", expected, cfg.Windows)
+	}
+	if expected := []string{`tab\t`, "x"}; !reflect.DeepEqual(cfg.Other, expected) {
+		t.Errorf("expected Other to be %q, got %q", expected, cfg.Other)
+	}
+	if expected := []string{`a\`, "b", "c"}; !reflect.DeepEqual(cfg.Unescape, expected) {
+		t.Errorf("expected Unescape to be split without escaping %q, got %q", expected, cfg.Unescape)
+	}
+}
+
+// TestSnapshot tests that configs parsed from one snapshot are stable when the environment changes.
+func TestSnapshot(t *testing.T) {
+	type FirstConfig struct {
+		Host string `env:"SNAPSHOT_HOST"`
+	}
+
+	type SecondConfig struct {
+		Host string `env:"SNAPSHOT_HOST"`
+		Port int    `env:"SNAPSHOT_PORT"`
+	}
+
+	_ = os.Setenv("SNAPSHOT_HOST", "before")
+	_ = os.Setenv("SNAPSHOT_PORT", "1000")
+
+	snapshot := Snapshot()
+
+	first := &FirstConfig{}
+	if err := ParseEnvFromMap(first, snapshot); err != nil {
+		t.Fatalf("ParseEnvFromMap returned an error: %v", err)
+	}
+
+	_ = os.Setenv("SNAPSHOT_HOST", "after")
+	_ = os.Unsetenv("SNAPSHOT_PORT")
+
+	second := &SecondConfig{}
+	if err := ParseEnvFromMap(second, snapshot); err != nil {
+		t.Fatalf("ParseEnvFromMap returned an error: %v", err)
+	}
+
+	if first.Host != "before" || second.Host != "before" {
+		t.Errorf("expected both Hosts to be 'before', got '%s' and '%s'", first.Host, second.Host)
+	}
+	if second.Port != 1000 {
+		t.Errorf("expected Port to be 1000, got %d", second.Port)
+	}
+}
+
+// TestParseEnvStructSliceUnmarshal tests struct slices whose elements implement UnmarshalJSON or UnmarshalText.
+func TestParseEnvStructSliceUnmarshal(t *testing.T) {
+	type UnmarshalSliceConfig struct {
+		JSONSlice []JSONUnmarshalType `env:"STRUCT_SLICE_JSON"`
+		TextSlice []TextUnmarshalType `env:"STRUCT_SLICE_TEXT"`
+	}
+
+	_ = os.Setenv("STRUCT_SLICE_JSON", `{"a":1},{"b":2}`)
+	_ = os.Setenv("STRUCT_SLICE_TEXT", "x,y")
+
+	cfg := &UnmarshalSliceConfig{}
+	err := ParseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	expectedJSON := []JSONUnmarshalType{
+		{Data: map[string]interface{}{"a": float64(1)}},
+		{Data: map[string]interface{}{"b": float64(2)}},
+	}
+	if !reflect.DeepEqual(cfg.JSONSlice, expectedJSON) {
+		t.Errorf("expected JSONSlice to be %v, got %v", expectedJSON, cfg.JSONSlice)
+	}
+	expectedText := []TextUnmarshalType{{Value: "text:x"}, {Value: "text:y"}}
+	if !reflect.DeepEqual(cfg.TextSlice, expectedText) {
+		t.Errorf("expected TextSlice to be %v, got %v", expectedText, cfg.TextSlice)
+	}
+
+	_ = os.Setenv("STRUCT_SLICE_JSON", `{"a":1},not-json`)
+	err = ParseEnv(&UnmarshalSliceConfig{})
+	if err == nil {
+		t.Fatal("expected an error when a struct slice element fails to unmarshal, but got none")
+	}
+}
+
+// TestParseEnvDisableUnmarshalFallback tests that the implicit unmarshaler fallback can be turned off.
+func TestParseEnvDisableUnmarshalFallback(t *testing.T) {
+	type FallbackConfig struct {
+		IntField     IntAlias          `env:"NO_FALLBACK_INT"`
+		IntSlice     []IntAlias        `env:"NO_FALLBACK_INTS"`
+		ExplicitText TextUnmarshalType `env:"NO_FALLBACK_TEXT,parser=text"`
+	}
+
+	_ = os.Setenv("NO_FALLBACK_INT", "5")
+	_ = os.Setenv("NO_FALLBACK_INTS", "1,2")
+	_ = os.Setenv("NO_FALLBACK_TEXT", "explicit")
+
+	opts := ParseEnvOptions{DisableUnmarshalFallback: true}
+
+	cfg := &FallbackConfig{}
+	err := ParseEnvWithOptions(cfg, opts)
+	if err != nil {
+		t.Fatalf("ParseEnvWithOptions returned an error: %v", err)
+	}
+
+	if cfg.IntField != 5 {
+		t.Errorf("expected IntField to be parsed as a plain int 5, got %d", cfg.IntField)
+	}
+	if !reflect.DeepEqual(cfg.IntSlice, []IntAlias{1, 2}) {
+		t.Errorf("expected IntSlice to be parsed as plain ints [1 2], got %v", cfg.IntSlice)
+	}
+	if cfg.ExplicitText.Value != "text:explicit" {
+		t.Errorf("expected ExplicitText.Value to be 'text:explicit', got '%s'", cfg.ExplicitText.Value)
+	}
+
+	// The default behaviour keeps using UnmarshalText
+	cfg = &FallbackConfig{}
+	err = ParseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.IntField != 50 {
+		t.Errorf("expected IntField to use UnmarshalText and be 50, got %d", cfg.IntField)
+	}
+
+	// Struct types without explicit parser are unsupported when the fallback is disabled
+	type StructConfig struct {
+		TextField TextUnmarshalType `env:"NO_FALLBACK_TEXT"`
+	}
+	err = ParseEnvWithOptions(&StructConfig{}, opts)
+	if err == nil {
+		t.Fatal("expected an error for a struct field without parser when the fallback is disabled, but got none")
+	}
+}
+
+// errRejectedPayload is returned by FailingJSONType.UnmarshalJSON
+var errRejectedPayload = fmt.Errorf("payload rejected")
+
+// FailingJSONType implements json.Unmarshaler and always fails
+type FailingJSONType struct {
+	Data string
+}
+
+func (f *FailingJSONType) UnmarshalJSON(data []byte) error {
+	return errRejectedPayload
+}
+
+// FailingTextMap implements encoding.TextUnmarshaler on a non-struct type and always fails
+type FailingTextMap map[string]string
+
+func (f *FailingTextMap) UnmarshalText(text []byte) error {
+	return fmt.Errorf("bad map text %q", string(text))
+}
+
+// TestParseEnvUnmarshalErrorSurfaced tests that unmarshaler errors are returned instead of a generic one.
+func TestParseEnvUnmarshalErrorSurfaced(t *testing.T) {
+	type StructConfig struct {
+		Payload FailingJSONType `env:"SURFACED_PAYLOAD"`
+	}
+
+	_ = os.Setenv("SURFACED_PAYLOAD", `{"data":"x"}`)
+
+	err := ParseEnv(&StructConfig{})
+	if err == nil {
+		t.Fatal("expected an error when UnmarshalJSON fails, but got none")
+	}
+	if !errors.Is(err, errRejectedPayload) {
+		t.Errorf("expected error to wrap errRejectedPayload, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "Payload") {
+		t.Errorf("expected error to name the field Payload, got: %v", err)
+	}
+
+	type MapConfig struct {
+		Labels FailingTextMap `env:"SURFACED_LABELS"`
+	}
+
+	_ = os.Setenv("SURFACED_LABELS", "a=b")
+
+	err = ParseEnv(&MapConfig{})
+	if err == nil {
+		t.Fatal("expected an error when UnmarshalText fails, but got none")
+	}
+	if !strings.Contains(err.Error(), `bad map text "a=b"`) {
+		t.Errorf("expected the UnmarshalText error to be surfaced, got: %v", err)
+	}
+
+	type SliceConfig struct {
+		Payloads []FailingJSONType `env:"SURFACED_PAYLOADS"`
+	}
+
+	err = ParseEnvFromMap(&SliceConfig{}, map[string]string{"SURFACED_PAYLOADS": "a,b"})
+	if !errors.Is(err, errRejectedPayload) || !strings.Contains(err.Error(), "element 0 of field Payloads") {
+		t.Errorf("expected the element error to wrap errRejectedPayload, got: %v", err)
+	}
+}
+
+// TestParseEnvUniqueSlice tests removing duplicate slice elements with the unique option.
+func TestParseEnvUniqueSlice(t *testing.T) {
+	type UniqueConfig struct {
+		Admins []string `env:"UNIQUE_ADMINS,unique"`
+		Ports  []int    `env:"UNIQUE_PORTS,unique"`
+		Sizes  []uint64 `env:"UNIQUE_SIZES,parser=bytesize,unique"`
+	}
+
+	_ = os.Setenv("UNIQUE_ADMINS", "bob,alice,bob,carol,alice")
+	_ = os.Setenv("UNIQUE_PORTS", "80,443,80")
+	_ = os.Setenv("UNIQUE_SIZES", "1KB,1000,2KB")
+
+	cfg := &UniqueConfig{}
+	err := ParseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if expected := []string{"bob", "alice", "carol"}; !reflect.DeepEqual(cfg.Admins, expected) {
+		t.Errorf("expected Admins to be %v, got %v", expected, cfg.Admins)
+	}
+	if expected := []int{80, 443}; !reflect.DeepEqual(cfg.Ports, expected) {
+		t.Errorf("expected Ports to be %v, got %v", expected, cfg.Ports)
+	}
+	if expected := []uint64{1000, 2000}; !reflect.DeepEqual(cfg.Sizes, expected) {
+		t.Errorf("expected Sizes to be %v, got %v", expected, cfg.Sizes)
+	}
+}
+
+// TestParseEnvUniqueStrictSlice tests that uniquestrict rejects duplicates and unique rejects non-comparable elements.
+func TestParseEnvUniqueStrictSlice(t *testing.T) {
+	type StrictConfig struct {
+		Admins []string `env:"UNIQUE_STRICT_ADMINS,uniquestrict"`
+		Ports  []int    `env:"UNIQUE_STRICT_PORTS,uniquestrict"`
+	}
+
+	_ = os.Setenv("UNIQUE_STRICT_ADMINS", "bob,alice")
+	_ = os.Setenv("UNIQUE_STRICT_PORTS", "80,443,80")
+
+	err := ParseEnv(&StrictConfig{})
+	if err == nil {
+		t.Fatal("expected an error for duplicate elements with uniquestrict, but got none")
+	}
+	if !strings.Contains(err.Error(), "duplicate element 80 at index 2") {
+		t.Errorf("expected error to name the duplicate element, got: %v", err)
+	}
+
+	type NonComparableConfig struct {
+		Matrix [][]string `env:"UNIQUE_UNSET_MATRIX,unique"`
+	}
+
+	_ = os.Unsetenv("UNIQUE_UNSET_MATRIX")
+
+	err = ParseEnv(&NonComparableConfig{})
+	if err == nil {
+		t.Fatal("expected an error for unique on non-comparable elements, but got none")
+	}
+}
+
+// TestParseEnvNestedSlices tests parsing [][]string and [][]int with outer and inner delimiters.
+func TestParseEnvNestedSlices(t *testing.T) {
+	type MatrixConfig struct {
+		Matrix   [][]string `env:"NESTED_MATRIX"`
+		Grid     [][]int    `env:"NESTED_GRID,delim=;,innerdelim=|"`
+		Explicit [][]string `env:"NESTED_EXPLICIT,delim=,,innerdelim=:"`
+	}
+
+	_ = os.Setenv("NESTED_MATRIX", "a:b:c,d:e")
+	_ = os.Setenv("NESTED_GRID", "1|2;3|4|5")
+	_ = os.Setenv("NESTED_EXPLICIT", "x:y,z")
+
+	cfg := &MatrixConfig{}
+	err := ParseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if expected := [][]string{{"a", "b", "c"}, {"d", "e"}}; !reflect.DeepEqual(cfg.Matrix, expected) {
+		t.Errorf("expected Matrix to be %v, got %v", expected, cfg.Matrix)
+	}
+	if expected := [][]int{{1, 2}, {3, 4, 5}}; !reflect.DeepEqual(cfg.Grid, expected) {
+		t.Errorf("expected Grid to be %v, got %v", expected, cfg.Grid)
+	}
+	if expected := [][]string{{"x", "y"}, {"z"}}; !reflect.DeepEqual(cfg.Explicit, expected) {
+		t.Errorf("expected Explicit to be %v, got %v", expected, cfg.Explicit)
+	}
+}
+
+// TestParseEnvNestedSliceError tests that element errors name the outer and inner index.
+func TestParseEnvNestedSliceError(t *testing.T) {
+	type GridConfig struct {
+		Grid [][]int `env:"NESTED_BAD_GRID"`
+	}
+
+	_ = os.Setenv("NESTED_BAD_GRID", "1:2,3:x")
+
+	err := ParseEnv(&GridConfig{})
+	if err == nil {
+		t.Fatal("expected an error for an invalid nested element, but got none")
+	}
+	if !strings.Contains(err.Error(), "[1][1]") {
+		t.Errorf("expected error to name index [1][1], got: %v", err)
+	}
+}
+
+// Storage is implemented by the pluggable backends in TestParseEnvFactoryRegistry.
+type Storage interface {
+	Name() string
+}
+
+type S3Storage struct {
+	Bucket string `env:"FACTORY_S3_BUCKET,required"`
+}
+
+func (s *S3Storage) Name() string { return "s3:" + s.Bucket }
+
+type MemoryStorage struct{}
+
+func (MemoryStorage) Name() string { return "memory" }
+
+// TestParseEnvFactoryRegistry tests constructing interface fields from a named registry.
+func TestParseEnvFactoryRegistry(t *testing.T) {
+	type StorageConfig struct {
+		Storage Storage `env:"FACTORY_STORAGE,registry=storage"`
+		Cache   Storage `env:"FACTORY_CACHE,registry=storage,default=memory"`
+	}
+
+	opts := ParseEnvOptions{
+		Factories: map[string]map[string]func() any{
+			"storage": {
+				"s3":     func() any { return &S3Storage{} },
+				"memory": func() any { return MemoryStorage{} },
+				"bogus":  func() any { return 42 },
+			},
+		},
+	}
+
+	_ = os.Setenv("FACTORY_STORAGE", "s3")
+	_ = os.Setenv("FACTORY_S3_BUCKET", "backups")
+	_ = os.Unsetenv("FACTORY_CACHE")
+
+	cfg := &StorageConfig{}
+	err := ParseEnvWithOptions(cfg, opts)
+	if err != nil {
+		t.Fatalf("ParseEnvWithOptions returned an error: %v", err)
+	}
+
+	if cfg.Storage == nil || cfg.Storage.Name() != "s3:backups" {
+		t.Errorf("expected Storage to be the parsed s3 implementation, got %v", cfg.Storage)
+	}
+	if cfg.Cache == nil || cfg.Cache.Name() != "memory" {
+		t.Errorf("expected Cache to be the memory implementation, got %v", cfg.Cache)
+	}
+
+	tests := []struct {
+		name     string
+		envValue string
+	}{
+		{name: "Unregistered", envValue: "gcs"},
+		{name: "NotImplementing", envValue: "bogus"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = os.Setenv("FACTORY_STORAGE", tt.envValue)
+			err := ParseEnvWithOptions(&StorageConfig{}, opts)
+			if err == nil {
+				t.Fatalf("expected an error for %s, but got none", tt.name)
+			}
+		})
+	}
+
+	_ = os.Setenv("FACTORY_STORAGE", "s3")
+	_ = os.Unsetenv("FACTORY_S3_BUCKET")
+	err = ParseEnvWithOptions(&StorageConfig{}, opts)
+	if err == nil {
+		t.Fatal("expected an error for the implementation's missing required variable, but got none")
+	}
+}
+
+// TestParseEnvRequiredPresentEmpty tests that an explicitly empty variable satisfies required while an unset one doesn't.
+func TestParseEnvRequiredPresentEmpty(t *testing.T) {
+	type RequiredConfig struct {
+		Token string `env:"REQUIRED_PRESENT_TOKEN,required"`
+		Port  int    `env:"REQUIRED_PRESENT_PORT,required"`
+	}
+
+	cfg := &RequiredConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{"REQUIRED_PRESENT_TOKEN": "", "REQUIRED_PRESENT_PORT": ""})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Token != "" || cfg.Port != 0 {
+		t.Errorf("expected explicitly empty fields to keep their zero values, got %+v", cfg)
+	}
+
+	err = ParseEnvFromMap(&RequiredConfig{}, map[string]string{"REQUIRED_PRESENT_TOKEN": ""})
+	if err == nil {
+		t.Fatal("expected an error when a required variable is unset, but got none")
+	}
+	if !strings.Contains(err.Error(), "REQUIRED_PRESENT_PORT") {
+		t.Errorf("expected error to name REQUIRED_PRESENT_PORT, got: %v", err)
+	}
+}
+
+// TestParseEnvAtomicTypes tests populating sync/atomic typed values through their Store method.
+func TestParseEnvAtomicTypes(t *testing.T) {
+	type AtomicConfig struct {
+		Limit   atomic.Int64  `env:"ATOMIC_LIMIT"`
+		Enabled atomic.Bool   `env:"ATOMIC_ENABLED,default=true"`
+		Size    atomic.Uint64 `env:"ATOMIC_SIZE,parser=bytesize"`
+		Unset   atomic.Int32  `env:"ATOMIC_UNSET"`
+	}
+
+	cfg := &AtomicConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"ATOMIC_LIMIT": "-42",
+		"ATOMIC_SIZE":  "2KiB",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.Limit.Load() != -42 {
+		t.Errorf("expected Limit to be -42, got %d", cfg.Limit.Load())
+	}
+	if !cfg.Enabled.Load() {
+		t.Error("expected Enabled to be true")
+	}
+	if cfg.Size.Load() != 2048 {
+		t.Errorf("expected Size to be 2048, got %d", cfg.Size.Load())
+	}
+	if cfg.Unset.Load() != 0 {
+		t.Errorf("expected Unset to be 0, got %d", cfg.Unset.Load())
+	}
+
+	err = ParseEnvFromMap(&AtomicConfig{}, map[string]string{"ATOMIC_ENABLED": "maybe"})
+	if err == nil {
+		t.Fatal("expected an error for an invalid atomic.Bool value, but got none")
+	}
+}
+
+type HookDBConfig struct {
+	Host  string `env:"HOOK_DB_HOST"`
+	Port  int    `env:"HOOK_DB_PORT"`
+	Name  string `env:"HOOK_DB_NAME"`
+	DSN   string
+	calls *[]string
+}
+
+func (c *HookDBConfig) Validate() error {
+	if c.calls != nil {
+		*c.calls = append(*c.calls, "db.Validate")
+	}
+	if c.Port <= 0 {
+		return fmt.Errorf("port must be positive, got %d", c.Port)
+	}
+	return nil
+}
+
+func (c *HookDBConfig) AfterParse() error {
+	if c.calls != nil {
+		*c.calls = append(*c.calls, "db.AfterParse")
+	}
+	c.DSN = fmt.Sprintf("%s:%d/%s", c.Host, c.Port, c.Name)
+	return nil
+}
+
+type HookAppConfig struct {
+	DB    HookDBConfig
+	Mode  string `env:"HOOK_APP_MODE"`
+	calls []string
+}
+
+func (c *HookAppConfig) Validate() error {
+	c.calls = append(c.calls, "app.Validate")
+	return nil
+}
+
+func (c *HookAppConfig) AfterParse() error {
+	c.calls = append(c.calls, "app.AfterParse")
+	if c.Mode == "broken" {
+		return errors.New("broken mode")
+	}
+	return nil
+}
+
+// TestParseEnvAfterParse tests that Validate and AfterParse run in order on nested and top-level structs.
+func TestParseEnvAfterParse(t *testing.T) {
+	cfg := &HookAppConfig{}
+	cfg.DB.calls = &cfg.calls
+
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"HOOK_DB_HOST": "db.local",
+		"HOOK_DB_PORT": "5432",
+		"HOOK_DB_NAME": "app",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.DB.DSN != "db.local:5432/app" {
+		t.Errorf("expected DSN to be derived in AfterParse, got '%s'", cfg.DB.DSN)
+	}
+	expected := []string{"db.Validate", "db.AfterParse", "app.Validate", "app.AfterParse"}
+	if !reflect.DeepEqual(cfg.calls, expected) {
+		t.Errorf("expected hooks to be called in order %v, got %v", expected, cfg.calls)
+	}
+}
+
+// TestParseEnvAfterParseErrors tests that failing validation skips AfterParse and that AfterParse errors are returned.
+func TestParseEnvAfterParseErrors(t *testing.T) {
+	cfg := &HookAppConfig{}
+	cfg.DB.calls = &cfg.calls
+
+	err := ParseEnvFromMap(cfg, map[string]string{"HOOK_DB_PORT": "0"})
+	if err == nil {
+		t.Fatal("expected a validation error, but got none")
+	}
+	if !reflect.DeepEqual(cfg.calls, []string{"db.Validate"}) {
+		t.Errorf("expected AfterParse not to run after failed validation, got %v", cfg.calls)
+	}
+
+	err = ParseEnvFromMap(&HookAppConfig{}, map[string]string{"HOOK_DB_PORT": "1", "HOOK_APP_MODE": "broken"})
+	if err == nil || !strings.Contains(err.Error(), "broken mode") {
+		t.Errorf("expected the AfterParse error to be returned, got: %v", err)
+	}
+}
+
+// TestParseEnvSliceIntegerWidths tests that slice elements use their type's bit width and report overflow by index.
+func TestParseEnvSliceIntegerWidths(t *testing.T) {
+	type WidthConfig struct {
+		Ints   []int    `env:"SLICE_WIDTH_INTS"`
+		Uint16 []uint16 `env:"SLICE_WIDTH_UINT16"`
+	}
+
+	cfg := &WidthConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"SLICE_WIDTH_INTS":   "1,9223372036854775807,-9223372036854775808",
+		"SLICE_WIDTH_UINT16": "65535",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if expected := []int{1, math.MaxInt, math.MinInt}; strconv.IntSize == 64 && !reflect.DeepEqual(cfg.Ints, expected) {
+		t.Errorf("expected Ints to be %v, got %v", expected, cfg.Ints)
+	}
+
+	tests := []struct {
+		name     string
+		cfg      any
+		value    string
+		expected string
+	}{
+		{"int8", &struct {
+			V []int8 `env:"SLICE_WIDTH_ERR"`
+		}{}, "1,128", "value 128 at index 1 of field V overflows int8 (range -128 to 127)"},
+		{"int16", &struct {
+			V []int16 `env:"SLICE_WIDTH_ERR"`
+		}{}, "-32769", "value -32769 at index 0 of field V overflows int16 (range -32768 to 32767)"},
+		{"int", &struct {
+			V []int `env:"SLICE_WIDTH_ERR"`
+		}{}, "0,0,9223372036854775808", "value 9223372036854775808 at index 2 of field V overflows int"},
+		{"uint8", &struct {
+			V []uint8 `env:"SLICE_WIDTH_ERR"`
+		}{}, "256", "value 256 at index 0 of field V overflows uint8 (max 255)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(tt.cfg, map[string]string{"SLICE_WIDTH_ERR": tt.value})
+			if err == nil {
+				t.Fatalf("expected an overflow error, but got none")
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected error to contain %q, got: %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+// TestParseGeneric tests the generic Parse and ParseWith helpers.
+func TestParseGeneric(t *testing.T) {
+	type GenericConfig struct {
+		Host string `env:"GENERIC_HOST"`
+		Port int    `env:"GENERIC_PORT,default=8080"`
+	}
+
+	_ = os.Setenv("GENERIC_HOST", "localhost")
+
+	cfg, err := Parse[GenericConfig]()
+	if err != nil {
+		t.Fatalf("Parse returned an error: %v", err)
+	}
+	if cfg.Host != "localhost" || cfg.Port != 8080 {
+		t.Errorf("expected config to be {localhost 8080}, got %+v", cfg)
+	}
+
+	cfg, err = ParseWith[GenericConfig](ParseEnvOptions{Lookup: mapLookup(map[string]string{"GENERIC_PORT": "9090"})})
+	if err != nil {
+		t.Fatalf("ParseWith returned an error: %v", err)
+	}
+	if cfg.Host != "" || cfg.Port != 9090 {
+		t.Errorf("expected config to be { 9090}, got %+v", cfg)
+	}
+
+	_, err = Parse[int]()
+	if err == nil {
+		t.Fatal("expected an error for a non-struct type, but got none")
+	}
+}
+
+// TestParseEnvNegate tests that a truthy negate key forces a bool field to false.
+func TestParseEnvNegate(t *testing.T) {
+	type NegateConfig struct {
+		EnableFoo bool `env:"NEGATE_ENABLE_FOO,negate=NEGATE_DISABLE_FOO"`
+		EnableBar bool `env:"NEGATE_ENABLE_BAR,default=true,negate=NEGATE_DISABLE_BAR"`
+	}
+
+	tests := []struct {
+		name        string
+		vars        map[string]string
+		expectedFoo bool
+		expectedBar bool
+	}{
+		{"negate unset", map[string]string{"NEGATE_ENABLE_FOO": "true"}, true, true},
+		{"negate set", map[string]string{"NEGATE_DISABLE_FOO": "true", "NEGATE_DISABLE_BAR": "1"}, false, false},
+		{"both set", map[string]string{"NEGATE_ENABLE_FOO": "true", "NEGATE_DISABLE_FOO": "true"}, false, true},
+		{"negate falsy", map[string]string{"NEGATE_ENABLE_FOO": "true", "NEGATE_DISABLE_FOO": "false"}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &NegateConfig{}
+			err := ParseEnvFromMap(cfg, tt.vars)
+			if err != nil {
+				t.Fatalf("ParseEnv returned an error: %v", err)
+			}
+			if cfg.EnableFoo != tt.expectedFoo {
+				t.Errorf("expected EnableFoo to be %v, got %v", tt.expectedFoo, cfg.EnableFoo)
+			}
+			if cfg.EnableBar != tt.expectedBar {
+				t.Errorf("expected EnableBar to be %v, got %v", tt.expectedBar, cfg.EnableBar)
+			}
+		})
+	}
+
+	err := ParseEnvFromMap(&NegateConfig{}, map[string]string{"NEGATE_DISABLE_FOO": "maybe"})
+	if err == nil {
+		t.Fatal("expected an error for an invalid negate value, but got none")
+	}
+
+	type InvalidNegateConfig struct {
+		Port int `env:"NEGATE_PORT,negate=NEGATE_NO_PORT"`
+	}
+	err = ParseEnvFromMap(&InvalidNegateConfig{}, nil)
+	if err == nil {
+		t.Fatal("expected an error for negate on a non-bool field, but got none")
+	}
+}
+
+type PrefixDBConfig struct {
+	_    struct{} `env:",prefix=DB_"`
+	Host string   `env:"HOST"`
+}
+
+type PrefixAppConfig struct {
+	_     struct{} `env:",prefix=PREFIX_APP_"`
+	Name  string   `env:"NAME,required"`
+	Port  int      `env:"PORT,default=8080"`
+	DB    PrefixDBConfig
+	Cache *PrefixDBConfig
+}
+
+// TestParseEnvStructPrefix tests that a struct-level prefix applies to all its fields and nested structs.
+func TestParseEnvStructPrefix(t *testing.T) {
+	vars := map[string]string{
+		"PREFIX_APP_NAME":    "api",
+		"PREFIX_APP_DB_HOST": "db.local",
+		"NAME":               "unprefixed",
+		"PORT":               "1",
+	}
+
+	cfg := &PrefixAppConfig{}
+	err := ParseEnvFromMap(cfg, vars)
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.Name != "api" {
+		t.Errorf("expected Name to be 'api', got '%s'", cfg.Name)
+	}
+	if cfg.Port != 8080 {
+		t.Errorf("expected Port to default to 8080, got %d", cfg.Port)
+	}
+	if cfg.DB.Host != "db.local" {
+		t.Errorf("expected DB.Host to be 'db.local', got '%s'", cfg.DB.Host)
+	}
+	if cfg.Cache == nil || cfg.Cache.Host != "db.local" {
+		t.Errorf("expected Cache to be allocated from the prefixed key, got %+v", cfg.Cache)
+	}
+
+	env, err := DumpEnv(cfg)
+	if err != nil {
+		t.Fatalf("DumpEnv returned an error: %v", err)
+	}
+	if env["PREFIX_APP_NAME"] != "api" || env["PREFIX_APP_DB_HOST"] != "db.local" {
+		t.Errorf("expected DumpEnv to write prefixed keys, got %v", env)
+	}
+
+	err = ParseEnvFromMap(&PrefixAppConfig{}, map[string]string{"NAME": "unprefixed"})
+	if err == nil {
+		t.Fatal("expected an error when only the unprefixed required variable is set, but got none")
+	}
+}
+
+// TestParseEnvRegexp tests parsing *regexp.Regexp and []*regexp.Regexp fields.
+func TestParseEnvRegexp(t *testing.T) {
+	type RegexpConfig struct {
+		Pattern  *regexp.Regexp   `env:"REGEXP_PATTERN"`
+		Patterns []*regexp.Regexp `env:"REGEXP_PATTERNS,delim=;"`
+		Unset    *regexp.Regexp   `env:"REGEXP_UNSET"`
+	}
+
+	cfg := &RegexpConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"REGEXP_PATTERN":  `^user-\d{2,4}$`,
+		"REGEXP_PATTERNS": `^a+$;b{1,2}`,
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.Pattern == nil || !cfg.Pattern.MatchString("user-123") || cfg.Pattern.MatchString("user-1") {
+		t.Errorf("expected Pattern to match user IDs, got %v", cfg.Pattern)
+	}
+	if len(cfg.Patterns) != 2 || !cfg.Patterns[0].MatchString("aaa") || cfg.Patterns[1].String() != "b{1,2}" {
+		t.Errorf("expected Patterns to be [^a+$ b{1,2}], got %v", cfg.Patterns)
+	}
+	if cfg.Unset != nil {
+		t.Errorf("expected Unset to stay nil, got %v", cfg.Unset)
+	}
+
+	env, err := DumpEnv(cfg)
+	if err != nil {
+		t.Fatalf("DumpEnv returned an error: %v", err)
+	}
+	if env["REGEXP_PATTERN"] != `^user-\d{2,4}$` || env["REGEXP_UNSET"] != "" {
+		t.Errorf("expected DumpEnv to write the patterns back, got %v", env)
+	}
+
+	err = ParseEnvFromMap(&RegexpConfig{}, map[string]string{"REGEXP_PATTERN": "(unclosed"})
+	if err == nil {
+		t.Fatal("expected an error for an invalid pattern, but got none")
+	}
+	err = ParseEnvFromMap(&RegexpConfig{}, map[string]string{"REGEXP_PATTERNS": "ok;[bad"})
+	if err == nil || !strings.Contains(err.Error(), "index 1") {
+		t.Errorf("expected an error naming index 1 for an invalid slice pattern, got: %v", err)
+	}
+}
+
+// TestParseEnvJSON tests populating a struct from a JSON variable with individual variables taking precedence.
+func TestParseEnvJSON(t *testing.T) {
+	type JSONDatabase struct {
+		Host string `env:"JSONCFG_DB_HOST" json:"host"`
+		Port int    `env:"JSONCFG_DB_PORT,default=5432" json:"port"`
+	}
+	type JSONAppConfig struct {
+		Name    string        `env:"JSONCFG_NAME,required" json:"name"`
+		Mode    string        `env:"JSONCFG_MODE,default=dev" json:"mode"`
+		Tags    []string      `env:"JSONCFG_TAGS" json:"tags"`
+		Timeout time.Duration `env:"JSONCFG_TIMEOUT,default=5s"`
+		DB      JSONDatabase  `json:"db"`
+	}
+
+	_ = os.Setenv("JSONCFG_APP", `{"name":"from-json","mode":"prod","tags":["a","b"],"db":{"host":"json.local","port":6543}}`)
+	_ = os.Setenv("JSONCFG_MODE", "staging")
+	_ = os.Unsetenv("JSONCFG_NAME")
+	_ = os.Unsetenv("JSONCFG_TAGS")
+	_ = os.Unsetenv("JSONCFG_TIMEOUT")
+	_ = os.Setenv("JSONCFG_DB_HOST", "env.local")
+	_ = os.Unsetenv("JSONCFG_DB_PORT")
+
+	cfg := &JSONAppConfig{}
+	err := ParseEnvJSON(cfg, "JSONCFG_APP")
+	if err != nil {
+		t.Fatalf("ParseEnvJSON returned an error: %v", err)
+	}
+
+	if cfg.Name != "from-json" {
+		t.Errorf("expected Name from JSON to satisfy required, got '%s'", cfg.Name)
+	}
+	if cfg.Mode != "staging" {
+		t.Errorf("expected JSONCFG_MODE to override the JSON value, got '%s'", cfg.Mode)
+	}
+	if !reflect.DeepEqual(cfg.Tags, []string{"a", "b"}) {
+		t.Errorf("expected Tags from JSON, got %v", cfg.Tags)
+	}
+	if cfg.Timeout != 5*time.Second {
+		t.Errorf("expected Timeout to default to 5s, got %v", cfg.Timeout)
+	}
+	if cfg.DB.Host != "env.local" || cfg.DB.Port != 6543 {
+		t.Errorf("expected DB to be {env.local 6543}, got %+v", cfg.DB)
+	}
+
+	_ = os.Setenv("JSONCFG_APP", `{"name":`)
+	err = ParseEnvJSON(&JSONAppConfig{}, "JSONCFG_APP")
+	if err == nil || !strings.HasPrefix(err.Error(), "xconf.ParseEnvJSON: invalid JSON in environment variable JSONCFG_APP") {
+		t.Fatalf("expected an invalid JSON error, got: %v", err)
+	}
+
+	err = ParseEnvJSON(JSONAppConfig{}, "JSONCFG_APP")
+	if err == nil || !strings.HasPrefix(err.Error(), "xconf.ParseEnvJSON: requires a non-nil pointer to a struct") {
+		t.Errorf("expected a pointer error without a repeated function name, got: %v", err)
+	}
+}
+
+// TestParseEnvSecretResolver tests resolving secret references through a resolver.
+func TestParseEnvSecretResolver(t *testing.T) {
+	type SecretConfig struct {
+		Password string `env:"SECRET_PASSWORD,secret"`
+		Port     int    `env:"SECRET_PORT,secret,default=vault://db#port"`
+		Plain    string `env:"SECRET_PLAIN"`
+	}
+
+	secrets := map[string]string{
+		"vault://db#password": "s3cr3t",
+		"vault://db#port":     "5432",
+	}
+	opts := ParseEnvOptions{
+		Lookup: mapLookup(map[string]string{
+			"SECRET_PASSWORD": "vault://db#password",
+			"SECRET_PLAIN":    "vault://db#password",
+		}),
+		SecretResolver: func(ref string) (string, error) {
+			val, ok := secrets[ref]
+			if !ok {
+				return "", fmt.Errorf("secret %s not found", ref)
+			}
+			return val, nil
+		},
+	}
+
+	cfg := &SecretConfig{}
+	err := ParseEnvWithOptions(cfg, opts)
+	if err != nil {
+		t.Fatalf("ParseEnvWithOptions returned an error: %v", err)
+	}
+
+	if cfg.Password != "s3cr3t" {
+		t.Errorf("expected Password to be resolved, got '%s'", cfg.Password)
+	}
+	if cfg.Port != 5432 {
+		t.Errorf("expected Port to be resolved from the default reference, got %d", cfg.Port)
+	}
+	if cfg.Plain != "vault://db#password" {
+		t.Errorf("expected Plain to keep the raw value, got '%s'", cfg.Plain)
+	}
+
+	opts.Lookup = mapLookup(map[string]string{"SECRET_PASSWORD": "vault://missing#key"})
+	err = ParseEnvWithOptions(&SecretConfig{}, opts)
+	if err == nil {
+		t.Fatal("expected an error for an unresolvable secret, but got none")
+	}
+	if !strings.Contains(err.Error(), "Password") || strings.Contains(err.Error(), "vault://missing#key") {
+		t.Errorf("expected error to name the field and redact the reference, got: %v", err)
+	}
+
+	err = ParseEnvFromMap(&SecretConfig{}, map[string]string{"SECRET_PASSWORD": "vault://db#password"})
+	if err == nil {
+		t.Fatal("expected an error for a secret field without a resolver, but got none")
+	}
+}
+
+// TestParseEnvSecretResolvedOnce tests that atomic and template secret fields call the resolver once,
+// with the reference only.
+func TestParseEnvSecretResolvedOnce(t *testing.T) {
+	type SecretOnceConfig struct {
+		Host  string       `env:"SECRET_ONCE_HOST"`
+		Limit atomic.Int64 `env:"SECRET_ONCE_LIMIT,secret"`
+		URL   string       `env:"SECRET_ONCE_URL,secret,template"`
+	}
+
+	secrets := map[string]string{
+		"vault://limit": "42",
+		"vault://url":   "http://{{.Host}}",
+	}
+	var refs []string
+	opts := ParseEnvOptions{
+		Lookup: mapLookup(map[string]string{
+			"SECRET_ONCE_HOST":  "db.local",
+			"SECRET_ONCE_LIMIT": "vault://limit",
+			"SECRET_ONCE_URL":   "vault://url",
+		}),
+		SecretResolver: func(ref string) (string, error) {
+			refs = append(refs, ref)
+			val, ok := secrets[ref]
+			if !ok {
+				return "", fmt.Errorf("secret %s not found", ref)
+			}
+			return val, nil
+		},
+	}
+
+	cfg := &SecretOnceConfig{}
+	if err := ParseEnvWithOptions(cfg, opts); err != nil {
+		t.Fatalf("ParseEnvWithOptions returned an error: %v", err)
+	}
+	if expected := []string{"vault://limit", "vault://url"}; !reflect.DeepEqual(refs, expected) {
+		t.Errorf("expected the resolver to be called with %v, got %v", expected, refs)
+	}
+	if cfg.Limit.Load() != 42 {
+		t.Errorf("expected Limit to be 42, got %d", cfg.Limit.Load())
+	}
+	if cfg.URL != "http://db.local" {
+		t.Errorf("expected URL to be rendered, got %q", cfg.URL)
+	}
+}
+
+// TestParseEnvIndexedSlice tests populating slices from KEY_0, KEY_1, ... variables.
+func TestParseEnvIndexedSlice(t *testing.T) {
+	type IndexedConfig struct {
+		Items    []string `env:"INDEXED_ITEM,indexed"`
+		Ports    []int    `env:"INDEXED_PORT,indexed,default=80"`
+		Required []string `env:"INDEXED_REQUIRED,indexed,required"`
+	}
+
+	cfg := &IndexedConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"INDEXED_ITEM_0":     "a,b",
+		"INDEXED_ITEM_1":     "c",
+		"INDEXED_ITEM_2":     "d",
+		"INDEXED_ITEM_4":     "after the gap",
+		"INDEXED_ITEM":       "ignored",
+		"INDEXED_REQUIRED_0": "x",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if expected := []string{"a,b", "c", "d"}; !reflect.DeepEqual(cfg.Items, expected) {
+		t.Errorf("expected Items to be %v, got %v", expected, cfg.Items)
+	}
+	if expected := []int{80}; !reflect.DeepEqual(cfg.Ports, expected) {
+		t.Errorf("expected Ports to fall back to the default %v, got %v", expected, cfg.Ports)
+	}
+
+	err = ParseEnvFromMap(&IndexedConfig{}, map[string]string{"INDEXED_REQUIRED_1": "y"})
+	if err == nil {
+		t.Fatal("expected an error when the sequence doesn't start at index 0, but got none")
+	}
+
+	err = ParseEnvFromMap(&IndexedConfig{}, map[string]string{"INDEXED_REQUIRED_0": "x", "INDEXED_PORT_0": "80", "INDEXED_PORT_1": "http"})
+	if err == nil {
+		t.Fatal("expected an error for an invalid indexed element, but got none")
+	}
+}
+
+// TestParseEnvIndexedSlicePipeline tests that expansion, secrets, transforms and oneof apply to every
+// element of an indexed slice.
+func TestParseEnvIndexedSlicePipeline(t *testing.T) {
+	type IndexedPipelineConfig struct {
+		Modes   []string `env:"INDEXED_PIPE_MODE,indexed,transform=lower,oneof=x y"`
+		Paths   []string `env:"INDEXED_PIPE_PATH,indexed"`
+		Secrets []string `env:"INDEXED_PIPE_SECRET,indexed,secret"`
+	}
+
+	var refs []string
+	cfg := &IndexedPipelineConfig{}
+	err := ParseEnvWithOptions(cfg, ParseEnvOptions{
+		Lookup: mapLookup(map[string]string{
+			"INDEXED_PIPE_MODE_0":   "X",
+			"INDEXED_PIPE_MODE_1":   "Y",
+			"INDEXED_PIPE_PATH_0":   "%HOME_DIR%/a",
+			"INDEXED_PIPE_PATH_1":   "b,c",
+			"INDEXED_PIPE_SECRET_0": "vault://a",
+			"INDEXED_PIPE_SECRET_1": "vault://b",
+			"HOME_DIR":              "/home",
+		}),
+		WindowsExpand: true,
+		SecretResolver: func(ref string) (string, error) {
+			refs = append(refs, ref)
+			return strings.TrimPrefix(ref, "vault://") + "-secret", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if expected := []string{"x", "y"}; !reflect.DeepEqual(cfg.Modes, expected) {
+		t.Errorf("expected Modes to be %v, got %v", expected, cfg.Modes)
+	}
+	if expected := []string{"/home/a", "b,c"}; !reflect.DeepEqual(cfg.Paths, expected) {
+		t.Errorf("expected Paths to be %v, got %v", expected, cfg.Paths)
+	}
+	if expected := []string{"a-secret", "b-secret"}; !reflect.DeepEqual(cfg.Secrets, expected) {
+		t.Errorf("expected Secrets to be %v, got %v", expected, cfg.Secrets)
+	}
+	if expected := []string{"vault://a", "vault://b"}; !reflect.DeepEqual(refs, expected) {
+		t.Errorf("expected the resolver to be called with %v, got %v", expected, refs)
+	}
+
+	err = ParseEnvFromMap(&IndexedPipelineConfig{}, map[string]string{"INDEXED_PIPE_MODE_0": "X", "INDEXED_PIPE_MODE_1": "Z"})
+	if err == nil || !strings.Contains(err.Error(), "value 'z' at index 1 of field Modes must be one of [x y]") {
+		t.Errorf("expected a oneof error for the transformed element, got: %v", err)
+	}
+}
+
+// TestParseEnvMergedSlice tests merging a comma list with KEY_0, KEY_1, ... overrides.
+func TestParseEnvMergedSlice(t *testing.T) {
+	type MergedConfig struct {
+		Hosts []string `env:"MERGED_HOST,merged"`
+		Ports []int    `env:"MERGED_PORT,merged,default=80"`
+	}
+
+	tests := []struct {
+		name  string
+		env   map[string]string
+		hosts []string
+		ports []int
+	}{
+		{"list only", map[string]string{"MERGED_HOST": "a,b,c"}, []string{"a", "b", "c"}, []int{80}},
+		{"override in range", map[string]string{"MERGED_HOST": "a,b,c", "MERGED_HOST_1": "x,y"}, []string{"a", "x,y", "c"}, []int{80}},
+		{"sparse overrides", map[string]string{"MERGED_HOST": "a,b,c", "MERGED_HOST_0": "x", "MERGED_HOST_2": "z"}, []string{"x", "b", "z"}, []int{80}},
+		{"extend past the list", map[string]string{"MERGED_HOST": "a", "MERGED_HOST_1": "b", "MERGED_HOST_2": "c"}, []string{"a", "b", "c"}, []int{80}},
+		{"overrides only", map[string]string{"MERGED_PORT_0": "8080", "MERGED_PORT_1": "8081"}, nil, []int{8080, 8081}},
+		{"override typed element", map[string]string{"MERGED_PORT": "1,2", "MERGED_PORT_1": "3"}, nil, []int{1, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &MergedConfig{}
+			if err := ParseEnvFromMap(cfg, tt.env); err != nil {
+				t.Fatalf("ParseEnv returned an error: %v", err)
+			}
+			if !reflect.DeepEqual(cfg.Hosts, tt.hosts) || !reflect.DeepEqual(cfg.Ports, tt.ports) {
+				t.Errorf("expected %v and %v, got %v and %v", tt.hosts, tt.ports, cfg.Hosts, cfg.Ports)
+			}
+		})
+	}
+
+	err := ParseEnvFromMap(&MergedConfig{}, map[string]string{"MERGED_PORT": "1,2", "MERGED_PORT_0": "http"})
+	if err == nil {
+		t.Fatal("expected an error for an invalid override, but got none")
+	}
+
+	err = ParseEnvFromMap(&MergedConfig{}, map[string]string{"MERGED_HOST": "a,b", "MERGED_HOST_5": "x"})
+	if err == nil || !strings.Contains(err.Error(), "field Hosts: override MERGED_HOST_5 follows a gap, MERGED_HOST_2 is not set") {
+		t.Errorf("expected an error for an override past a gap, got: %v", err)
+	}
+
+	// The list and its overrides are transformed element by element
+	type TransformConfig struct {
+		Modes []string `env:"MERGED_MODE,merged,transform=trim+lower,oneof=a b c"`
+	}
+	transformed := &TransformConfig{}
+	err = ParseEnvFromMap(transformed, map[string]string{"MERGED_MODE": "A, B", "MERGED_MODE_1": " C ", "MERGED_MODE_2": "A"})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if expected := []string{"a", "c", "a"}; !reflect.DeepEqual(transformed.Modes, expected) {
+		t.Errorf("expected Modes to be %v, got %v", expected, transformed.Modes)
+	}
+
+	type IndexedConfig struct {
+		Hosts []string `env:"MERGED_BOTH,merged,indexed"`
+	}
+	err = ParseEnvFromMap(&IndexedConfig{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "merged option for field Hosts requires a slice without the indexed option") {
+		t.Errorf("expected an error for merged with indexed, got: %v", err)
+	}
+}
+
+// TestParseEnvURLValues tests parsing query strings into url.Values fields.
+func TestParseEnvURLValues(t *testing.T) {
+	type QueryConfig struct {
+		Params url.Values `env:"QUERY_PARAMS"`
+		Extra  url.Values `env:"QUERY_EXTRA,default=debug=1"`
+	}
+
+	cfg := &QueryConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{"QUERY_PARAMS": "tag=a&tag=b&q=hello+world&empty="})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	expected := url.Values{"tag": {"a", "b"}, "q": {"hello world"}, "empty": {""}}
+	if !reflect.DeepEqual(cfg.Params, expected) {
+		t.Errorf("expected Params to be %v, got %v", expected, cfg.Params)
+	}
+	if cfg.Extra.Get("debug") != "1" {
+		t.Errorf("expected Extra to hold debug=1, got %v", cfg.Extra)
+	}
+
+	env, err := DumpEnv(cfg)
+	if err != nil {
+		t.Fatalf("DumpEnv returned an error: %v", err)
+	}
+	if env["QUERY_PARAMS"] != "empty=&q=hello+world&tag=a&tag=b" {
+		t.Errorf("expected Params to be dumped as a query string, got %q", env["QUERY_PARAMS"])
+	}
+
+	err = ParseEnvFromMap(&QueryConfig{}, map[string]string{"QUERY_PARAMS": "a=%zz"})
+	if err == nil || !strings.Contains(err.Error(), "invalid query string for field Params") {
+		t.Errorf("expected an invalid query string error, got: %v", err)
+	}
+}
+
+// TestParseEnvHardwareAddr tests parsing MAC addresses into net.HardwareAddr fields.
+func TestParseEnvHardwareAddr(t *testing.T) {
+	type DeviceConfig struct {
+		MAC     net.HardwareAddr   `env:"DEVICE_MAC"`
+		Allowed []net.HardwareAddr `env:"DEVICE_ALLOWED"`
+	}
+
+	cfg := &DeviceConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"DEVICE_MAC":     "00:1a:2b:3c:4d:5e",
+		"DEVICE_ALLOWED": "aa:bb:cc:dd:ee:ff,00-11-22-33-44-55",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.MAC.String() != "00:1a:2b:3c:4d:5e" {
+		t.Errorf("expected MAC to be 00:1a:2b:3c:4d:5e, got %s", cfg.MAC)
+	}
+	if len(cfg.Allowed) != 2 || cfg.Allowed[1].String() != "00:11:22:33:44:55" {
+		t.Errorf("expected Allowed to hold two addresses, got %v", cfg.Allowed)
+	}
+
+	env, err := DumpEnv(cfg)
+	if err != nil {
+		t.Fatalf("DumpEnv returned an error: %v", err)
+	}
+	if env["DEVICE_MAC"] != "00:1a:2b:3c:4d:5e" || env["DEVICE_ALLOWED"] != "aa:bb:cc:dd:ee:ff,00:11:22:33:44:55" {
+		t.Errorf("expected DumpEnv to write MAC addresses, got %v", env)
+	}
+
+	err = ParseEnvFromMap(&DeviceConfig{}, map[string]string{"DEVICE_MAC": "not-a-mac"})
+	if err == nil || !strings.Contains(err.Error(), "MAC") {
+		t.Errorf("expected an error naming field MAC, got: %v", err)
+	}
+	err = ParseEnvFromMap(&DeviceConfig{}, map[string]string{"DEVICE_ALLOWED": "aa:bb:cc:dd:ee:ff,zz"})
+	if err == nil || !strings.Contains(err.Error(), "index 1 of field Allowed") {
+		t.Errorf("expected an error naming index 1 of field Allowed, got: %v", err)
+	}
+}
+
+// TestParseEnvTrimQuotes tests stripping surrounding quotes from values with TrimQuotes.
+func TestParseEnvTrimQuotes(t *testing.T) {
+	type QuotedConfig struct {
+		Single   string   `env:"QUOTED_SINGLE"`
+		Double   int      `env:"QUOTED_DOUBLE"`
+		Unquoted string   `env:"QUOTED_UNQUOTED"`
+		Mixed    string   `env:"QUOTED_MIXED"`
+		Hosts    []string `env:"QUOTED_HOSTS"`
+		Lone     string   `env:"QUOTED_LONE"`
+	}
+
+	vars := map[string]string{
+		"QUOTED_SINGLE":   "'bar'",
+		"QUOTED_DOUBLE":   `"42"`,
+		"QUOTED_UNQUOTED": "plain",
+		"QUOTED_MIXED":    `'bar"`,
+		"QUOTED_HOSTS":    `"a,b"`,
+		"QUOTED_LONE":     `"`,
+	}
+
+	cfg := &QuotedConfig{}
+	err := ParseEnvWithOptions(cfg, ParseEnvOptions{Lookup: mapLookup(vars), TrimQuotes: true})
+	if err != nil {
+		t.Fatalf("ParseEnvWithOptions returned an error: %v", err)
+	}
+
+	if cfg.Single != "bar" {
+		t.Errorf("expected Single to be 'bar', got '%s'", cfg.Single)
+	}
+	if cfg.Double != 42 {
+		t.Errorf("expected Double to be 42, got %d", cfg.Double)
+	}
+	if cfg.Unquoted != "plain" {
+		t.Errorf("expected Unquoted to be 'plain', got '%s'", cfg.Unquoted)
+	}
+	if cfg.Mixed != `'bar"` {
+		t.Errorf("expected Mixed quotes to be left alone, got '%s'", cfg.Mixed)
+	}
+	if !reflect.DeepEqual(cfg.Hosts, []string{"a", "b"}) {
+		t.Errorf("expected Hosts to be unquoted before splitting, got %v", cfg.Hosts)
+	}
+	if cfg.Lone != `"` {
+		t.Errorf("expected a lone quote to be left alone, got '%s'", cfg.Lone)
+	}
+
+	cfg = &QuotedConfig{}
+	err = ParseEnvFromMap(cfg, map[string]string{"QUOTED_SINGLE": "'bar'"})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Single != "'bar'" {
+		t.Errorf("expected quotes to be kept without TrimQuotes, got '%s'", cfg.Single)
+	}
+}
+
+// TestParseEnvUintptr tests parsing uintptr and []uintptr fields with the pointer width.
+func TestParseEnvUintptr(t *testing.T) {
+	type UintptrConfig struct {
+		Addr  uintptr   `env:"UINTPTR_ADDR"`
+		Addrs []uintptr `env:"UINTPTR_ADDRS"`
+		Hex   uintptr   `env:"UINTPTR_HEX,parser=hexnum"`
+	}
+
+	cfg := &UintptrConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"UINTPTR_ADDR":  "4096",
+		"UINTPTR_ADDRS": "1,2",
+		"UINTPTR_HEX":   "ff00",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.Addr != 4096 {
+		t.Errorf("expected Addr to be 4096, got %d", cfg.Addr)
+	}
+	if !reflect.DeepEqual(cfg.Addrs, []uintptr{1, 2}) {
+		t.Errorf("expected Addrs to be [1 2], got %v", cfg.Addrs)
+	}
+	if cfg.Hex != 0xff00 {
+		t.Errorf("expected Hex to be 0xff00, got %#x", cfg.Hex)
+	}
+
+	tooBig := "18446744073709551616"
+	if strconv.IntSize == 32 {
+		tooBig = "4294967296"
+	}
+	err = ParseEnvFromMap(&UintptrConfig{}, map[string]string{"UINTPTR_ADDR": tooBig})
+	if err == nil || !strings.Contains(err.Error(), "overflows uintptr") {
+		t.Errorf("expected an overflow error, got: %v", err)
+	}
+	err = ParseEnvFromMap(&UintptrConfig{}, map[string]string{"UINTPTR_ADDRS": "1,-1"})
+	if err == nil {
+		t.Fatal("expected an error for a negative uintptr element, but got none")
+	}
+}
+
+// TestParseEnvMapWithSliceDelims tests that per-field slice and map separators coexist in one config.
+func TestParseEnvMapWithSliceDelims(t *testing.T) {
+	type MapConfig struct {
+		Tags     []string                 `env:"MAPSEP_TAGS,delim=|"`
+		Limits   map[string]int           `env:"MAPSEP_LIMITS,mapsep=;,kvsep=:"`
+		Labels   map[string]string        `env:"MAPSEP_LABELS,mapsep=,,kvsep=="`
+		Matrix   [][]int                  `env:"MAPSEP_MATRIX,delim=;,innerdelim=,"`
+		Timeouts map[string]time.Duration `env:"MAPSEP_TIMEOUTS"`
+	}
+
+	cfg := &MapConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"MAPSEP_TAGS":     "a,b|c",
+		"MAPSEP_LIMITS":   "cpu:2;mem:512",
+		"MAPSEP_LABELS":   "env=prod,team=core",
+		"MAPSEP_MATRIX":   "1,2;3",
+		"MAPSEP_TIMEOUTS": "read:5s,write:1m",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg.Tags, []string{"a,b", "c"}) {
+		t.Errorf("expected Tags to be [a,b c], got %v", cfg.Tags)
+	}
+	if !reflect.DeepEqual(cfg.Limits, map[string]int{"cpu": 2, "mem": 512}) {
+		t.Errorf("expected Limits to be map[cpu:2 mem:512], got %v", cfg.Limits)
+	}
+	if !reflect.DeepEqual(cfg.Labels, map[string]string{"env": "prod", "team": "core"}) {
+		t.Errorf("expected Labels to be map[env:prod team:core], got %v", cfg.Labels)
+	}
+	if !reflect.DeepEqual(cfg.Matrix, [][]int{{1, 2}, {3}}) {
+		t.Errorf("expected Matrix to be [[1 2] [3]], got %v", cfg.Matrix)
+	}
+	if !reflect.DeepEqual(cfg.Timeouts, map[string]time.Duration{"read": 5 * time.Second, "write": time.Minute}) {
+		t.Errorf("expected Timeouts to be map[read:5s write:1m0s], got %v", cfg.Timeouts)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"missing kvsep", map[string]string{"MAPSEP_LIMITS": "cpu:2;mem"}, `invalid map entry "mem" at index 1`},
+		{"invalid value", map[string]string{"MAPSEP_LIMITS": "cpu:x"}, `invalid map value "x" for key "cpu"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&MapConfig{}, tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+
+	env, err := DumpEnv(cfg)
+	if err != nil {
+		t.Fatalf("DumpEnv returned an error: %v", err)
+	}
+	if env["MAPSEP_LIMITS"] != "cpu:2;mem:512" {
+		t.Errorf("expected MAPSEP_LIMITS to be dumped as cpu:2;mem:512, got %q", env["MAPSEP_LIMITS"])
+	}
+}
+
+// Level implements both StringParser and encoding.TextUnmarshaler
+type Level struct {
+	Name   string
+	Source string
+}
+
+var errUnknownLevel = errors.New("unknown level")
+
+func (l *Level) ParseString(s string) error {
+	if s != "debug" && s != "info" {
+		return errUnknownLevel
+	}
+	l.Name, l.Source = s, "ParseString"
+	return nil
+}
+
+func (l *Level) UnmarshalText(text []byte) error {
+	l.Name, l.Source = string(text), "UnmarshalText"
+	return nil
+}
+
+// TestParseEnvStringParser tests that ParseString is preferred over the unmarshalers for scalars and slice elements.
+func TestParseEnvStringParser(t *testing.T) {
+	type ParserConfig struct {
+		Level  Level   `env:"STRPARSER_LEVEL"`
+		Levels []Level `env:"STRPARSER_LEVELS"`
+	}
+
+	cfg := &ParserConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"STRPARSER_LEVEL":  "debug",
+		"STRPARSER_LEVELS": "info,debug",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.Level != (Level{"debug", "ParseString"}) {
+		t.Errorf("expected Level to be parsed by ParseString, got %+v", cfg.Level)
+	}
+	if !reflect.DeepEqual(cfg.Levels, []Level{{"info", "ParseString"}, {"debug", "ParseString"}}) {
+		t.Errorf("expected Levels to be parsed by ParseString, got %+v", cfg.Levels)
+	}
+
+	err = ParseEnvFromMap(&ParserConfig{}, map[string]string{"STRPARSER_LEVEL": "trace"})
+	if !errors.Is(err, errUnknownLevel) {
+		t.Errorf("expected error to wrap errUnknownLevel, got: %v", err)
+	}
+	err = ParseEnvFromMap(&ParserConfig{}, map[string]string{"STRPARSER_LEVELS": "info,trace"})
+	if !errors.Is(err, errUnknownLevel) || !strings.Contains(err.Error(), "element 1") {
+		t.Errorf("expected error to wrap errUnknownLevel and name element 1, got: %v", err)
+	}
+}
+
+// TestParseEnvFieldFilter tests that only fields whose env key passes the filter are parsed.
+func TestParseEnvFieldFilter(t *testing.T) {
+	type RuntimeConfig struct {
+		_     struct{} `env:",prefix=RUNTIME_"`
+		Level string   `env:"LEVEL"`
+	}
+	type FilterConfig struct {
+		Host    string `env:"FILTER_HOST,required"`
+		Port    int    `env:"FILTER_PORT,default=8080"`
+		Runtime RuntimeConfig
+		Workers int `env:"RUNTIME_WORKERS"`
+	}
+
+	cfg := &FilterConfig{Host: "kept", Port: 9090, Runtime: RuntimeConfig{Level: "info"}}
+	err := ParseEnvWithOptions(cfg, ParseEnvOptions{
+		Lookup: mapLookup(map[string]string{
+			"FILTER_HOST":     "changed",
+			"RUNTIME_LEVEL":   "debug",
+			"RUNTIME_WORKERS": "4",
+		}),
+		FieldFilter: func(key string) bool {
+			return strings.HasPrefix(key, "RUNTIME_")
+		},
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.Host != "kept" {
+		t.Errorf("expected Host to keep its value, got %q", cfg.Host)
+	}
+	if cfg.Port != 9090 {
+		t.Errorf("expected Port to keep its value instead of the default, got %d", cfg.Port)
+	}
+	if cfg.Runtime.Level != "debug" {
+		t.Errorf("expected Runtime.Level to be debug, got %q", cfg.Runtime.Level)
+	}
+	if cfg.Workers != 4 {
+		t.Errorf("expected Workers to be 4, got %d", cfg.Workers)
+	}
+
+	// Required fields that are filtered out are not enforced
+	err = ParseEnvWithOptions(&FilterConfig{}, ParseEnvOptions{
+		Lookup:      mapLookup(map[string]string{}),
+		FieldFilter: func(key string) bool { return key != "FILTER_HOST" },
+	})
+	if err != nil {
+		t.Errorf("expected no error for a filtered out required field, got: %v", err)
+	}
+}
+
+// TestParseEnvSet tests parsing lists into map[T]struct{} sets.
+func TestParseEnvSet(t *testing.T) {
+	type SetConfig struct {
+		Regions map[string]struct{} `env:"SET_REGIONS,oneof=eu us asia"`
+		Ports   map[int]struct{}    `env:"SET_PORTS,delim=;"`
+	}
+
+	cfg := &SetConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"SET_REGIONS": "eu,us,eu",
+		"SET_PORTS":   "80;443",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg.Regions, map[string]struct{}{"eu": {}, "us": {}}) {
+		t.Errorf("expected Regions to be {eu us}, got %v", cfg.Regions)
+	}
+	if !reflect.DeepEqual(cfg.Ports, map[int]struct{}{80: {}, 443: {}}) {
+		t.Errorf("expected Ports to be {80 443}, got %v", cfg.Ports)
+	}
+
+	err = ParseEnvFromMap(&SetConfig{}, map[string]string{"SET_REGIONS": "eu,mars"})
+	if err == nil || !strings.Contains(err.Error(), "'mars'") {
+		t.Errorf("expected an error rejecting mars, got: %v", err)
+	}
+	err = ParseEnvFromMap(&SetConfig{}, map[string]string{"SET_PORTS": "80;http"})
+	if err == nil || !strings.Contains(err.Error(), `invalid set element "http"`) {
+		t.Errorf("expected an error for the invalid port, got: %v", err)
+	}
+
+	env, err := DumpEnv(cfg)
+	if err != nil {
+		t.Fatalf("DumpEnv returned an error: %v", err)
+	}
+	if env["SET_REGIONS"] != "eu,us" || env["SET_PORTS"] != "443;80" {
+		t.Errorf("expected sets to be dumped as sorted lists, got %q and %q", env["SET_REGIONS"], env["SET_PORTS"])
+	}
+}
+
+// YesNo is a bool parsed from "yes" and "no" by UnmarshalText
+type YesNo bool
+
+func (y *YesNo) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "yes":
+		*y = true
+	case "no":
+		*y = false
+	default:
+		return fmt.Errorf("invalid yes/no value %q", string(text))
+	}
+	return nil
+}
+
+// Ratio is a float parsed from percentages like "50%" by UnmarshalText
+type Ratio float64
+
+func (r *Ratio) UnmarshalText(text []byte) error {
+	f, err := strconv.ParseFloat(strings.TrimSuffix(string(text), "%"), 64)
+	if err != nil {
+		return err
+	}
+	*r = Ratio(f / 100)
+	return nil
+}
+
+// Mask is an unsigned integer parsed from binary digits by UnmarshalText
+type Mask uint8
+
+func (m *Mask) UnmarshalText(text []byte) error {
+	u, err := strconv.ParseUint(string(text), 2, 8)
+	if err != nil {
+		return err
+	}
+	*m = Mask(u)
+	return nil
+}
+
+// TestParseEnvSliceElementUnmarshalers tests that bool, float and uint slice elements try UnmarshalText first.
+func TestParseEnvSliceElementUnmarshalers(t *testing.T) {
+	type ElementConfig struct {
+		Flags  []YesNo `env:"ELEMUNMARSHAL_FLAGS"`
+		Ratios []Ratio `env:"ELEMUNMARSHAL_RATIOS"`
+		Masks  []Mask  `env:"ELEMUNMARSHAL_MASKS"`
+	}
+
+	cfg := &ElementConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"ELEMUNMARSHAL_FLAGS":  "yes,no,true",
+		"ELEMUNMARSHAL_RATIOS": "50%,25%",
+		"ELEMUNMARSHAL_MASKS":  "101,11",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg.Flags, []YesNo{true, false, true}) {
+		t.Errorf("expected Flags to be [true false true], got %v", cfg.Flags)
+	}
+	if !reflect.DeepEqual(cfg.Ratios, []Ratio{0.5, 0.25}) {
+		t.Errorf("expected Ratios to be [0.5 0.25], got %v", cfg.Ratios)
+	}
+	if !reflect.DeepEqual(cfg.Masks, []Mask{5, 3}) {
+		t.Errorf("expected Masks to be [5 3], got %v", cfg.Masks)
+	}
+
+	// With the fallback disabled elements are parsed by their kind
+	cfg = &ElementConfig{}
+	err = ParseEnvWithOptions(cfg, ParseEnvOptions{
+		Lookup:                   mapLookup(map[string]string{"ELEMUNMARSHAL_MASKS": "101,11"}),
+		DisableUnmarshalFallback: true,
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.Masks, []Mask{101, 11}) {
+		t.Errorf("expected Masks to be [101 11], got %v", cfg.Masks)
+	}
+}
+
+// TestParseEnvAutoJSON tests decoding JSON values into fields without built-in parsing with AutoJSON.
+func TestParseEnvAutoJSON(t *testing.T) {
+	type Endpoint struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	}
+	type AutoJSONConfig struct {
+		Primary   Endpoint          `env:"AUTOJSON_PRIMARY"`
+		Fallbacks []Endpoint        `env:"AUTOJSON_FALLBACKS"`
+		Weights   map[string][]int  `env:"AUTOJSON_WEIGHTS"`
+		Names     []string          `env:"AUTOJSON_NAMES"`
+		Labels    map[string]string `env:"AUTOJSON_LABELS"`
+	}
+
+	env := map[string]string{
+		"AUTOJSON_PRIMARY":   `{"host":"db","port":5432}`,
+		"AUTOJSON_FALLBACKS": `[{"host":"a","port":1},{"host":"b","port":2}]`,
+		"AUTOJSON_WEIGHTS":   `{"x":[1,2]}`,
+		"AUTOJSON_NAMES":     `[a,b]`,
+		"AUTOJSON_LABELS":    "env:prod",
+	}
+	cfg := &AutoJSONConfig{}
+	err := ParseEnvWithOptions(cfg, ParseEnvOptions{Lookup: mapLookup(env), AutoJSON: true})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.Primary != (Endpoint{"db", 5432}) {
+		t.Errorf("expected Primary to be {db 5432}, got %+v", cfg.Primary)
+	}
+	if !reflect.DeepEqual(cfg.Fallbacks, []Endpoint{{"a", 1}, {"b", 2}}) {
+		t.Errorf("expected Fallbacks to be [{a 1} {b 2}], got %+v", cfg.Fallbacks)
+	}
+	if !reflect.DeepEqual(cfg.Weights, map[string][]int{"x": {1, 2}}) {
+		t.Errorf("expected Weights to be map[x:[1 2]], got %v", cfg.Weights)
+	}
+	// Types with built-in parsing are not decoded as JSON
+	if !reflect.DeepEqual(cfg.Names, []string{"[a", "b]"}) {
+		t.Errorf("expected Names to be split by commas, got %q", cfg.Names)
+	}
+	if !reflect.DeepEqual(cfg.Labels, map[string]string{"env": "prod"}) {
+		t.Errorf("expected Labels to be map[env:prod], got %v", cfg.Labels)
+	}
+
+	err = ParseEnvWithOptions(&AutoJSONConfig{}, ParseEnvOptions{
+		Lookup:   mapLookup(map[string]string{"AUTOJSON_PRIMARY": `{"host":`}),
+		AutoJSON: true,
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid JSON for field Primary") {
+		t.Errorf("expected an invalid JSON error, got: %v", err)
+	}
+
+	// Without AutoJSON the struct is unsupported
+	err = ParseEnvFromMap(&AutoJSONConfig{}, map[string]string{"AUTOJSON_PRIMARY": `{"host":"db"}`})
+	if err == nil {
+		t.Error("expected an error without AutoJSON, but got none")
+	}
+}
+
+// TestParseEnvAppendSlice tests appending parsed slice values to the existing contents with the append option.
+func TestParseEnvAppendSlice(t *testing.T) {
+	type AppendConfig struct {
+		Hosts    []string `env:"APPEND_HOSTS,append"`
+		Ports    []int    `env:"APPEND_PORTS,append,unique"`
+		Sizes    []int64  `env:"APPEND_SIZES,append,parser=bytesize"`
+		Replaced []string `env:"APPEND_REPLACED"`
+	}
+
+	defaults := []string{"localhost", "db"}
+	cfg := &AppendConfig{
+		Hosts:    defaults[:1],
+		Ports:    []int{80},
+		Sizes:    []int64{1},
+		Replaced: []string{"old"},
+	}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"APPEND_HOSTS":    "cache",
+		"APPEND_PORTS":    "443,80",
+		"APPEND_SIZES":    "1KB",
+		"APPEND_REPLACED": "new",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg.Hosts, []string{"localhost", "cache"}) {
+		t.Errorf("expected Hosts to be [localhost cache], got %v", cfg.Hosts)
+	}
+	if defaults[1] != "db" {
+		t.Errorf("expected the backing array of the existing slice to be left alone, got %v", defaults)
+	}
+	if !reflect.DeepEqual(cfg.Ports, []int{80, 443}) {
+		t.Errorf("expected Ports to be [80 443], got %v", cfg.Ports)
+	}
+	if !reflect.DeepEqual(cfg.Sizes, []int64{1, 1000}) {
+		t.Errorf("expected Sizes to be [1 1000], got %v", cfg.Sizes)
+	}
+	if !reflect.DeepEqual(cfg.Replaced, []string{"new"}) {
+		t.Errorf("expected Replaced to be replaced by [new], got %v", cfg.Replaced)
+	}
+
+	type InvalidAppendConfig struct {
+		Host string `env:"APPEND_HOST,append"`
+	}
+	if err := ParseEnvFromMap(&InvalidAppendConfig{}, nil); err == nil {
+		t.Error("expected an error for append on a non-slice field, but got none")
+	}
+}
+
+// TestParseEnvIndexedStructSlice tests parsing struct slices from KEY_<i>_ prefixed variables with the indexed option.
+func TestParseEnvIndexedStructSlice(t *testing.T) {
+	type ReplicaConfig struct {
+		Host string `env:"HOST,required"`
+		Port int    `env:"PORT,default=5432"`
+	}
+	type ReplicasConfig struct {
+		Replicas []ReplicaConfig `env:"REPLICA,indexed"`
+	}
+
+	cfg := &ReplicasConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"REPLICA_0_HOST": "db0",
+		"REPLICA_0_PORT": "5433",
+		"REPLICA_1_HOST": "db1",
+		"REPLICA_3_HOST": "unreachable",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	expected := []ReplicaConfig{{"db0", 5433}, {"db1", 5432}}
+	if !reflect.DeepEqual(cfg.Replicas, expected) {
+		t.Errorf("expected Replicas to be %v, got %v", expected, cfg.Replicas)
+	}
+
+	cfg = &ReplicasConfig{}
+	if err := ParseEnvFromMap(cfg, map[string]string{}); err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Replicas != nil {
+		t.Errorf("expected no Replicas, got %v", cfg.Replicas)
+	}
+
+	// Required fields are enforced for every discovered element
+	err = ParseEnvFromMap(&ReplicasConfig{}, map[string]string{"REPLICA_0_PORT": "1"})
+	if err == nil || !strings.Contains(err.Error(), "Host") {
+		t.Errorf("expected an error for the missing REPLICA_0_HOST, got: %v", err)
+	}
+
+	type RequiredReplicasConfig struct {
+		Replicas []ReplicaConfig `env:"REQ_REPLICA,indexed,required"`
+	}
+	err = ParseEnvFromMap(&RequiredReplicasConfig{}, map[string]string{})
+	if err == nil || !strings.Contains(err.Error(), "required slice field Replicas is empty") {
+		t.Errorf("expected a required error, got: %v", err)
+	}
+}
+
+// TestParseEnvErrorFieldPath tests that errors name the dotted path of deeply nested fields.
+func TestParseEnvErrorFieldPath(t *testing.T) {
+	type PathLeaf struct {
+		Port int `env:"PATH_PORT"`
+	}
+	type PathMiddle struct {
+		Leaf PathLeaf
+	}
+	type PathReplica struct {
+		Timeout time.Duration `env:"TIMEOUT"`
+	}
+	type PathConfig struct {
+		Middle   *PathMiddle
+		Replicas []PathReplica `env:"PATH_REPLICA,indexed"`
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"nested struct", map[string]string{"PATH_PORT": "http"}, "Middle.Leaf.Port"},
+		{"indexed struct slice", map[string]string{"PATH_REPLICA_0_TIMEOUT": "1s", "PATH_REPLICA_1_TIMEOUT": "soon"}, "Replicas[1].Timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&PathConfig{}, tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error naming %s, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// TestParseEnvNewlineDelim tests newline separated slices, including CRLF line endings.
+func TestParseEnvNewlineDelim(t *testing.T) {
+	type NewlineConfig struct {
+		Hosts []string `env:"NEWLINE_HOSTS,delim=\n"`
+		Ports []int    `env:"NEWLINE_PORTS,delim=\n"`
+	}
+
+	tests := []struct {
+		name  string
+		hosts string
+		ports string
+	}{
+		{"LF", "a.example\nb.example\n", "80\n443"},
+		{"CRLF", "a.example\r\nb.example\r\n", "80\r\n443\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &NewlineConfig{}
+			err := ParseEnvFromMap(cfg, map[string]string{"NEWLINE_HOSTS": tt.hosts, "NEWLINE_PORTS": tt.ports})
+			if err != nil {
+				t.Fatalf("ParseEnv returned an error: %v", err)
+			}
+			if !reflect.DeepEqual(cfg.Hosts, []string{"a.example", "b.example"}) {
+				t.Errorf("expected Hosts to be [a.example b.example], got %q", cfg.Hosts)
+			}
+			if !reflect.DeepEqual(cfg.Ports, []int{80, 443}) {
+				t.Errorf("expected Ports to be [80 443], got %v", cfg.Ports)
+			}
+		})
+	}
+}
+
+// TestParseEnvRegexSplit tests splitting slices by the regular expression of the regexsplit option.
+func TestParseEnvRegexSplit(t *testing.T) {
+	type RegexSplitConfig struct {
+		Words []string `env:"REGEXSPLIT_WORDS,regexsplit=\\s+"`
+		Ports []int    `env:"REGEXSPLIT_PORTS,regexsplit=[\\s;]+"`
+		Modes []string `env:"REGEXSPLIT_MODES,regexsplit=\\s+,oneof=read write"`
+	}
+
+	cfg := &RegexSplitConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"REGEXSPLIT_WORDS": "  alpha beta\tgamma\n delta ",
+		"REGEXSPLIT_PORTS": "80 ;443;;  8080",
+		"REGEXSPLIT_MODES": "read  write",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if expected := []string{"alpha", "beta", "gamma", "delta"}; !reflect.DeepEqual(cfg.Words, expected) {
+		t.Errorf("expected Words to be %q, got %q", expected, cfg.Words)
+	}
+	if expected := []int{80, 443, 8080}; !reflect.DeepEqual(cfg.Ports, expected) {
+		t.Errorf("expected Ports to be %v, got %v", expected, cfg.Ports)
+	}
+	if expected := []string{"read", "write"}; !reflect.DeepEqual(cfg.Modes, expected) {
+		t.Errorf("expected Modes to be %q, got %q", expected, cfg.Modes)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"invalid element", map[string]string{"REGEXSPLIT_PORTS": "80 http"}, "REGEXSPLIT_PORTS"},
+		{"invalid oneof element", map[string]string{"REGEXSPLIT_MODES": "read admin"}, "value 'admin' at index 1 of field Modes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&RegexSplitConfig{}, tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+
+	type InvalidConfig struct {
+		Words []string `env:"REGEXSPLIT_INVALID,regexsplit=[a-"`
+	}
+	if err := ParseEnvFromMap(&InvalidConfig{}, nil); err == nil || !strings.Contains(err.Error(), "invalid regexsplit pattern for field Words") {
+		t.Errorf("expected an invalid pattern error, got: %v", err)
+	}
+
+	type ScalarConfig struct {
+		Word string `env:"REGEXSPLIT_SCALAR,regexsplit=\\s+"`
+	}
+	if err := ParseEnvFromMap(&ScalarConfig{}, nil); err == nil || !strings.Contains(err.Error(), "regexsplit option for field Word requires a slice") {
+		t.Errorf("expected an error for regexsplit on a string field, got: %v", err)
+	}
+}
+
+// TestParseEnvPresence tests bool fields with the presence option, which are true whenever the variable is set.
+func TestParseEnvPresence(t *testing.T) {
+	type PresenceConfig struct {
+		Debug bool `env:"PRESENCE_DEBUG,presence"`
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want bool
+	}{
+		{"present empty", map[string]string{"PRESENCE_DEBUG": ""}, true},
+		{"present value", map[string]string{"PRESENCE_DEBUG": "0"}, true},
+		{"unset", map[string]string{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &PresenceConfig{Debug: !tt.want}
+			if err := ParseEnvFromMap(cfg, tt.env); err != nil {
+				t.Fatalf("ParseEnv returned an error: %v", err)
+			}
+			if cfg.Debug != tt.want {
+				t.Errorf("expected Debug to be %v, got %v", tt.want, cfg.Debug)
+			}
+		})
+	}
+
+	type InvalidPresenceConfig struct {
+		Debug string `env:"PRESENCE_INVALID,presence"`
+	}
+	if err := ParseEnvFromMap(&InvalidPresenceConfig{}, nil); err == nil {
+		t.Error("expected an error for presence on a non-bool field, but got none")
+	}
+}
+
+// Hostname has a fmt.Scanner Scan method and sets itself from the environment with FromEnv
+type Hostname string
+
+func (h *Hostname) Scan(state fmt.ScanState, verb rune) error {
+	return errors.New("Scan must not be called by ParseEnv")
+}
+
+func (h *Hostname) FromEnv(s string) error {
+	if strings.Contains(s, " ") {
+		return fmt.Errorf("invalid hostname %q", s)
+	}
+	*h = Hostname(strings.ToLower(s))
+	return nil
+}
+
+// TestParseEnvSetterMethod tests using a custom setter method name with the SetterMethod option.
+func TestParseEnvSetterMethod(t *testing.T) {
+	type SetterMethodConfig struct {
+		Host  Hostname   `env:"SETTERMETHOD_HOST"`
+		Hosts []Hostname `env:"SETTERMETHOD_HOSTS"`
+	}
+
+	env := map[string]string{"SETTERMETHOD_HOST": "DB.Example", "SETTERMETHOD_HOSTS": "A,B"}
+	cfg := &SetterMethodConfig{}
+	err := ParseEnvWithOptions(cfg, ParseEnvOptions{Lookup: mapLookup(env), SetterMethod: "FromEnv"})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Host != "db.example" {
+		t.Errorf("expected Host to be db.example, got %q", cfg.Host)
+	}
+	if !reflect.DeepEqual(cfg.Hosts, []Hostname{"a", "b"}) {
+		t.Errorf("expected Hosts to be [a b], got %q", cfg.Hosts)
+	}
+
+	err = ParseEnvWithOptions(&SetterMethodConfig{}, ParseEnvOptions{
+		Lookup:       mapLookup(map[string]string{"SETTERMETHOD_HOSTS": "a,b c"}),
+		SetterMethod: "FromEnv",
+	})
+	if err == nil || !strings.Contains(err.Error(), `invalid hostname "b c"`) {
+		t.Errorf("expected the FromEnv error, got: %v", err)
+	}
+
+	// Scan with a non-setter signature is ignored, so the string is set as is
+	cfg = &SetterMethodConfig{}
+	if err := ParseEnvFromMap(cfg, env); err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Host != "DB.Example" {
+		t.Errorf("expected Host to be DB.Example, got %q", cfg.Host)
+	}
+}
+
+// TestParseEnvContextCancel tests that cancelling the context aborts a pending secret resolution.
+func TestParseEnvContextCancel(t *testing.T) {
+	type CtxConfig struct {
+		Token string `env:"CTX_TOKEN,secret"`
+		Port  int    `env:"CTX_PORT"`
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	opts := ParseEnvOptions{
+		Lookup: mapLookup(map[string]string{
+			"CTX_TOKEN": "vault://api#token",
+			"CTX_PORT":  "8080",
+		}),
+		SecretResolverContext: func(ctx context.Context, ref string) (string, error) {
+			close(started)
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+	}
+
+	cfg := &CtxConfig{}
+	err := ParseEnvContext(ctx, cfg, opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a context.Canceled error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "Token") {
+		t.Errorf("expected the error to name the field, got %v", err)
+	}
+	if cfg.Token != "" {
+		t.Errorf("expected Token to stay empty, got %q", cfg.Token)
+	}
+}
+
+// TestParseEnvContextDone tests that a done context stops parsing before resolvers are called.
+func TestParseEnvContextDone(t *testing.T) {
+	type CtxConfig struct {
+		Token string `env:"CTX_DONE_TOKEN,secret"`
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	opts := ParseEnvOptions{
+		Lookup: mapLookup(map[string]string{"CTX_DONE_TOKEN": "vault://api#token"}),
+		SecretResolver: func(ref string) (string, error) {
+			called = true
+			return "t0k3n", nil
+		},
+	}
+
+	err := ParseEnvContext(ctx, &CtxConfig{}, opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a context.Canceled error, got %v", err)
+	}
+	if called {
+		t.Errorf("expected SecretResolver not to be called")
+	}
+
+	cfg := &CtxConfig{}
+	if err := ParseEnvContext(context.Background(), cfg, opts); err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Token != "t0k3n" {
+		t.Errorf("expected Token to be resolved, got %q", cfg.Token)
+	}
+}
+
+// TestParseEnvSlogLevel tests parsing slog.Level from names and numbers through its UnmarshalText.
+func TestParseEnvSlogLevel(t *testing.T) {
+	type LevelConfig struct {
+		Level slog.Level `env:"SLOG_LEVEL"`
+	}
+
+	tests := []struct {
+		value string
+		want  slog.Level
+	}{
+		{"INFO", slog.LevelInfo},
+		{"debug", slog.LevelDebug},
+		{"WARN+2", slog.LevelWarn + 2},
+		{"8", slog.LevelError},
+		{"-4", slog.LevelDebug},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			cfg := &LevelConfig{}
+			if err := ParseEnvFromMap(cfg, map[string]string{"SLOG_LEVEL": tt.value}); err != nil {
+				t.Fatalf("ParseEnv returned an error: %v", err)
+			}
+			if cfg.Level != tt.want {
+				t.Errorf("expected Level to be %v, got %v", tt.want, cfg.Level)
+			}
+		})
+	}
+
+	err := ParseEnvFromMap(&LevelConfig{}, map[string]string{"SLOG_LEVEL": "verbose"})
+	if err == nil {
+		t.Fatal("expected an error for an invalid level")
+	}
+	if !strings.Contains(err.Error(), "failed to unmarshal value for field Level") || !strings.Contains(err.Error(), "unknown name") {
+		t.Errorf("expected the UnmarshalText error to be surfaced, got: %v", err)
+	}
+}
+
+// TestParseEnvStrictParse tests that strictparse rejects numeric variables set to an empty value.
+func TestParseEnvStrictParse(t *testing.T) {
+	type StrictParseConfig struct {
+		Port    int           `env:"STRICTPARSE_PORT,strictparse"`
+		Ratio   float64       `env:"STRICTPARSE_RATIO,strictparse,default=0.5"`
+		Timeout time.Duration `env:"STRICTPARSE_TIMEOUT,strictparse"`
+		Retries int           `env:"STRICTPARSE_RETRIES"`
+	}
+
+	cfg := &StrictParseConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"STRICTPARSE_PORT":    "8080",
+		"STRICTPARSE_RETRIES": "",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Port != 8080 {
+		t.Errorf("expected Port to be 8080, got %d", cfg.Port)
+	}
+	if cfg.Ratio != 0.5 {
+		t.Errorf("expected the unset Ratio to default to 0.5, got %v", cfg.Ratio)
+	}
+	if cfg.Retries != 0 {
+		t.Errorf("expected the empty Retries without strictparse to stay 0, got %d", cfg.Retries)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"empty int", map[string]string{"STRICTPARSE_PORT": ""}, "environment variable STRICTPARSE_PORT for field Port is set but empty"},
+		{"blank float with default", map[string]string{"STRICTPARSE_RATIO": "  "}, "environment variable STRICTPARSE_RATIO for field Ratio is set but empty"},
+		{"empty duration", map[string]string{"STRICTPARSE_TIMEOUT": ""}, "field Timeout is set but empty"},
+		{"invalid int", map[string]string{"STRICTPARSE_PORT": "http"}, "invalid int value for STRICTPARSE_PORT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&StrictParseConfig{}, tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+
+	type StringConfig struct {
+		Name string `env:"STRICTPARSE_NAME,strictparse"`
+	}
+	if err := ParseEnvFromMap(&StringConfig{}, nil); err == nil || !strings.Contains(err.Error(), "requires a numeric field") {
+		t.Errorf("expected an error for strictparse on a string field, got: %v", err)
+	}
+}
+
+// stubYAMLUnmarshal decodes flat "key: value" documents by converting them to JSON.
+func stubYAMLUnmarshal(data []byte, v any) error {
+	doc := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return fmt.Errorf("invalid line %q", line)
+		}
+		doc[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// TestParseEnvYAML tests parser=yaml with a caller-supplied YAMLUnmarshal.
+func TestParseEnvYAML(t *testing.T) {
+	type Endpoint struct {
+		Host string `json:"host"`
+		Path string `json:"path"`
+	}
+	type YAMLConfig struct {
+		Endpoint Endpoint          `env:"YAML_ENDPOINT,parser=yaml"`
+		Labels   map[string]string `env:"YAML_LABELS,parser=yaml"`
+	}
+
+	env := map[string]string{
+		"YAML_ENDPOINT": "host: db.local\npath: /v1",
+		"YAML_LABELS":   "team: core\nenv: prod",
+	}
+	opts := ParseEnvOptions{Lookup: mapLookup(env), YAMLUnmarshal: stubYAMLUnmarshal}
+
+	cfg := &YAMLConfig{}
+	if err := ParseEnvWithOptions(cfg, opts); err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Endpoint.Host != "db.local" || cfg.Endpoint.Path != "/v1" {
+		t.Errorf("expected Endpoint to be {db.local /v1}, got %+v", cfg.Endpoint)
+	}
+	if len(cfg.Labels) != 2 || cfg.Labels["team"] != "core" {
+		t.Errorf("expected Labels to be map[env:prod team:core], got %v", cfg.Labels)
+	}
+
+	env["YAML_LABELS"] = "not yaml"
+	err := ParseEnvWithOptions(&YAMLConfig{}, opts)
+	if err == nil || !strings.Contains(err.Error(), "field Labels: failed to unmarshal YAML: invalid line") {
+		t.Errorf("expected the unmarshal error naming the field, got: %v", err)
+	}
+
+	err = ParseEnvFromMap(&YAMLConfig{}, env)
+	if err == nil || !strings.Contains(err.Error(), "parser=yaml requires ParseEnvOptions.YAMLUnmarshal") {
+		t.Errorf("expected an error without a YAML unmarshaler, got: %v", err)
+	}
+}
+
+// TestParseEnvNonEmpty tests that nonempty rejects empty and whitespace-only strings and elements.
+func TestParseEnvNonEmpty(t *testing.T) {
+	type NonEmptyConfig struct {
+		Name    string   `env:"NONEMPTY_NAME,nonempty"`
+		Region  string   `env:"NONEMPTY_REGION,nonempty,default=eu"`
+		Brokers []string `env:"NONEMPTY_BROKERS,nonempty"`
+		Note    string   `env:"NONEMPTY_NOTE"`
+	}
+
+	cfg := &NonEmptyConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"NONEMPTY_NAME":    " api ",
+		"NONEMPTY_BROKERS": "a,b",
+		"NONEMPTY_NOTE":    "   ",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Name != " api " || cfg.Region != "eu" {
+		t.Errorf("expected Name and Region to be ' api ' and 'eu', got %q and %q", cfg.Name, cfg.Region)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"whitespace-only string", map[string]string{"NONEMPTY_NAME": "   ", "NONEMPTY_BROKERS": "a"}, "field Name: value must not be empty or whitespace"},
+		{"empty string", map[string]string{"NONEMPTY_NAME": "", "NONEMPTY_BROKERS": "a"}, "field Name: value must not be empty or whitespace"},
+		{"unset string", map[string]string{"NONEMPTY_BROKERS": "a"}, "field Name: value must not be empty or whitespace"},
+		{"whitespace over default", map[string]string{"NONEMPTY_NAME": "api", "NONEMPTY_REGION": "\t", "NONEMPTY_BROKERS": "a"}, "field Region: value must not be empty or whitespace"},
+		{"whitespace-only element", map[string]string{"NONEMPTY_NAME": "api", "NONEMPTY_BROKERS": "a,  ,b"}, "field Brokers: element 1 must not be empty or whitespace"},
+		{"empty element", map[string]string{"NONEMPTY_NAME": "api", "NONEMPTY_BROKERS": "a,"}, "field Brokers: element 1 must not be empty or whitespace"},
+		{"unset slice", map[string]string{"NONEMPTY_NAME": "api"}, "field Brokers: value must have at least one element"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&NonEmptyConfig{}, tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+
+	type IntConfig struct {
+		Port int `env:"NONEMPTY_PORT,nonempty"`
+	}
+	if err := ParseEnvFromMap(&IntConfig{}, nil); err == nil || !strings.Contains(err.Error(), "requires a string or string slice field") {
+		t.Errorf("expected an error for nonempty on an int field, got: %v", err)
+	}
+}
+
+// TestParseEnvValidJSON tests that the json option rejects malformed JSON and keeps the raw string.
+func TestParseEnvValidJSON(t *testing.T) {
+	type ValidJSONConfig struct {
+		Payload string `env:"VALIDJSON_PAYLOAD,json"`
+		Extra   string `env:"VALIDJSON_EXTRA,json,default=[]"`
+	}
+
+	cfg := &ValidJSONConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{"VALIDJSON_PAYLOAD": `{"a": [1, 2]}`})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Payload != `{"a": [1, 2]}` || cfg.Extra != "[]" {
+		t.Errorf("expected the raw JSON strings, got %q and %q", cfg.Payload, cfg.Extra)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"unterminated object", map[string]string{"VALIDJSON_PAYLOAD": `{"a": 1`}, "field Payload: value is not valid JSON"},
+		{"bare word", map[string]string{"VALIDJSON_EXTRA": "hello"}, "field Extra: value is not valid JSON"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&ValidJSONConfig{}, tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+
+	type IntConfig struct {
+		Count int `env:"VALIDJSON_COUNT,json"`
+	}
+	if err := ParseEnvFromMap(&IntConfig{}, nil); err == nil || !strings.Contains(err.Error(), "json option for field Count requires a string field") {
+		t.Errorf("expected an error for json on an int field, got: %v", err)
+	}
+}
+
+// TestParseEnvZeroAsUnset tests that zeroasunset fields fall back to their default when set to a zero value.
+func TestParseEnvZeroAsUnset(t *testing.T) {
+	type ZeroAsUnsetConfig struct {
+		Port    int           `env:"ZEROUNSET_PORT,zeroasunset,min=1,default=8080"`
+		Timeout time.Duration `env:"ZEROUNSET_TIMEOUT,zeroasunset,default=5s"`
+		Retries int           `env:"ZEROUNSET_RETRIES,default=3"`
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want ZeroAsUnsetConfig
+	}{
+		{"zero falls back", map[string]string{"ZEROUNSET_PORT": "0", "ZEROUNSET_TIMEOUT": "0s", "ZEROUNSET_RETRIES": "0"}, ZeroAsUnsetConfig{8080, 5 * time.Second, 0}},
+		{"non-zero kept", map[string]string{"ZEROUNSET_PORT": "9090", "ZEROUNSET_TIMEOUT": "1m"}, ZeroAsUnsetConfig{9090, time.Minute, 3}},
+		{"unset", nil, ZeroAsUnsetConfig{8080, 5 * time.Second, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &ZeroAsUnsetConfig{}
+			if err := ParseEnvFromMap(cfg, tt.env); err != nil {
+				t.Fatalf("ParseEnv returned an error: %v", err)
+			}
+			if *cfg != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, *cfg)
+			}
+		})
+	}
+
+	type RequiredConfig struct {
+		Workers int `env:"ZEROUNSET_WORKERS,zeroasunset,required"`
+	}
+	err := ParseEnvFromMap(&RequiredConfig{}, map[string]string{"ZEROUNSET_WORKERS": "0"})
+	if err == nil || !strings.Contains(err.Error(), "required environment variable ZEROUNSET_WORKERS for field Workers not set") {
+		t.Errorf("expected a required error for a zero value, got: %v", err)
+	}
+	err = ParseEnvFromMap(&RequiredConfig{}, map[string]string{"ZEROUNSET_WORKERS": "many"})
+	if err == nil || !strings.Contains(err.Error(), "Workers") {
+		t.Errorf("expected a parse error naming field Workers, got: %v", err)
+	}
+
+	type SliceConfig struct {
+		Ports []int `env:"ZEROUNSET_PORTS,zeroasunset"`
+	}
+	err = ParseEnvFromMap(&SliceConfig{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "zeroasunset option for field Ports requires a scalar field") {
+		t.Errorf("expected an error for zeroasunset on a slice, got: %v", err)
+	}
+
+	type UnexportedConfig struct {
+		port int `env:"ZEROUNSET_UNEXPORTED,zeroasunset,default=8080"`
+	}
+	err = ParseEnvFromMap(&UnexportedConfig{}, map[string]string{"ZEROUNSET_UNEXPORTED": "0"})
+	if err == nil || !strings.Contains(err.Error(), "field port is not exported") {
+		t.Errorf("expected a not exported error for an unexported zeroasunset field, got: %v", err)
+	}
+}
+
+// TestParseEnvPreserveExisting tests that a reload with PreserveExisting keeps values set at runtime.
+func TestParseEnvPreserveExisting(t *testing.T) {
+	type ReloadConfig struct {
+		Workers  int           `env:"RELOAD_WORKERS,default=4"`
+		Mode     string        `env:"RELOAD_MODE,default=safe"`
+		Timeout  time.Duration `env:"RELOAD_TIMEOUT,default=5s"`
+		Endpoint string        `env:"RELOAD_ENDPOINT,required"`
+	}
+
+	env := map[string]string{"RELOAD_ENDPOINT": "http://a", "RELOAD_MODE": "fast"}
+	opts := ParseEnvOptions{Lookup: mapLookup(env), PreserveExisting: true}
+
+	cfg := &ReloadConfig{}
+	if err := ParseEnvWithOptions(cfg, opts); err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Workers != 4 || cfg.Mode != "fast" {
+		t.Errorf("expected the first parse to apply defaults and variables, got %+v", cfg)
+	}
+
+	// Runtime overrides, then a reload where RELOAD_ENDPOINT and RELOAD_MODE are gone
+	cfg.Workers = 32
+	cfg.Timeout = time.Minute
+	delete(env, "RELOAD_ENDPOINT")
+	delete(env, "RELOAD_MODE")
+	if err := ParseEnvWithOptions(cfg, opts); err != nil {
+		t.Fatalf("ParseEnv returned an error on reload: %v", err)
+	}
+	if cfg.Workers != 32 || cfg.Timeout != time.Minute {
+		t.Errorf("expected the runtime overrides to be preserved, got Workers=%d Timeout=%v", cfg.Workers, cfg.Timeout)
+	}
+	if cfg.Mode != "fast" || cfg.Endpoint != "http://a" {
+		t.Errorf("expected the previous values to be preserved, got Mode=%q Endpoint=%q", cfg.Mode, cfg.Endpoint)
+	}
+
+	// Variables that are set still override
+	env["RELOAD_WORKERS"] = "8"
+	if err := ParseEnvWithOptions(cfg, opts); err != nil {
+		t.Fatalf("ParseEnv returned an error on reload: %v", err)
+	}
+	if cfg.Workers != 8 {
+		t.Errorf("expected RELOAD_WORKERS to override Workers, got %d", cfg.Workers)
+	}
+
+	// Without the option the defaults are re-applied
+	cfg.Timeout = time.Minute
+	env["RELOAD_ENDPOINT"] = "http://b"
+	if err := ParseEnvWithOptions(cfg, ParseEnvOptions{Lookup: mapLookup(env)}); err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Timeout != 5*time.Second || cfg.Mode != "safe" {
+		t.Errorf("expected the defaults to be re-applied, got Timeout=%v Mode=%q", cfg.Timeout, cfg.Mode)
+	}
+}
+
+// Color is a stringer-style integer enum implementing UnmarshalText.
+type Color int
+
+const (
+	ColorRed Color = iota + 1
+	ColorGreen
+)
+
+func (c Color) String() string {
+	switch c {
+	case ColorRed:
+		return "red"
+	case ColorGreen:
+		return "green"
+	}
+	return "Color(" + strconv.Itoa(int(c)) + ")"
+}
+
+func (c *Color) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "red":
+		*c = ColorRed
+	case "green":
+		*c = ColorGreen
+	default:
+		return fmt.Errorf("unknown color %q", text)
+	}
+	return nil
+}
+
+// TestParseEnvIntEnum tests integer enums with UnmarshalText, whose errors aren't masked by integer parsing.
+func TestParseEnvIntEnum(t *testing.T) {
+	type EnumConfig struct {
+		Color Color `env:"ENUM_COLOR"`
+	}
+
+	cfg := &EnumConfig{}
+	if err := ParseEnvFromMap(cfg, map[string]string{"ENUM_COLOR": "green"}); err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Color != ColorGreen {
+		t.Errorf("expected Color to be green, got %v", cfg.Color)
+	}
+
+	// A value the unmarshaler rejects is still accepted if it is an integer
+	if err := ParseEnvFromMap(cfg, map[string]string{"ENUM_COLOR": "1"}); err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Color != ColorRed {
+		t.Errorf("expected Color to be red, got %v", cfg.Color)
+	}
+
+	err := ParseEnvFromMap(&EnumConfig{}, map[string]string{"ENUM_COLOR": "purple"})
+	if err == nil {
+		t.Fatal("expected an error for an invalid enum name")
+	}
+	if !strings.Contains(err.Error(), `failed to unmarshal value for field Color: unknown color "purple"`) {
+		t.Errorf("expected the UnmarshalText error, got: %v", err)
+	}
+	if strings.Contains(err.Error(), "strconv") {
+		t.Errorf("expected the integer parsing error to be hidden, got: %v", err)
+	}
+}
+
+// TestParseEnvOnField tests that OnField fires once per set field and reports defaults.
+func TestParseEnvOnField(t *testing.T) {
+	type OnFieldDB struct {
+		_    struct{} `env:",prefix=ONFIELD_DB_"`
+		Host string   `env:"HOST,default=localhost"`
+		Port int      `env:"PORT"`
+	}
+	type OnFieldConfig struct {
+		Name    string        `env:"ONFIELD_NAME"`
+		Timeout time.Duration `env:"ONFIELD_TIMEOUT,default=5s"`
+		Unset   string        `env:"ONFIELD_UNSET"`
+		DB      OnFieldDB
+	}
+
+	type event struct {
+		key         string
+		value       string
+		fromDefault bool
+	}
+	events := make(map[string]event)
+	calls := 0
+	opts := ParseEnvOptions{
+		Lookup: mapLookup(map[string]string{
+			"ONFIELD_NAME":    "api",
+			"ONFIELD_DB_PORT": "5432",
+		}),
+		OnField: func(path, key string, value reflect.Value, fromDefault bool) {
+			calls++
+			events[path] = event{key: key, value: fmt.Sprint(value.Interface()), fromDefault: fromDefault}
+		},
+	}
+
+	cfg := &OnFieldConfig{}
+	if err := ParseEnvWithOptions(cfg, opts); err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	want := map[string]event{
+		"Name":    {key: "ONFIELD_NAME", value: "api"},
+		"Timeout": {key: "ONFIELD_TIMEOUT", value: "5s", fromDefault: true},
+		"DB.Host": {key: "ONFIELD_DB_HOST", value: "localhost", fromDefault: true},
+		"DB.Port": {key: "ONFIELD_DB_PORT", value: "5432"},
+	}
+	if calls != len(want) {
+		t.Errorf("expected OnField to be called %d times, got %d: %v", len(want), calls, events)
+	}
+	for path, w := range want {
+		if got, ok := events[path]; !ok || got != w {
+			t.Errorf("expected the event for %s to be %+v, got %+v", path, w, got)
+		}
+	}
+	if _, ok := events["Unset"]; ok {
+		t.Errorf("expected no event for the unset field")
+	}
+
+	// Fields spanning several variables or forced by another one are reported too
+	type OnFieldReplica struct {
+		Host string `env:"HOST"`
+	}
+	type OnFieldMultiConfig struct {
+		Labels   map[string]string `env:"ONFIELD_LABEL_,collectprefix"`
+		Replicas []OnFieldReplica  `env:"ONFIELD_REPLICA,indexed"`
+		Cache    bool              `env:"ONFIELD_CACHE,negate=ONFIELD_NO_CACHE"`
+		Debug    bool              `env:"ONFIELD_DEBUG,negate=ONFIELD_NO_DEBUG"`
+	}
+	vars := map[string]string{
+		"ONFIELD_LABEL_TEAM":     "core",
+		"ONFIELD_REPLICA_0_HOST": "db0",
+		"ONFIELD_REPLICA_1_HOST": "db1",
+		"ONFIELD_NO_CACHE":       "true",
+		"ONFIELD_DEBUG":          "true",
+		"ONFIELD_NO_DEBUG":       "true",
+	}
+	var keys []string
+	err := ParseEnvWithOptions(&OnFieldMultiConfig{}, ParseEnvOptions{
+		Lookup:   mapLookup(vars),
+		ListKeys: mapKeys(vars),
+		OnField: func(path, key string, value reflect.Value, fromDefault bool) {
+			keys = append(keys, path+"="+key)
+		},
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	expected := []string{
+		"Replicas[0].Host=ONFIELD_REPLICA_0_HOST",
+		"Replicas[1].Host=ONFIELD_REPLICA_1_HOST",
+		"Labels=ONFIELD_LABEL_",
+		"Cache=ONFIELD_NO_CACHE",
+		"Debug=ONFIELD_DEBUG",
+	}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected the reported keys to be %v, got %v", expected, keys)
+	}
+}
+
+// Rule is a routing rule decoded from a JSON array and validated per element.
+type Rule struct {
+	Match string `json:"match"`
+	To    string `json:"to"`
+}
+
+func (r *Rule) Validate() error {
+	if r.Match == "" {
+		return errors.New("match must not be empty")
+	}
+	return nil
+}
+
+// TestParseEnvJSONSlice tests parser=json on slices with per-element validation.
+func TestParseEnvJSONSlice(t *testing.T) {
+	type RulesConfig struct {
+		Rules  []Rule              `env:"JSONSLICE_RULES,parser=json"`
+		Routes []map[string]string `env:"JSONSLICE_ROUTES,parser=json"`
+	}
+
+	cfg := &RulesConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"JSONSLICE_RULES":  `[{"match":"a","to":"b"},{"match":"c","to":"d"}]`,
+		"JSONSLICE_ROUTES": `[{"path":"/"},{"path":"/api","host":"api"}]`,
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if len(cfg.Rules) != 2 || cfg.Rules[1] != (Rule{Match: "c", To: "d"}) {
+		t.Errorf("expected two rules, got %+v", cfg.Rules)
+	}
+	if len(cfg.Routes) != 2 || cfg.Routes[1]["host"] != "api" {
+		t.Errorf("expected two routes, got %v", cfg.Routes)
+	}
+
+	env, err := DumpEnv(cfg)
+	if err != nil {
+		t.Fatalf("DumpEnv returned an error: %v", err)
+	}
+	if env["JSONSLICE_RULES"] != `[{"match":"a","to":"b"},{"match":"c","to":"d"}]` {
+		t.Errorf("expected Rules to be dumped as a JSON array, got %s", env["JSONSLICE_RULES"])
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"invalid element", map[string]string{"JSONSLICE_RULES": `[{"match":"a"},{"to":"b"}]`}, "field Rules: validation of element 1 failed: match must not be empty"},
+		{"malformed JSON", map[string]string{"JSONSLICE_RULES": `[{"match":"a"}`}, "field Rules: failed to unmarshal JSON"},
+		{"not an array", map[string]string{"JSONSLICE_RULES": `{"match":"a"}`}, "field Rules: failed to unmarshal JSON"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&RulesConfig{}, tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// Endpoint is decoded by a decoder method of its config.
+type Endpoint struct {
+	Host string
+	Port int
+}
+
+// DecoderConfig for testing decoder methods
+type DecoderConfig struct {
+	Primary   Endpoint `env:"DECODER_PRIMARY,decoder=DecodeEndpoint"`
+	Secondary Endpoint `env:"DECODER_SECONDARY,decoder=DecodeTypedEndpoint"`
+}
+
+// DecodeEndpoint decodes "host:port" into an Endpoint
+func (c *DecoderConfig) DecodeEndpoint(s string) (any, error) {
+	host, port, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, fmt.Errorf("missing port in %q", s)
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return nil, err
+	}
+	return Endpoint{Host: host, Port: n}, nil
+}
+
+// DecodeTypedEndpoint decodes "host:port" into an Endpoint with a typed result
+func (c *DecoderConfig) DecodeTypedEndpoint(s string) (Endpoint, error) {
+	e, err := c.DecodeEndpoint(s)
+	if err != nil {
+		return Endpoint{}, err
+	}
+	return e.(Endpoint), nil
+}
+
+// DecoderConfigNotFound for testing missing decoder methods
+type DecoderConfigNotFound struct {
+	Primary Endpoint `env:"DECODER_PRIMARY,decoder=NonExistentMethod"`
+}
+
+// DecoderConfigMismatch for testing decoder methods returning the wrong type
+type DecoderConfigMismatch struct {
+	Primary Endpoint `env:"DECODER_PRIMARY,decoder=DecodeString"`
+}
+
+// DecodeString returns a string, which can't be assigned to an Endpoint
+func (c *DecoderConfigMismatch) DecodeString(s string) (string, error) {
+	return s, nil
+}
+
+// DecoderConfigDynamicMismatch for testing decoder methods returning the wrong dynamic type
+type DecoderConfigDynamicMismatch struct {
+	Port int `env:"DECODER_PORT,decoder=DecodeAny"`
+}
+
+// DecodeAny returns a string as any, which can't be assigned to an int
+func (c *DecoderConfigDynamicMismatch) DecodeAny(s string) (any, error) {
+	return s, nil
+}
+
+// TestParseEnvDecoder tests decoder method functionality.
+func TestParseEnvDecoder(t *testing.T) {
+	cfg := &DecoderConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"DECODER_PRIMARY":   "db.local:5432",
+		"DECODER_SECONDARY": "replica:5433",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Primary != (Endpoint{Host: "db.local", Port: 5432}) {
+		t.Errorf("expected Primary to be {db.local 5432}, got %+v", cfg.Primary)
+	}
+	if cfg.Secondary != (Endpoint{Host: "replica", Port: 5433}) {
+		t.Errorf("expected Secondary to be {replica 5433}, got %+v", cfg.Secondary)
+	}
+}
+
+// TestParseEnvDecoderNotFound tests error when decoder method is not found.
+func TestParseEnvDecoderNotFound(t *testing.T) {
+	err := ParseEnvFromMap(&DecoderConfigNotFound{}, map[string]string{"DECODER_PRIMARY": "db:1"})
+	if err == nil || !strings.Contains(err.Error(), "decoder method 'NonExistentMethod' for field 'Primary' not found") {
+		t.Errorf("expected an error for the missing decoder method, got: %v", err)
+	}
+}
+
+// TestParseEnvDecoderError tests error handling when decoder method fails or returns the wrong type.
+func TestParseEnvDecoderError(t *testing.T) {
+	err := ParseEnvFromMap(&DecoderConfig{}, map[string]string{"DECODER_PRIMARY": "db.local"})
+	if err == nil || !strings.Contains(err.Error(), `decoder method 'DecodeEndpoint' for field 'Primary' failed: missing port in "db.local"`) {
+		t.Errorf("expected the decoder error, got: %v", err)
+	}
+
+	err = ParseEnvFromMap(&DecoderConfigMismatch{}, map[string]string{"DECODER_PRIMARY": "db:1"})
+	if err == nil || !strings.Contains(err.Error(), "returns string, which is not assignable to lazyconf.Endpoint") {
+		t.Errorf("expected an error for the mismatched return type, got: %v", err)
+	}
+
+	err = ParseEnvFromMap(&DecoderConfigDynamicMismatch{}, map[string]string{"DECODER_PORT": "80"})
+	if err == nil || !strings.Contains(err.Error(), "returned string, which is not assignable to int") {
+		t.Errorf("expected an error for the mismatched dynamic type, got: %v", err)
+	}
+
+	err = ParseEnvWithOptions(&DecoderConfigMismatch{}, ParseEnvOptions{Lookup: mapLookup(nil), ValidateTypesUpfront: true})
+	if err == nil || !strings.Contains(err.Error(), "decoder method 'DecodeString' returns string") {
+		t.Errorf("expected ValidateTypesUpfront to report the mismatched decoder, got: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package lazyconf
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// lengthLimits holds the len=, minlen= and maxlen= options of a slice field, -1 when unset.
+type lengthLimits struct {
+	exact, min, max int
+}
+
+// parseLengthLimits parses the length options of a slice field.
+func parseLengthLimits(fieldType reflect.Type, lenStr, minStr, maxStr string) (lengthLimits, error) {
+	if fieldType.Kind() != reflect.Slice {
+		return lengthLimits{}, fmt.Errorf("len, minlen and maxlen options require a slice, got %s", fieldType)
+	}
+
+	parse := func(name, s string) (int, error) {
+		if s == "" {
+			return -1, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid %s=%s, expected a non-negative integer", name, s)
+		}
+		return n, nil
+	}
+
+	var l lengthLimits
+	var err error
+	if l.exact, err = parse("len", lenStr); err != nil {
+		return lengthLimits{}, err
+	}
+	if l.min, err = parse("minlen", minStr); err != nil {
+		return lengthLimits{}, err
+	}
+	if l.max, err = parse("maxlen", maxStr); err != nil {
+		return lengthLimits{}, err
+	}
+	if l.min >= 0 && l.max >= 0 && l.min > l.max {
+		return lengthLimits{}, fmt.Errorf("minlen=%d is greater than maxlen=%d", l.min, l.max)
+	}
+	return l, nil
+}
+
+// check returns an error if the slice doesn't have an allowed number of elements.
+func (l lengthLimits) check(fieldValue reflect.Value) error {
+	n := fieldValue.Len()
+	if l.exact >= 0 && n != l.exact {
+		return fmt.Errorf("expected exactly %d elements, got %d", l.exact, n)
+	}
+	if l.min >= 0 && n < l.min {
+		return fmt.Errorf("expected at least %d elements, got %d", l.min, n)
+	}
+	if l.max >= 0 && n > l.max {
+		return fmt.Errorf("expected at most %d elements, got %d", l.max, n)
+	}
+	return nil
+}
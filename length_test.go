@@ -0,0 +1,62 @@
+package lazyconf
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseEnvSliceLength tests the len=, minlen= and maxlen= options on slice fields.
+func TestParseEnvSliceLength(t *testing.T) {
+	type LengthConfig struct {
+		Pair    []string `env:"LENGTH_PAIR,len=2"`
+		Brokers []string `env:"LENGTH_BROKERS,minlen=2,maxlen=3"`
+	}
+
+	cfg := &LengthConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"LENGTH_PAIR":    "localhost,8080",
+		"LENGTH_BROKERS": "a,b,c",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if len(cfg.Pair) != 2 || cfg.Pair[1] != "8080" {
+		t.Errorf("expected Pair to be [localhost 8080], got %v", cfg.Pair)
+	}
+	if len(cfg.Brokers) != 3 {
+		t.Errorf("expected 3 Brokers, got %v", cfg.Brokers)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"too many for len", map[string]string{"LENGTH_PAIR": "a,b,c"}, "field Pair: expected exactly 2 elements, got 3"},
+		{"too few for len", map[string]string{"LENGTH_PAIR": "a"}, "field Pair: expected exactly 2 elements, got 1"},
+		{"above maxlen", map[string]string{"LENGTH_BROKERS": "a,b,c,d"}, "field Brokers: expected at most 3 elements, got 4"},
+		{"below minlen", map[string]string{"LENGTH_BROKERS": "a"}, "field Brokers: expected at least 2 elements, got 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&LengthConfig{}, tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+
+	type InvalidLengthConfig struct {
+		Hosts []string `env:"LENGTH_HOSTS,minlen=3,maxlen=2"`
+	}
+	if err := ParseEnvFromMap(&InvalidLengthConfig{}, nil); err == nil || !strings.Contains(err.Error(), "minlen=3 is greater than maxlen=2") {
+		t.Errorf("expected an error for the invalid lengths, got: %v", err)
+	}
+
+	type NonSliceConfig struct {
+		Name string `env:"LENGTH_NAME,len=2"`
+	}
+	if err := ParseEnvFromMap(&NonSliceConfig{}, nil); err == nil || !strings.Contains(err.Error(), "require a slice") {
+		t.Errorf("expected an error for len on a string field, got: %v", err)
+	}
+}
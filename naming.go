@@ -0,0 +1,30 @@
+package lazyconf
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SnakeUpper converts a Go field name into an upper snake case env key, e.g. "MaxConns" becomes
+// "MAX_CONNS". Runs of capitals are treated as acronyms, so "HTTPPort" becomes "HTTP_PORT".
+// Digits stay attached to the preceding word.
+func SnakeUpper(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// ScreamingSnake is an alias of SnakeUpper.
+func ScreamingSnake(name string) string {
+	return SnakeUpper(name)
+}
@@ -0,0 +1,102 @@
+package lazyconf
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSnakeUpper tests converting field names into upper snake case keys.
+func TestSnakeUpper(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"Port", "PORT"},
+		{"MaxConns", "MAX_CONNS"},
+		{"maxConns", "MAX_CONNS"},
+		{"HTTPPort", "HTTP_PORT"},
+		{"UserID", "USER_ID"},
+		{"DBHost2", "DB_HOST2"},
+		{"Port2Name", "PORT2_NAME"},
+		{"APIKeyV2", "API_KEY_V2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SnakeUpper(tt.name); got != tt.expected {
+				t.Errorf("expected SnakeUpper(%q) to be %q, got %q", tt.name, tt.expected, got)
+			}
+			if got := ScreamingSnake(tt.name); got != tt.expected {
+				t.Errorf("expected ScreamingSnake(%q) to be %q, got %q", tt.name, tt.expected, got)
+			}
+		})
+	}
+}
+
+type KeyFromFieldNested struct {
+	DBHost string
+}
+
+type KeyFromFieldConfig struct {
+	MaxConns  int
+	HTTPPort  int    `env:",default=8080"`
+	Explicit  string `env:"CUSTOM_KEY"`
+	StartedAt time.Time
+	Nested    KeyFromFieldNested
+	Optional  *KeyFromFieldNested
+	Skipped   string `env:"_"`
+	internal  string
+}
+
+// TestParseEnvKeyFromField tests deriving keys of fields without one from their names.
+func TestParseEnvKeyFromField(t *testing.T) {
+	vars := map[string]string{
+		"MAX_CONNS":  "10",
+		"CUSTOM_KEY": "custom",
+		"STARTED_AT": "2024-01-02T00:00:00Z",
+		"DB_HOST":    "db.local",
+		"SKIPPED":    "nope",
+		"INTERNAL":   "nope",
+	}
+
+	cfg := &KeyFromFieldConfig{}
+	err := ParseEnvWithOptions(cfg, ParseEnvOptions{Lookup: mapLookup(vars), KeyFromField: SnakeUpper})
+	if err != nil {
+		t.Fatalf("ParseEnvWithOptions returned an error: %v", err)
+	}
+
+	if cfg.MaxConns != 10 {
+		t.Errorf("expected MaxConns to be 10, got %d", cfg.MaxConns)
+	}
+	if cfg.HTTPPort != 8080 {
+		t.Errorf("expected HTTPPort to default to 8080, got %d", cfg.HTTPPort)
+	}
+	if cfg.Explicit != "custom" {
+		t.Errorf("expected Explicit to be 'custom', got '%s'", cfg.Explicit)
+	}
+	if cfg.StartedAt.Year() != 2024 {
+		t.Errorf("expected StartedAt to be parsed, got %v", cfg.StartedAt)
+	}
+	if cfg.Nested.DBHost != "db.local" {
+		t.Errorf("expected Nested.DBHost to be 'db.local', got '%s'", cfg.Nested.DBHost)
+	}
+	if cfg.Optional == nil || cfg.Optional.DBHost != "db.local" {
+		t.Errorf("expected Optional to be allocated from the derived key, got %+v", cfg.Optional)
+	}
+	if cfg.Skipped != "" || cfg.internal != "" {
+		t.Errorf("expected ignored and unexported fields to stay empty, got %q and %q", cfg.Skipped, cfg.internal)
+	}
+
+	// Custom naming strategies are plain functions
+	cfg = &KeyFromFieldConfig{}
+	err = ParseEnvWithOptions(cfg, ParseEnvOptions{
+		Lookup:       mapLookup(map[string]string{"app.MaxConns": "3"}),
+		KeyFromField: func(name string) string { return "app." + name },
+	})
+	if err != nil {
+		t.Fatalf("ParseEnvWithOptions returned an error: %v", err)
+	}
+	if cfg.MaxConns != 3 {
+		t.Errorf("expected MaxConns to be 3, got %d", cfg.MaxConns)
+	}
+}
@@ -0,0 +1,101 @@
+package lazyconf
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// normalizeNumber strips thousands separators from the integer part of s and converts the
+// decimal separator to ".". With the default "." decimal separator, "," and "_" group digits;
+// with decimal="," it is "." and "_" instead, e.g. "1.234,56" becomes "1234.56".
+func normalizeNumber(s, decimal string) (string, error) {
+	s = strings.TrimSpace(s)
+	group := ","
+	if decimal == "," {
+		group = "."
+	}
+
+	intPart, fracPart, hasFrac := strings.Cut(s, decimal)
+	if strings.ContainsAny(fracPart, group+"_") {
+		return "", fmt.Errorf("malformed number %q", s)
+	}
+	intPart = strings.NewReplacer(group, "", "_", "").Replace(intPart)
+	if hasFrac {
+		return intPart + "." + fracPart, nil
+	}
+	return intPart, nil
+}
+
+// trimDigitSeparators removes the underscores of a number written like a Go literal, e.g. "1_000_000".
+// Underscores that don't sit between two digits are kept, so such values are still rejected.
+func trimDigitSeparators(s string) string {
+	if !strings.Contains(s, "_") {
+		return s
+	}
+	isDigit := func(c byte) bool { return '0' <= c && c <= '9' }
+	for i := range len(s) {
+		if s[i] == '_' && (i == 0 || i == len(s)-1 || !isDigit(s[i-1]) || !isDigit(s[i+1])) {
+			return s
+		}
+	}
+	return strings.ReplaceAll(s, "_", "")
+}
+
+// setNumber parses envVal as a number that may contain thousands separators and a localized
+// decimal separator, and stores it in the integer or float fieldValue.
+func setNumber(fieldValue reflect.Value, envVal, decimal string) error {
+	num, err := normalizeNumber(envVal, decimal)
+	if err != nil {
+		return err
+	}
+
+	switch fieldValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		vl, err := strconv.ParseInt(num, 10, fieldValue.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fieldValue.SetInt(vl)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		vl, err := strconv.ParseUint(num, 10, fieldValue.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fieldValue.SetUint(vl)
+	default:
+		vl, err := strconv.ParseFloat(num, fieldValue.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fieldValue.SetFloat(vl)
+	}
+	return nil
+}
+
+// checkFinite returns an error if the float value, or any float element of a slice or value of
+// a map, is infinite or NaN.
+func checkFinite(fieldValue reflect.Value) error {
+	switch fieldValue.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if f := fieldValue.Float(); math.IsInf(f, 0) || math.IsNaN(f) {
+			return fmt.Errorf("non-finite value %v", f)
+		}
+	case reflect.Slice:
+		for i := range fieldValue.Len() {
+			if err := checkFinite(fieldValue.Index(i)); err != nil {
+				return fmt.Errorf("element %d: %v", i, err)
+			}
+		}
+	case reflect.Map:
+		iter := fieldValue.MapRange()
+		for iter.Next() {
+			if err := checkFinite(iter.Value()); err != nil {
+				return fmt.Errorf("key %v: %v", iter.Key(), err)
+			}
+		}
+	}
+	return nil
+}
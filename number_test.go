@@ -0,0 +1,177 @@
+package lazyconf
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseEnvNumber tests parser=number with thousands separators and localized decimals.
+func TestParseEnvNumber(t *testing.T) {
+	type NumberConfig struct {
+		Price     float64 `env:"NUMBER_PRICE,parser=number"`
+		Count     int     `env:"NUMBER_COUNT,parser=number"`
+		Limit     uint32  `env:"NUMBER_LIMIT,parser=number"`
+		Localized float64 `env:"NUMBER_LOCALIZED,parser=number,decimal=,"`
+		Grouped   int64   `env:"NUMBER_GROUPED,parser=number,decimal=,,required"`
+	}
+
+	cfg := &NumberConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"NUMBER_PRICE":     "1,234.56",
+		"NUMBER_COUNT":     "-1,000,000",
+		"NUMBER_LIMIT":     "4_000_000",
+		"NUMBER_LOCALIZED": "1.234,5",
+		"NUMBER_GROUPED":   "12.345",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.Price != 1234.56 {
+		t.Errorf("expected Price to be 1234.56, got %v", cfg.Price)
+	}
+	if cfg.Count != -1000000 {
+		t.Errorf("expected Count to be -1000000, got %d", cfg.Count)
+	}
+	if cfg.Limit != 4000000 {
+		t.Errorf("expected Limit to be 4000000, got %d", cfg.Limit)
+	}
+	if cfg.Localized != 1234.5 {
+		t.Errorf("expected Localized to be 1234.5, got %v", cfg.Localized)
+	}
+	if cfg.Grouped != 12345 {
+		t.Errorf("expected Grouped to be 12345, got %d", cfg.Grouped)
+	}
+}
+
+// TestParseEnvNumberErrors tests invalid values and field types for parser=number.
+func TestParseEnvNumberErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   any
+		value string
+	}{
+		{"fraction in integer", &struct {
+			V int `env:"NUMBER_ERR,parser=number"`
+		}{}, "1,234.5"},
+		{"separator in fraction", &struct {
+			V float64 `env:"NUMBER_ERR,parser=number"`
+		}{}, "1.234,5"},
+		{"overflow", &struct {
+			V int8 `env:"NUMBER_ERR,parser=number"`
+		}{}, "1,000"},
+		{"slice", &struct {
+			V []int `env:"NUMBER_ERR,parser=number"`
+		}{}, "1,000"},
+		{"unknown decimal", &struct {
+			V float64 `env:"NUMBER_ERR,parser=number,decimal=;"`
+		}{}, "1;5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(tt.cfg, map[string]string{"NUMBER_ERR": tt.value})
+			if err == nil {
+				t.Fatalf("expected an error for %s, but got none", tt.name)
+			}
+		})
+	}
+}
+
+// TestParseEnvRejectNonFinite tests that RejectNonFinite rejects infinite and NaN floats.
+func TestParseEnvRejectNonFinite(t *testing.T) {
+	type FiniteConfig struct {
+		Ratio   float64            `env:"FINITE_RATIO"`
+		Scale   float32            `env:"FINITE_SCALE"`
+		Weights []float64          `env:"FINITE_WEIGHTS"`
+		Limits  map[string]float64 `env:"FINITE_LIMITS"`
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"normal", map[string]string{"FINITE_RATIO": "0.5", "FINITE_WEIGHTS": "1,2.5"}, ""},
+		{"inf", map[string]string{"FINITE_RATIO": "inf"}, "field Ratio: non-finite value +Inf"},
+		{"negative inf float32", map[string]string{"FINITE_SCALE": "-Inf"}, "field Scale: non-finite value -Inf"},
+		{"nan element", map[string]string{"FINITE_WEIGHTS": "1,nan"}, "field Weights: element 1: non-finite value NaN"},
+		{"inf map value", map[string]string{"FINITE_LIMITS": "cpu:inf"}, "field Limits: key cpu: non-finite value +Inf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Non-finite values are accepted by default
+			if err := ParseEnvFromMap(&FiniteConfig{}, tt.env); err != nil {
+				t.Errorf("ParseEnv returned an error without RejectNonFinite: %v", err)
+			}
+
+			err := ParseEnvWithOptions(&FiniteConfig{}, ParseEnvOptions{Lookup: mapLookup(tt.env), RejectNonFinite: true})
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("ParseEnv returned an error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// TestParseEnvDigitSeparators tests underscores between digits in plain numeric fields.
+func TestParseEnvDigitSeparators(t *testing.T) {
+	type SeparatorConfig struct {
+		Count   int               `env:"DIGITSEP_COUNT"`
+		Budget  float64           `env:"DIGITSEP_BUDGET"`
+		Size    uint64            `env:"DIGITSEP_SIZE"`
+		Timeout time.Duration     `env:"DIGITSEP_TIMEOUT"`
+		Limits  []int64           `env:"DIGITSEP_LIMITS"`
+		Quotas  map[string]uint32 `env:"DIGITSEP_QUOTAS"`
+		Plain   int               `env:"DIGITSEP_PLAIN"`
+	}
+
+	cfg := &SeparatorConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"DIGITSEP_COUNT":   "1_000",
+		"DIGITSEP_BUDGET":  "3_000_000.5",
+		"DIGITSEP_SIZE":    "1_048_576",
+		"DIGITSEP_TIMEOUT": "1_500ms",
+		"DIGITSEP_LIMITS":  "10_000,-2_000",
+		"DIGITSEP_QUOTAS":  "api:100_000",
+		"DIGITSEP_PLAIN":   "42",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Count != 1000 {
+		t.Errorf("expected Count to be 1000, got %d", cfg.Count)
+	}
+	if cfg.Budget != 3000000.5 {
+		t.Errorf("expected Budget to be 3000000.5, got %v", cfg.Budget)
+	}
+	if cfg.Size != 1048576 {
+		t.Errorf("expected Size to be 1048576, got %d", cfg.Size)
+	}
+	if cfg.Timeout != 1500*time.Millisecond {
+		t.Errorf("expected Timeout to be 1.5s, got %v", cfg.Timeout)
+	}
+	if len(cfg.Limits) != 2 || cfg.Limits[0] != 10000 || cfg.Limits[1] != -2000 {
+		t.Errorf("expected Limits to be [10000 -2000], got %v", cfg.Limits)
+	}
+	if cfg.Quotas["api"] != 100000 {
+		t.Errorf("expected Quotas[api] to be 100000, got %v", cfg.Quotas)
+	}
+	if cfg.Plain != 42 {
+		t.Errorf("expected Plain to be 42, got %d", cfg.Plain)
+	}
+
+	for _, value := range []string{"_1000", "1000_", "1__000", "1_.5"} {
+		t.Run(value, func(t *testing.T) {
+			if err := ParseEnvFromMap(&SeparatorConfig{}, map[string]string{"DIGITSEP_BUDGET": value}); err == nil {
+				t.Errorf("expected an error for misplaced underscores in %q", value)
+			}
+		})
+	}
+}
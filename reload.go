@@ -0,0 +1,113 @@
+package lazyconf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Change describes a field whose value differs after a reload.
+type Change struct {
+	Path string // Field path, e.g. "Database.Port"
+	Old  any
+	New  any
+}
+
+// Changes lists the fields changed by ReparseEnv in declaration order.
+type Changes []Change
+
+// ReparseEnv parses the environment into a copy of the struct pointed to by cfg, compares it with
+// cfg field by field and copies the differing values into cfg. Since the copy starts out with the
+// values of cfg, fields without a default whose variables are unset keep them, and fields derived
+// by AfterParse are compared like the others. Only unexported fields are left alone. If parsing
+// fails, cfg is not modified.
+func ReparseEnv(cfg any) (Changes, error) {
+	return ReparseEnvWithOptions(cfg, ParseEnvOptions{})
+}
+
+// ReparseEnvWithOptions is like ReparseEnv, but parses the environment with opts, e.g. to resolve
+// registry= fields through opts.Funcs.
+func ReparseEnvWithOptions(cfg any, opts ParseEnvOptions) (Changes, error) {
+	op := "xconf.ReparseEnv"
+
+	if err := checkStructPointer(cfg); err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+
+	cur := reflect.ValueOf(cfg).Elem()
+	next := reflect.New(cur.Type())
+	cloneStruct(next.Elem(), cur)
+	if err := ParseEnvWithOptions(next.Interface(), opts); err != nil {
+		return nil, err
+	}
+
+	var changes Changes
+	applyChanges(cur, next.Elem(), "", &changes)
+	return changes, nil
+}
+
+// cloneStruct copies the struct src into dst, allocating copies of the structs its pointer fields
+// point to, so parsing into dst leaves src untouched.
+func cloneStruct(dst, src reflect.Value) {
+	dst.Set(src)
+	for i := range dst.NumField() {
+		field := dst.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+		switch {
+		case field.Kind() == reflect.Struct:
+			cloneStruct(field, src.Field(i))
+		case field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct && !checkRegexp(field.Type()) && !field.IsNil():
+			elem := reflect.New(field.Type().Elem())
+			cloneStruct(elem.Elem(), src.Field(i).Elem())
+			field.Set(elem)
+		}
+	}
+}
+
+// applyChanges copies the exported fields of next that differ from cur into cur, recursing into
+// nested structs, and records every copied field in changes.
+func applyChanges(cur, next reflect.Value, path string, changes *Changes) {
+	t := cur.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		fieldPath := path + field.Name
+
+		// Unexported fields can't be copied
+		if !field.IsExported() {
+			continue
+		}
+
+		// Nested structs are compared field by field, structs parsed from a single variable as a whole
+		tag := field.Tag.Get("env")
+		if key, _, _ := strings.Cut(tag, ","); isNestedStruct(field, tag) && key == "" {
+			applyChanges(cur.Field(i), next.Field(i), fieldPath+".", changes)
+			continue
+		}
+
+		// Pointers to structs are compared field by field when both are set, otherwise as a whole
+		if field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct && !checkRegexp(field.Type) {
+			if !cur.Field(i).IsNil() && !next.Field(i).IsNil() {
+				applyChanges(cur.Field(i).Elem(), next.Field(i).Elem(), fieldPath+".", changes)
+				continue
+			}
+		}
+
+		if equalValues(cur.Field(i), next.Field(i)) {
+			continue
+		}
+		*changes = append(*changes, Change{Path: fieldPath, Old: cur.Field(i).Interface(), New: next.Field(i).Interface()})
+		cur.Field(i).Set(next.Field(i))
+	}
+}
+
+// equalValues reports whether a and b hold equal values. Funcs, which reflect.DeepEqual only reports
+// equal when both are nil, are compared by their code pointers, so a function picked again from a
+// registry is not a change.
+func equalValues(a, b reflect.Value) bool {
+	if a.Kind() == reflect.Func {
+		return a.Pointer() == b.Pointer()
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
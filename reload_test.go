@@ -0,0 +1,178 @@
+package lazyconf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestReparseEnv tests that ReparseEnv reports and applies only the changed fields.
+func TestReparseEnv(t *testing.T) {
+	type ReloadDB struct {
+		Host string `env:"REPARSE_DB_HOST,default=localhost"`
+		Port int    `env:"REPARSE_DB_PORT,default=5432"`
+	}
+	type ReloadCache struct {
+		Size int `env:"REPARSE_CACHE_SIZE"`
+	}
+	type ReparseConfig struct {
+		Level   string        `env:"REPARSE_LEVEL,default=info"`
+		Timeout time.Duration `env:"REPARSE_TIMEOUT,default=5s"`
+		Tags    []string      `env:"REPARSE_TAGS"`
+		DB      ReloadDB
+		Cache   *ReloadCache
+		Runtime string
+	}
+
+	t.Setenv("REPARSE_LEVEL", "info")
+	t.Setenv("REPARSE_TAGS", "a,b")
+	cfg := &ReparseConfig{}
+	if err := ParseEnv(cfg); err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	cfg.Runtime = "kept"
+	tags := cfg.Tags
+
+	t.Setenv("REPARSE_LEVEL", "debug")
+	t.Setenv("REPARSE_DB_PORT", "6543")
+	changes, err := ReparseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ReparseEnv returned an error: %v", err)
+	}
+
+	expected := Changes{
+		{Path: "Level", Old: "info", New: "debug"},
+		{Path: "DB.Port", Old: 5432, New: 6543},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected changes %v, got %v", expected, changes)
+	}
+	if cfg.Level != "debug" || cfg.DB.Port != 6543 || cfg.DB.Host != "localhost" || cfg.Timeout != 5*time.Second {
+		t.Errorf("expected the changes to be applied, got %+v", cfg)
+	}
+	if cfg.Runtime != "kept" {
+		t.Errorf("expected the untagged Runtime field to be kept, got %q", cfg.Runtime)
+	}
+	if &cfg.Tags[0] != &tags[0] {
+		t.Error("expected the unchanged Tags slice to be kept as is")
+	}
+
+	// Nothing changed since the last reload
+	changes, err = ReparseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ReparseEnv returned an error: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+
+	// A pointer block set for the first time is reported as a whole
+	t.Setenv("REPARSE_CACHE_SIZE", "64")
+	changes, err = ReparseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ReparseEnv returned an error: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Path != "Cache" || cfg.Cache == nil || cfg.Cache.Size != 64 {
+		t.Errorf("expected Cache to be set, got %v", changes)
+	}
+
+	// A failed reload leaves the config untouched
+	t.Setenv("REPARSE_LEVEL", "warn")
+	t.Setenv("REPARSE_CACHE_SIZE", "128")
+	t.Setenv("REPARSE_TIMEOUT", "soon")
+	_, err = ReparseEnv(cfg)
+	if err == nil || !strings.Contains(err.Error(), "Timeout") {
+		t.Errorf("expected an error naming field Timeout, got: %v", err)
+	}
+	if cfg.Level != "debug" {
+		t.Errorf("expected Level to stay debug after a failed reload, got %q", cfg.Level)
+	}
+	if cfg.Cache.Size != 64 {
+		t.Errorf("expected the Cache block to stay untouched after a failed reload, got %d", cfg.Cache.Size)
+	}
+}
+
+// TestReparseEnvFuncField tests that a registry= func field picking the same function again is not
+// reported as changed, while picking another one is.
+func TestReparseEnvFuncField(t *testing.T) {
+	type FuncReloadConfig struct {
+		Strategy func(a, b int) int `env:"REPARSE_STRATEGY,registry=strategies"`
+	}
+
+	opts := ParseEnvOptions{Funcs: map[string]map[string]any{
+		"strategies": {
+			"sum": func(a, b int) int { return a + b },
+			"max": func(a, b int) int { return max(a, b) },
+		},
+	}}
+
+	t.Setenv("REPARSE_STRATEGY", "sum")
+	cfg := &FuncReloadConfig{}
+	if err := ParseEnvWithOptions(cfg, opts); err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	changes, err := ReparseEnvWithOptions(cfg, opts)
+	if err != nil {
+		t.Fatalf("ReparseEnv returned an error: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected no changes for the same function, got %v", changes)
+	}
+
+	t.Setenv("REPARSE_STRATEGY", "max")
+	changes, err = ReparseEnvWithOptions(cfg, opts)
+	if err != nil {
+		t.Fatalf("ReparseEnv returned an error: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Path != "Strategy" || cfg.Strategy(1, 2) != 2 {
+		t.Errorf("expected Strategy to change to max, got %v", changes)
+	}
+}
+
+type reloadDerivedConfig struct {
+	Host  string    `env:"REPARSE_DERIVED_HOST"`
+	Batch int       `env:"REPARSE_DERIVED_BATCH"`
+	Start time.Time `env:"REPARSE_DERIVED_START"`
+	DSN   string
+}
+
+// AfterParse derives DSN from Host.
+func (c *reloadDerivedConfig) AfterParse() error {
+	c.DSN = "dsn://" + c.Host
+	return nil
+}
+
+// TestReparseEnvKeepsState tests that fields derived by AfterParse are updated on reload, and that
+// fields set by the code whose variables are unset keep their values.
+func TestReparseEnvKeepsState(t *testing.T) {
+	t.Setenv("REPARSE_DERIVED_HOST", "a")
+	t.Setenv("REPARSE_DERIVED_START", "2024-01-01T00:00:00Z")
+	cfg := &reloadDerivedConfig{}
+	if err := ParseEnv(cfg); err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	cfg.Batch = 5
+
+	t.Setenv("REPARSE_DERIVED_HOST", "b")
+	t.Setenv("REPARSE_DERIVED_START", "2025-01-01T00:00:00Z")
+	changes, err := ReparseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ReparseEnv returned an error: %v", err)
+	}
+
+	var paths []string
+	for _, c := range changes {
+		paths = append(paths, c.Path)
+	}
+	if expected := []string{"Host", "Start", "DSN"}; !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected changes to %v, got %v", expected, changes)
+	}
+	if cfg.DSN != "dsn://b" {
+		t.Errorf("expected the derived DSN to follow Host, got %q", cfg.DSN)
+	}
+	if cfg.Batch != 5 {
+		t.Errorf("expected Batch to keep the value set by the code, got %d", cfg.Batch)
+	}
+}
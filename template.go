@@ -0,0 +1,51 @@
+package lazyconf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// templateField is a field tagged with the template option whose raw value is rendered
+// after the rest of the struct has been parsed.
+type templateField struct {
+	index int
+	key   string
+	value string
+}
+
+// renderTemplates executes the raw value of every template field as a text/template against
+// the struct pointed to by val, then parses the rendered text into the field. Fields referenced
+// by a template must therefore be plain (non-template) fields of the same struct.
+func renderTemplates(val reflect.Value, fields []templateField, opts ParseEnvOptions) error {
+	op := "xconf.ParseEnv"
+
+	v := val.Elem()
+	t := v.Type()
+
+	for _, tf := range fields {
+		field := t.Field(tf.index)
+		fieldPath := opts.path + field.Name
+		if !v.Field(tf.index).CanSet() {
+			return fmt.Errorf("%s: field %s is not exported", op, fieldPath)
+		}
+
+		tmpl, err := template.New(field.Name).Option("missingkey=error").Parse(tf.value)
+		if err != nil {
+			return fmt.Errorf("%s: invalid template for field %s: %v", op, fieldPath, err)
+		}
+		var rendered strings.Builder
+		if err := tmpl.Execute(&rendered, val.Interface()); err != nil {
+			return fmt.Errorf("%s: failed to execute template for field %s: %v", op, fieldPath, err)
+		}
+
+		// The raw value was resolved before rendering, so the rendered text isn't resolved again
+		parsed, err := parseSingleField(field, tf.key, rendered.String(), opts, "secret")
+		if err != nil {
+			return err
+		}
+		v.Field(tf.index).Set(parsed)
+	}
+	return nil
+}
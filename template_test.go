@@ -0,0 +1,65 @@
+package lazyconf
+
+import (
+	"strings"
+	"testing"
+)
+
+type TemplateConfig struct {
+	Host  string   `env:"TEMPLATE_HOST"`
+	Port  int      `env:"TEMPLATE_PORT"`
+	URL   string   `env:"TEMPLATE_URL,template"`
+	Ports []int    `env:"TEMPLATE_PORTS,template,delim=;,default={{.Port}};{{.Port}}1"`
+	Names []string `env:"TEMPLATE_NAMES,template,unique"`
+}
+
+// TestParseEnvTemplate tests rendering template values against already parsed fields.
+func TestParseEnvTemplate(t *testing.T) {
+	cfg := &TemplateConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"TEMPLATE_HOST":  "db.local",
+		"TEMPLATE_PORT":  "5432",
+		"TEMPLATE_URL":   "postgres://{{.Host}}:{{.Port}}",
+		"TEMPLATE_NAMES": "{{.Host}},{{.Host}},plain",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if cfg.URL != "postgres://db.local:5432" {
+		t.Errorf("expected URL to be 'postgres://db.local:5432', got '%s'", cfg.URL)
+	}
+	if len(cfg.Ports) != 2 || cfg.Ports[0] != 5432 || cfg.Ports[1] != 54321 {
+		t.Errorf("expected Ports to be [5432 54321], got %v", cfg.Ports)
+	}
+	if len(cfg.Names) != 2 || cfg.Names[0] != "db.local" || cfg.Names[1] != "plain" {
+		t.Errorf("expected Names to be [db.local plain], got %v", cfg.Names)
+	}
+}
+
+// TestParseEnvTemplateErrors tests that template errors are named by field.
+func TestParseEnvTemplateErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"syntax", "{{.Host"},
+		{"missing field", "{{.Missing}}"},
+		{"invalid rendered value", "{{.Host}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&TemplateConfig{}, map[string]string{
+				"TEMPLATE_HOST":  "db.local",
+				"TEMPLATE_PORTS": tt.value,
+			})
+			if err == nil {
+				t.Fatal("expected an error, but got none")
+			}
+			if !strings.Contains(err.Error(), "Ports") && !strings.Contains(err.Error(), "TEMPLATE_PORTS") {
+				t.Errorf("expected error to name field Ports, got: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package lazyconf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// epochLayout is the layout name for Unix timestamps in seconds.
+const epochLayout = "epoch"
+
+// Sentinel defaults for time.Time fields: the current UTC time at parse and the zero time.
+const (
+	timeDefaultNow  = "now"
+	timeDefaultZero = "zero"
+)
+
+// timeLayouts maps the names accepted by the layout= tag option to time layouts.
+var timeLayouts = map[string]string{
+	"rfc3339":  time.RFC3339,
+	"datetime": time.DateTime,
+	"dateonly": time.DateOnly,
+	"timeonly": time.TimeOnly,
+}
+
+// fallbackTimeLayouts are tried in order when a field has no layout= option.
+var fallbackTimeLayouts = []string{time.RFC3339, time.DateOnly, time.TimeOnly, "15:04"}
+
+// parseTime parses s using the named or literal layouts separated by "|", trying them in order.
+// Without a layout, s is tried against fallbackTimeLayouts and the RFC3339 error is returned if none
+// of them match. Values without a zone, such as date-only ones, are interpreted as UTC.
+func parseTime(s, layout string) (time.Time, error) {
+	if layout == "" {
+		var firstErr error
+		for _, l := range fallbackTimeLayouts {
+			t, err := time.Parse(l, s)
+			if err == nil {
+				return t, nil
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		return time.Time{}, firstErr
+	}
+
+	layouts := strings.Split(layout, "|")
+	var err error
+	for _, l := range layouts {
+		var t time.Time
+		if t, err = parseTimeLayout(s, l); err == nil {
+			return t, nil
+		}
+	}
+	if len(layouts) == 1 {
+		return time.Time{}, err
+	}
+	return time.Time{}, fmt.Errorf("value %q matches none of the layouts %s", s, strings.Join(layouts, ", "))
+}
+
+// parseTimeLayout parses s using a single named or literal layout.
+func parseTimeLayout(s, layout string) (time.Time, error) {
+	if strings.EqualFold(layout, epochLayout) {
+		sec, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid epoch timestamp %q", s)
+		}
+		return time.Unix(sec, 0).UTC(), nil
+	}
+	if named, ok := timeLayouts[strings.ToLower(layout)]; ok {
+		layout = named
+	}
+	return time.Parse(layout, s)
+}
+
+// formatTime formats t in the first of the layouts separated by "|", so parseTime reads it back.
+func formatTime(t time.Time, layout string) string {
+	layout, _, _ = strings.Cut(layout, "|")
+	if strings.EqualFold(layout, epochLayout) {
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+	if named, ok := timeLayouts[strings.ToLower(layout)]; ok {
+		layout = named
+	}
+	return t.Format(layout)
+}
@@ -0,0 +1,141 @@
+package lazyconf
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseEnvTimeLayout tests the dateonly and timeonly layouts.
+func TestParseEnvTimeLayout(t *testing.T) {
+	type LayoutConfig struct {
+		Date  time.Time   `env:"LAYOUT_DATE,layout=dateonly"`
+		Clock time.Time   `env:"LAYOUT_CLOCK,layout=timeonly"`
+		Dates []time.Time `env:"LAYOUT_DATES,layout=dateonly"`
+	}
+
+	_ = os.Setenv("LAYOUT_DATE", "2024-01-02")
+	_ = os.Setenv("LAYOUT_CLOCK", "15:04:05")
+	_ = os.Setenv("LAYOUT_DATES", "2024-01-02,2024-02-03")
+
+	cfg := &LayoutConfig{}
+	err := ParseEnv(cfg)
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if expected := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC); !cfg.Date.Equal(expected) || cfg.Date.Location() != time.UTC {
+		t.Errorf("expected Date to be %v, got %v", expected, cfg.Date)
+	}
+	if cfg.Clock.Hour() != 15 || cfg.Clock.Minute() != 4 || cfg.Clock.Second() != 5 {
+		t.Errorf("expected Clock to be 15:04:05, got %v", cfg.Clock.Format(time.TimeOnly))
+	}
+	if len(cfg.Dates) != 2 || cfg.Dates[1].Month() != time.February {
+		t.Errorf("expected Dates to hold 2024-01-02 and 2024-02-03, got %v", cfg.Dates)
+	}
+
+	_ = os.Setenv("LAYOUT_DATE", "2024-01-02T10:00:00Z")
+	if err := ParseEnv(&LayoutConfig{}); err == nil {
+		t.Error("expected an error for a value not matching layout=dateonly, but got none")
+	}
+}
+
+// TestParseEnvTimeLayoutFallback tests that date-only and time-only values are accepted without a layout.
+func TestParseEnvTimeLayoutFallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Time
+	}{
+		{"rfc3339", "2024-01-02T10:30:00Z", time.Date(2024, 1, 2, 10, 30, 0, 0, time.UTC)},
+		{"date only", "2024-01-02", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"time only", "15:04:05", time.Date(0, 1, 1, 15, 4, 5, 0, time.UTC)},
+		{"hours and minutes", "15:04", time.Date(0, 1, 1, 15, 4, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &struct {
+				At time.Time `env:"LAYOUT_FALLBACK_AT"`
+			}{}
+			err := ParseEnvFromMap(cfg, map[string]string{"LAYOUT_FALLBACK_AT": tt.value})
+			if err != nil {
+				t.Fatalf("ParseEnv returned an error: %v", err)
+			}
+			if !cfg.At.Equal(tt.expected) {
+				t.Errorf("expected At to be %v, got %v", tt.expected, cfg.At)
+			}
+		})
+	}
+
+	cfg := &struct {
+		At time.Time `env:"LAYOUT_FALLBACK_AT"`
+	}{}
+	if err := ParseEnvFromMap(cfg, map[string]string{"LAYOUT_FALLBACK_AT": "yesterday"}); err == nil {
+		t.Error("expected an error for an unparseable time, but got none")
+	}
+}
+
+// TestParseEnvTimeSliceLayouts tests that every slice element tries the listed layouts, including epoch.
+func TestParseEnvTimeSliceLayouts(t *testing.T) {
+	type LayoutsConfig struct {
+		Times []time.Time `env:"LAYOUTS_TIMES,layouts=epoch|rfc3339"`
+		At    time.Time   `env:"LAYOUTS_AT,layouts=dateonly|epoch"`
+	}
+
+	cfg := &LayoutsConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"LAYOUTS_TIMES": "1704067200,2024-01-02T10:00:00Z",
+		"LAYOUTS_AT":    "1704067200",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	expected := []time.Time{
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC),
+	}
+	if len(cfg.Times) != 2 || !cfg.Times[0].Equal(expected[0]) || !cfg.Times[1].Equal(expected[1]) {
+		t.Errorf("expected Times to be %v, got %v", expected, cfg.Times)
+	}
+	if !cfg.At.Equal(expected[0]) {
+		t.Errorf("expected At to be %v, got %v", expected[0], cfg.At)
+	}
+
+	err = ParseEnvFromMap(&LayoutsConfig{}, map[string]string{"LAYOUTS_TIMES": "1704067200,2024-01-02,yesterday"})
+	if err == nil {
+		t.Fatal("expected an error for elements matching none of the layouts, but got none")
+	}
+	if !strings.Contains(err.Error(), `"2024-01-02" at index 1`) {
+		t.Errorf("expected error to name the element index and value, got: %v", err)
+	}
+}
+
+// TestParseEnvTimeSentinelDefaults tests the now and zero defaults of time.Time fields.
+func TestParseEnvTimeSentinelDefaults(t *testing.T) {
+	type SentinelConfig struct {
+		StartedAt time.Time `env:"SENTINEL_STARTED_AT,default=now"`
+		Expires   time.Time `env:"SENTINEL_EXPIRES,default=zero"`
+		Override  time.Time `env:"SENTINEL_OVERRIDE,default=now"`
+	}
+
+	before := time.Now().UTC()
+	cfg := &SentinelConfig{Expires: time.Now()}
+	err := ParseEnvFromMap(cfg, map[string]string{"SENTINEL_OVERRIDE": "2024-01-02T00:00:00Z"})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	after := time.Now().UTC()
+
+	if cfg.StartedAt.Before(before) || cfg.StartedAt.After(after) || cfg.StartedAt.Location() != time.UTC {
+		t.Errorf("expected StartedAt to be the current UTC time, got %v", cfg.StartedAt)
+	}
+	if !cfg.Expires.IsZero() {
+		t.Errorf("expected Expires to be the zero time, got %v", cfg.Expires)
+	}
+	if cfg.Override.Year() != 2024 {
+		t.Errorf("expected an explicit value to win over default=now, got %v", cfg.Override)
+	}
+}
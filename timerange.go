@@ -0,0 +1,53 @@
+package lazyconf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// timeRangeLayouts are the layouts accepted for both ends of a TimeRange.
+const timeRangeLayouts = "timeonly|15:04"
+
+// TimeRange is a span between two times of day, e.g. "09:00-17:00".
+// Populate it with the parser=timerange tag option.
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// String formats the range as "15:04:05-15:04:05", which parser=timerange reads back.
+func (r TimeRange) String() string {
+	return r.Start.Format(time.TimeOnly) + "-" + r.End.Format(time.TimeOnly)
+}
+
+// setTimeRange parses envVal as "start-end" times of day and stores it in the TimeRange fieldValue.
+// With strict, a start after the end is an error, otherwise such a range spans midnight.
+func setTimeRange(fieldValue reflect.Value, envVal string, strict bool) error {
+	start, end, ok := strings.Cut(envVal, "-")
+	if !ok {
+		return fmt.Errorf("range %q is missing a dash between start and end", envVal)
+	}
+	var r TimeRange
+	var err error
+	if r.Start, err = parseTime(strings.TrimSpace(start), timeRangeLayouts); err != nil {
+		return fmt.Errorf("invalid start of range %q: %v", envVal, err)
+	}
+	if r.End, err = parseTime(strings.TrimSpace(end), timeRangeLayouts); err != nil {
+		return fmt.Errorf("invalid end of range %q: %v", envVal, err)
+	}
+	if strict && r.Start.After(r.End) {
+		return fmt.Errorf("start of range %q is after its end", envVal)
+	}
+	fieldValue.Set(reflect.ValueOf(r))
+	return nil
+}
+
+// checkTimeRange reports whether the type is TimeRange or a slice of TimeRange.
+func checkTimeRange(fieldType reflect.Type) bool {
+	if fieldType.Kind() == reflect.Slice {
+		fieldType = fieldType.Elem()
+	}
+	return fieldType == reflect.TypeOf(TimeRange{})
+}
@@ -0,0 +1,71 @@
+package lazyconf
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseEnvTimeRange tests parsing TimeRange fields with parser=timerange.
+func TestParseEnvTimeRange(t *testing.T) {
+	type TimeRangeConfig struct {
+		Office    TimeRange   `env:"TIMERANGE_OFFICE,parser=timerange"`
+		Night     TimeRange   `env:"TIMERANGE_NIGHT,parser=timerange"`
+		Strict    TimeRange   `env:"TIMERANGE_STRICT,parser=timerange,strict"`
+		Maintains []TimeRange `env:"TIMERANGE_MAINTAINS,parser=timerange"`
+	}
+
+	cfg := &TimeRangeConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"TIMERANGE_OFFICE":    "09:00-17:30",
+		"TIMERANGE_NIGHT":     "22:00-06:00",
+		"TIMERANGE_STRICT":    "08:00:15-08:30:00",
+		"TIMERANGE_MAINTAINS": "01:00-02:00,13:00-13:15",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	if got := cfg.Office.String(); got != "09:00:00-17:30:00" {
+		t.Errorf("expected Office to be 09:00:00-17:30:00, got %s", got)
+	}
+	if !cfg.Night.Start.After(cfg.Night.End) {
+		t.Errorf("expected Night to span midnight, got %s", cfg.Night)
+	}
+	if got := cfg.Strict.String(); got != "08:00:15-08:30:00" {
+		t.Errorf("expected Strict to be 08:00:15-08:30:00, got %s", got)
+	}
+	if len(cfg.Maintains) != 2 || cfg.Maintains[1].String() != "13:00:00-13:15:00" {
+		t.Errorf("expected two Maintains ranges ending with 13:00:00-13:15:00, got %v", cfg.Maintains)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"missing dash", map[string]string{"TIMERANGE_OFFICE": "09:00"}, "missing a dash"},
+		{"invalid start", map[string]string{"TIMERANGE_OFFICE": "9am-17:00"}, "invalid start"},
+		{"invalid end", map[string]string{"TIMERANGE_OFFICE": "09:00-5pm"}, "invalid end"},
+		{"start after end", map[string]string{"TIMERANGE_STRICT": "18:00-09:00"}, "is after its end"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvFromMap(&TimeRangeConfig{}, tt.env)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+
+	env, err := DumpEnv(cfg)
+	if err != nil {
+		t.Fatalf("DumpEnv returned an error: %v", err)
+	}
+	roundTrip := &TimeRangeConfig{}
+	if err := ParseEnvFromMap(roundTrip, env); err != nil {
+		t.Fatalf("ParseEnv of the dumped values returned an error: %v", err)
+	}
+	if roundTrip.Office != cfg.Office || len(roundTrip.Maintains) != 2 {
+		t.Errorf("expected the dumped ranges to parse back, got %v and %v", roundTrip.Office, roundTrip.Maintains)
+	}
+}
@@ -0,0 +1,55 @@
+package lazyconf
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// TriState is a boolean that also records whether it was specified at all.
+// Populate it with the parser=tribool tag option.
+type TriState int
+
+const (
+	// TriStateUnset means the variable was not set, or set to an empty value.
+	TriStateUnset TriState = iota
+	// TriStateTrue means the variable was set to a truthy value.
+	TriStateTrue
+	// TriStateFalse means the variable was set to a falsy value.
+	TriStateFalse
+)
+
+// String returns "unset", "true" or "false".
+func (t TriState) String() string {
+	switch t {
+	case TriStateUnset:
+		return "unset"
+	case TriStateTrue:
+		return "true"
+	case TriStateFalse:
+		return "false"
+	}
+	return fmt.Sprintf("TriState(%d)", int(t))
+}
+
+// setTriState parses envVal as a boolean, honoring the custom true/false tokens, and stores it in the
+// TriState fieldValue.
+func setTriState(fieldValue reflect.Value, envVal, trueToken, falseToken string) error {
+	b, err := parseBool(envVal, trueToken, falseToken)
+	if err != nil {
+		return err
+	}
+	if b {
+		fieldValue.SetInt(int64(TriStateTrue))
+	} else {
+		fieldValue.SetInt(int64(TriStateFalse))
+	}
+	return nil
+}
+
+// checkTriState reports whether the type is TriState or a slice of TriState.
+func checkTriState(fieldType reflect.Type) bool {
+	if fieldType.Kind() == reflect.Slice {
+		fieldType = fieldType.Elem()
+	}
+	return fieldType == reflect.TypeOf(TriStateUnset)
+}
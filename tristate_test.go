@@ -0,0 +1,62 @@
+package lazyconf
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseEnvTriState tests parsing TriState fields with parser=tribool.
+func TestParseEnvTriState(t *testing.T) {
+	type TriStateConfig struct {
+		Enabled  TriState   `env:"TRISTATE_ENABLED,parser=tribool"`
+		Disabled TriState   `env:"TRISTATE_DISABLED,parser=tribool"`
+		Unset    TriState   `env:"TRISTATE_UNSET,parser=tribool"`
+		Empty    TriState   `env:"TRISTATE_EMPTY,parser=tribool,required"`
+		Custom   TriState   `env:"TRISTATE_CUSTOM,parser=tribool,true=on,false=off"`
+		Flags    []TriState `env:"TRISTATE_FLAGS,parser=tribool"`
+	}
+
+	cfg := &TriStateConfig{}
+	err := ParseEnvFromMap(cfg, map[string]string{
+		"TRISTATE_ENABLED":  "true",
+		"TRISTATE_DISABLED": "0",
+		"TRISTATE_EMPTY":    "",
+		"TRISTATE_CUSTOM":   "off",
+		"TRISTATE_FLAGS":    "true,false",
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+
+	expected := map[string]TriState{
+		"Enabled":  TriStateTrue,
+		"Disabled": TriStateFalse,
+		"Unset":    TriStateUnset,
+		"Empty":    TriStateUnset,
+		"Custom":   TriStateFalse,
+	}
+	for name, want := range expected {
+		if got := reflect.ValueOf(cfg).Elem().FieldByName(name).Interface().(TriState); got != want {
+			t.Errorf("expected %s to be %v, got %v", name, want, got)
+		}
+	}
+	if !reflect.DeepEqual(cfg.Flags, []TriState{TriStateTrue, TriStateFalse}) {
+		t.Errorf("expected Flags to be [true false], got %v", cfg.Flags)
+	}
+
+	err = ParseEnvFromMap(&TriStateConfig{}, map[string]string{"TRISTATE_EMPTY": "maybe"})
+	if err == nil {
+		t.Fatal("expected an error for an invalid tribool value, but got none")
+	}
+	err = ParseEnvFromMap(&TriStateConfig{}, nil)
+	if err == nil {
+		t.Fatal("expected an error for an unset required tribool, but got none")
+	}
+}
+
+// TestTriStateString tests the string form of TriState values.
+func TestTriStateString(t *testing.T) {
+	if TriStateUnset.String() != "unset" || TriStateTrue.String() != "true" || TriStateFalse.String() != "false" {
+		t.Errorf("expected unset, true and false, got %v, %v and %v", TriStateUnset, TriStateTrue, TriStateFalse)
+	}
+}
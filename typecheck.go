@@ -0,0 +1,215 @@
+package lazyconf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// validateTypes walks the struct type and returns an error for the first field ParseEnv could not
+// populate, whether or not its variable is set. It mirrors the checks of ParseEnvWithOptions.
+func validateTypes(structType reflect.Type, opts ParseEnvOptions, visited map[reflect.Type]bool) error {
+	op := "xconf.ParseEnv"
+
+	if visited[structType] {
+		return nil
+	}
+	visited[structType] = true
+
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		tag := field.Tag.Get("env")
+		if isIgnoredTag(tag) {
+			continue
+		}
+
+		// Nested structs are checked recursively, like they are parsed
+		if isNestedStruct(field, tag) {
+			if err := validateTypes(field.Type, opts.withPath(field.Name), visited); err != nil {
+				return err
+			}
+			if key, _, _ := strings.Cut(tag, ","); key == "" {
+				continue
+			}
+		}
+		if field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct && !checkRegexp(field.Type) {
+			if field.IsExported() {
+				if err := validateTypes(field.Type.Elem(), opts.withPath(field.Name), visited); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		key, _, _ := strings.Cut(tag, ",")
+		if key == "" && (opts.KeyFromField == nil || !field.IsExported() || (field.Type.Kind() == reflect.Struct && !isValueStruct(field.Type))) {
+			continue
+		}
+
+		if !field.IsExported() {
+			return fmt.Errorf("%s: field %s is not exported", op, opts.path+field.Name)
+		}
+		if hasTagOption(tag, "indexed") && isIndexedStructSlice(field.Type) {
+			if err := validateTypes(field.Type.Elem(), opts.withPath(field.Name), visited); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := checkFieldType(structType, field, opts); err != nil {
+			return fmt.Errorf("%s: field %s: %v", op, opts.path+field.Name, err)
+		}
+	}
+	return nil
+}
+
+// checkFieldType reports an error if the tagged field can't be populated given its type and tag options.
+func checkFieldType(structType reflect.Type, field reflect.StructField, opts ParseEnvOptions) error {
+	fieldType := field.Type
+
+	var parserType, setterName, decoderName string
+	hasRegistry := false
+	for _, opt := range splitTag(field.Tag.Get("env"))[1:] {
+		if strings.HasPrefix(opt, "parser=") {
+			parserType = strings.TrimPrefix(opt, "parser=")
+		} else if strings.HasPrefix(opt, "setter=") {
+			setterName = strings.TrimPrefix(opt, "setter=")
+		} else if strings.HasPrefix(opt, "decoder=") {
+			decoderName = strings.TrimPrefix(opt, "decoder=")
+		} else if strings.HasPrefix(opt, "registry=") {
+			hasRegistry = true
+		}
+	}
+
+	if setterName != "" {
+		if _, ok := reflect.PointerTo(structType).MethodByName(setterName); !ok {
+			return fmt.Errorf("setter method '%s' not found", setterName)
+		}
+		return nil
+	}
+	if decoderName != "" {
+		decoder := reflect.New(structType).MethodByName(decoderName)
+		if !decoder.IsValid() {
+			return fmt.Errorf("decoder method '%s' not found", decoderName)
+		}
+		if err := checkDecoderType(decoder.Type(), fieldType); err != nil {
+			return fmt.Errorf("decoder method '%s' %v", decoderName, err)
+		}
+		return nil
+	}
+	if hasRegistry && (fieldType.Kind() == reflect.Func || fieldType.Kind() == reflect.Interface) {
+		return nil
+	}
+	if _, ok := setterMethodType(reflect.PointerTo(fieldType), opts.setterMethod()); ok {
+		return nil
+	}
+	if checkStringParser(fieldType) || isRegisteredType(fieldType) {
+		return nil
+	}
+	if _, ok := checkAtomicStore(fieldType); ok {
+		return nil
+	}
+	if parserType != "" {
+		for _, name := range strings.Split(parserType, "|") {
+			if !parserSupports(name, fieldType) {
+				return fmt.Errorf("parser=%s does not support type %s", name, fieldType)
+			}
+		}
+		return nil
+	}
+	if !opts.DisableUnmarshalFallback && (checkTextUnmarshaler(fieldType) || checkJSONUnmarshaler(fieldType)) {
+		return nil
+	}
+	if opts.AutoJSON && checkJSONContainer(fieldType) {
+		return nil
+	}
+	if !checkValueType(fieldType, opts) {
+		return fmt.Errorf("unsupported type %s", fieldType)
+	}
+	return nil
+}
+
+// parserSupports reports whether applyParser accepts the named parser for the type.
+func parserSupports(name string, fieldType reflect.Type) bool {
+	switch name {
+	case "text":
+		return checkTextUnmarshaler(fieldType)
+	case "json":
+		return checkJSONUnmarshaler(fieldType) || fieldType.Kind() == reflect.Slice
+	case "bytesize", "hexnum", "be", "le":
+		return checkIntegerKind(fieldType)
+	case "percent":
+		return checkFloatKind(fieldType)
+	case "duration":
+		return checkDurationKind(fieldType)
+	case "number":
+		return (checkIntegerKind(fieldType) || checkFloatKind(fieldType)) && fieldType.Kind() != reflect.Slice
+	case "reltime":
+		return checkTime(fieldType)
+	case "tribool":
+		return checkTriState(fieldType)
+	case "hex", "base64", "ints":
+		return checkBytes(fieldType)
+	case "durationrange":
+		return checkDurationRange(fieldType)
+	case "timerange":
+		return checkTimeRange(fieldType)
+	case "decimal":
+		return checkDecimal(fieldType)
+	case "kv":
+		return fieldType.Kind() == reflect.Struct
+	case "yaml":
+		return checkJSONContainer(fieldType)
+	}
+	return false
+}
+
+// checkValueType reports whether the built-in conversions handle the type.
+func checkValueType(fieldType reflect.Type, opts ParseEnvOptions) bool {
+	switch fieldType.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	case reflect.Struct:
+		return checkTime(fieldType)
+	case reflect.Ptr:
+		return checkRegexp(fieldType)
+	case reflect.Slice:
+		return checkSliceElementType(fieldType, opts)
+	case reflect.Map:
+		return checkURLValues(fieldType) || checkPrimitive(fieldType.Key()) && (checkSet(fieldType) || checkPrimitive(fieldType.Elem()))
+	}
+	return false
+}
+
+// checkSliceElementType reports whether the elements of the slice type can be parsed.
+func checkSliceElementType(sliceType reflect.Type, opts ParseEnvOptions) bool {
+	if checkSliceElementsSetter(sliceType, opts.setterMethod()) || checkStringParser(sliceType.Elem()) || isRegisteredType(sliceType.Elem()) {
+		return true
+	}
+	elemType := sliceType.Elem()
+	switch elemType.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Struct:
+		return checkTime(elemType) || (!opts.DisableUnmarshalFallback && (checkTextUnmarshaler(elemType) || checkJSONUnmarshaler(elemType)))
+	case reflect.Ptr:
+		return checkRegexp(elemType)
+	case reflect.Slice:
+		return checkPrimitive(elemType.Elem())
+	}
+	return false
+}
+
+// checkJSONContainer reports whether AutoJSON can decode a JSON object or array into the type.
+func checkJSONContainer(fieldType reflect.Type) bool {
+	switch fieldType.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Ptr, reflect.Interface:
+		return true
+	}
+	return false
+}
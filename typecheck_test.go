@@ -0,0 +1,77 @@
+package lazyconf
+
+import (
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestParseEnvValidateTypesUpfront tests that unsupported field types fail even when their variables are unset.
+func TestParseEnvValidateTypesUpfront(t *testing.T) {
+	type NestedUnsupported struct {
+		Ch chan int `env:"UPFRONT_NESTED_CH"`
+	}
+
+	tests := []struct {
+		name    string
+		cfg     any
+		wantErr bool
+	}{
+		{"supported", &struct {
+			Name     string            `env:"UPFRONT_NAME"`
+			Ports    []int             `env:"UPFRONT_PORTS"`
+			Matrix   [][]string        `env:"UPFRONT_MATRIX"`
+			Started  time.Time         `env:"UPFRONT_STARTED"`
+			Timeout  time.Duration     `env:"UPFRONT_TIMEOUT"`
+			Level    LevelType         `env:"UPFRONT_LEVEL"`
+			Payload  JSONRoundTripType `env:"UPFRONT_PAYLOAD,parser=json"`
+			Size     uint64            `env:"UPFRONT_SIZE,parser=bytesize"`
+			Pattern  *regexp.Regexp    `env:"UPFRONT_PATTERN"`
+			Limit    atomic.Int64      `env:"UPFRONT_LIMIT"`
+			DB       KVDatabase        `env:"UPFRONT_DB,parser=kv"`
+			Nested   DumpNestedConfig
+			Optional *DumpNestedConfig
+			Skipped  chan int `env:"-"`
+			Untagged chan int
+		}{}, false},
+		{"unsupported kind", &struct {
+			Ch chan int `env:"UPFRONT_CH"`
+		}{}, true},
+		{"unsupported struct", &struct {
+			Nested DumpNestedConfig `env:"UPFRONT_STRUCT"`
+		}{}, true},
+		{"unsupported slice element", &struct {
+			Maps []map[string]string `env:"UPFRONT_MAPS"`
+		}{}, true},
+		{"unsupported parser", &struct {
+			Name string `env:"UPFRONT_PARSER,parser=bytesize"`
+		}{}, true},
+		{"missing setter", &struct {
+			Name string `env:"UPFRONT_SETTER,setter=SetName"`
+		}{}, true},
+		{"nested", &struct {
+			Nested *NestedUnsupported
+		}{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseEnvWithOptions(tt.cfg, ParseEnvOptions{
+				Lookup:               mapLookup(nil),
+				ValidateTypesUpfront: true,
+			})
+			if tt.wantErr && err == nil {
+				t.Fatal("expected an error for an unsupported field type, but got none")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("ParseEnvWithOptions returned an error: %v", err)
+			}
+
+			// Without the upfront check unset variables hide the unsupported types
+			if err := ParseEnvWithOptions(tt.cfg, ParseEnvOptions{Lookup: mapLookup(nil)}); err != nil && tt.name != "missing setter" {
+				t.Errorf("expected no error without ValidateTypesUpfront, got: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package lazyconf
+
+import (
+	"reflect"
+	"sync"
+)
+
+// typeParsers holds the parsers registered with RegisterType, keyed by the type they produce.
+var typeParsers sync.Map // map[reflect.Type]func(string) (reflect.Value, error)
+
+// RegisterType registers parse as the parser for fields and slice elements of type T. It is meant
+// for types that can't implement Setter or the unmarshalers, such as those of other packages.
+// A registered parser takes precedence over the unmarshalers of T and replaces any parser
+// registered for T before. color.RGBA is registered by default.
+func RegisterType[T any](parse func(s string) (T, error)) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	typeParsers.Store(typ, func(s string) (reflect.Value, error) {
+		v, err := parse(s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(&v).Elem(), nil
+	})
+}
+
+// registeredParser returns the parser registered for the type, if any.
+func registeredParser(fieldType reflect.Type) (func(string) (reflect.Value, error), bool) {
+	parse, ok := typeParsers.Load(fieldType)
+	if !ok {
+		return nil, false
+	}
+	return parse.(func(string) (reflect.Value, error)), true
+}
+
+// isRegisteredType reports whether a parser is registered for the type.
+func isRegisteredType(fieldType reflect.Type) bool {
+	_, ok := typeParsers.Load(fieldType)
+	return ok
+}
@@ -0,0 +1,41 @@
+package lazyconf
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// Celsius stands in for a type of another package, parsed through RegisterType
+type Celsius struct {
+	Degrees float64
+}
+
+// TestRegisterType tests that registered parsers are used for fields and slice elements of the type.
+func TestRegisterType(t *testing.T) {
+	RegisterType(func(s string) (Celsius, error) {
+		var c Celsius
+		_, err := fmt.Sscanf(s, "%fC", &c.Degrees)
+		return c, err
+	})
+
+	type RegisteredConfig struct {
+		Max    Celsius   `env:"REGISTERED_MAX"`
+		Limits []Celsius `env:"REGISTERED_LIMITS"`
+	}
+
+	cfg := &RegisteredConfig{}
+	err := ParseEnvWithOptions(cfg, ParseEnvOptions{
+		Lookup:               mapLookup(map[string]string{"REGISTERED_MAX": "21.5C", "REGISTERED_LIMITS": "-5C,40C"}),
+		ValidateTypesUpfront: true,
+	})
+	if err != nil {
+		t.Fatalf("ParseEnv returned an error: %v", err)
+	}
+	if cfg.Max.Degrees != 21.5 {
+		t.Errorf("expected Max to be 21.5, got %v", cfg.Max.Degrees)
+	}
+	if !reflect.DeepEqual(cfg.Limits, []Celsius{{-5}, {40}}) {
+		t.Errorf("expected Limits to be [-5 40], got %v", cfg.Limits)
+	}
+}